@@ -0,0 +1,24 @@
+package backoff
+
+import "time"
+
+// Exponential returns the delay before the next attempt after the given number of attempts.
+// The delay doubles with every attempt starting from base and is capped at max.
+func Exponential(attempts int, base, max time.Duration) time.Duration {
+	if attempts < 1 {
+		return base
+	}
+
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+
+	if delay > max {
+		return max
+	}
+	return delay
+}
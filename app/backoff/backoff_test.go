@@ -0,0 +1,22 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponential(t *testing.T) {
+	base, max := time.Minute, 10*time.Minute
+
+	assert.Equal(t, time.Minute, Exponential(0, base, max))
+	assert.Equal(t, time.Minute, Exponential(1, base, max))
+	assert.Equal(t, 2*time.Minute, Exponential(2, base, max))
+	assert.Equal(t, 8*time.Minute, Exponential(4, base, max))
+	assert.Equal(t, max, Exponential(5, base, max))
+	assert.Equal(t, max, Exponential(100, base, max))
+
+	// Case: A base above the maximum is capped
+	assert.Equal(t, time.Second, Exponential(1, time.Minute, time.Second))
+}
@@ -0,0 +1,220 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Backstage entity kinds imported as services
+const (
+	KindComponent = "Component"
+	KindAPI       = "API"
+	KindSystem    = "System"
+)
+
+// backstageAPIVersion is the apiVersion of the entities written by the export
+const backstageAPIVersion = "backstage.io/v1alpha1"
+
+// Annotations added to the exported entities
+const (
+	AnnotationServiceID     = "service-catalog/service-id"
+	AnnotationLatestVersion = "service-catalog/latest-version"
+)
+
+// entityNameRegexp matches the characters which are not allowed in the name of a Backstage entity
+var entityNameRegexp = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// maxEntityNameLength is the maximum length of the name of a Backstage entity
+const maxEntityNameLength = 63
+
+// Entity is a Backstage descriptor such as a catalog-info.yaml (https://backstage.io/docs/features/software-catalog/descriptor-format)
+type Entity struct {
+	APIVersion string                 `yaml:"apiVersion" json:"apiVersion"`
+	Kind       string                 `yaml:"kind" json:"kind"`
+	Metadata   map[string]interface{} `yaml:"metadata" json:"metadata"`
+	Spec       map[string]interface{} `yaml:"spec,omitempty" json:"spec,omitempty"`
+}
+
+// DecodeBackstage reads the entities of a catalog-info.yaml, the file may contain multiple YAML documents
+func DecodeBackstage(r io.Reader) ([]Entity, error) {
+	var entities []Entity
+
+	decoder := yaml.NewDecoder(r)
+	for i := 0; ; i++ {
+		var entity Entity
+
+		err := decoder.Decode(&entity)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+
+		// Empty documents such as a trailing `---`
+		if entity.Kind == "" && entity.APIVersion == "" && entity.Metadata == nil {
+			continue
+		}
+
+		entities = append(entities, entity)
+	}
+
+	return entities, nil
+}
+
+// EncodeBackstage writes the entities as a multi document YAML file, the file is empty when there are no entities
+func EncodeBackstage(w io.Writer, entities []Entity) error {
+	// The encoder fails to close a stream without documents
+	if len(entities) == 0 {
+		return nil
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	for _, entity := range entities {
+		if err := encoder.Encode(entity); err != nil {
+			return err
+		}
+	}
+
+	return encoder.Close()
+}
+
+// FromEntities converts the Backstage entities into a catalog document.
+// Entities of kinds other than Component, API and System are not imported and are returned as skipped.
+func FromEntities(entities []Entity) (Document, []RowError) {
+	var (
+		document Document
+		skipped  []RowError
+	)
+
+	for i, entity := range entities {
+		row := fmt.Sprintf("document[%d]", i)
+
+		if !strings.HasPrefix(entity.APIVersion, "backstage.io/") {
+			skipped = append(skipped, RowError{Row: row, Field: "apiVersion", Msg: fmt.Sprintf("unsupported apiVersion %q", entity.APIVersion)})
+			continue
+		}
+
+		switch entity.Kind {
+		case KindComponent, KindAPI, KindSystem:
+		default:
+			skipped = append(skipped, RowError{Row: row, Field: "kind", Msg: fmt.Sprintf("unsupported kind %q", entity.Kind)})
+			continue
+		}
+
+		metadata := make(map[string]interface{}, len(entity.Metadata))
+		for key, value := range entity.Metadata {
+			metadata[key] = value
+		}
+
+		name, _ := metadata["name"].(string)
+		description, _ := metadata["description"].(string)
+		delete(metadata, "name")
+		delete(metadata, "description")
+
+		// The ids of the export are not imported as they belong to the source catalog
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, AnnotationServiceID)
+			delete(annotations, AnnotationLatestVersion)
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+
+		document.Services = append(document.Services, Service{
+			Name:        name,
+			Description: strings.TrimSpace(description),
+			Row:         row,
+			Entity: &Entity{
+				APIVersion: entity.APIVersion,
+				Kind:       entity.Kind,
+				Metadata:   metadata,
+				Spec:       entity.Spec,
+			},
+		})
+	}
+
+	return document, skipped
+}
+
+// ToEntity renders a service as a Backstage entity.
+// Services which were not imported from Backstage are rendered as a Component of type service.
+func ToEntity(service Service, owner string, annotations map[string]string) Entity {
+	entity := Entity{
+		APIVersion: backstageAPIVersion,
+		Kind:       KindComponent,
+		Metadata:   map[string]interface{}{},
+		Spec:       map[string]interface{}{},
+	}
+
+	if service.Entity != nil {
+		entity.APIVersion = service.Entity.APIVersion
+		entity.Kind = service.Entity.Kind
+		for key, value := range service.Entity.Metadata {
+			entity.Metadata[key] = value
+		}
+		for key, value := range service.Entity.Spec {
+			entity.Spec[key] = value
+		}
+	}
+
+	name := EntityName(service.Name)
+	entity.Metadata["name"] = name
+	if service.Description != "" {
+		entity.Metadata["description"] = service.Description
+	}
+	if _, ok := entity.Metadata["title"]; !ok && name != service.Name {
+		entity.Metadata["title"] = service.Name
+	}
+
+	if len(annotations) > 0 {
+		merged := map[string]interface{}{}
+		if existing, ok := entity.Metadata["annotations"].(map[string]interface{}); ok {
+			for key, value := range existing {
+				merged[key] = value
+			}
+		}
+		for key, value := range annotations {
+			merged[key] = value
+		}
+		entity.Metadata["annotations"] = merged
+	}
+
+	// Fields required by the Backstage schema of each kind
+	setDefault(entity.Spec, "owner", owner)
+	switch entity.Kind {
+	case KindComponent:
+		setDefault(entity.Spec, "type", "service")
+		setDefault(entity.Spec, "lifecycle", "production")
+	case KindAPI:
+		setDefault(entity.Spec, "type", "openapi")
+		setDefault(entity.Spec, "lifecycle", "production")
+		setDefault(entity.Spec, "definition", "")
+	}
+
+	return entity
+}
+
+// EntityName converts a service name into a valid Backstage entity name
+func EntityName(name string) string {
+	name = entityNameRegexp.ReplaceAllString(strings.TrimSpace(name), "-")
+	if len(name) > maxEntityNameLength {
+		name = name[:maxEntityNameLength]
+	}
+
+	// Names must start and end with an alphanumeric character
+	return strings.Trim(name, "-_.")
+}
+
+func setDefault(values map[string]interface{}, key string, value interface{}) {
+	if current, ok := values[key]; !ok || current == nil || current == "" {
+		values[key] = value
+	}
+}
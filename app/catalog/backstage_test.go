@@ -0,0 +1,108 @@
+package catalog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const catalogInfo = `apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: backend
+  description: this service has the backend
+  tags: [go]
+  annotations:
+    service-catalog/service-id: "12"
+    github.com/project-slug: org/backend
+spec:
+  type: service
+  lifecycle: experimental
+  owner: group:platform
+---
+apiVersion: backstage.io/v1alpha1
+kind: Group
+metadata:
+  name: platform
+spec:
+  type: team
+  children: []
+---
+apiVersion: backstage.io/v1alpha1
+kind: API
+metadata:
+  name: backend-api
+  description: the public api of the backend
+spec:
+  type: openapi
+  lifecycle: production
+  owner: group:platform
+  definition: "openapi: 3.0.0"
+---
+`
+
+func TestFromEntities(t *testing.T) {
+	entities, err := DecodeBackstage(strings.NewReader(catalogInfo))
+	if err != nil {
+		t.Fatal("Failed to decode entities:", err)
+	}
+	assert.Len(t, entities, 3)
+
+	document, skipped := FromEntities(entities)
+	assert.Len(t, document.Services, 2)
+	assert.Equal(t, []RowError{{Row: "document[1]", Field: "kind", Msg: `unsupported kind "Group"`}}, skipped)
+	assert.Empty(t, Validate(document))
+
+	backend := document.Services[0]
+	assert.Equal(t, "backend", backend.Name)
+	assert.Equal(t, "this service has the backend", backend.Description)
+	assert.Equal(t, "document[0]", backend.Row)
+	assert.Equal(t, KindComponent, backend.Entity.Kind)
+	assert.NotContains(t, backend.Entity.Metadata, "name")
+	assert.Equal(t, map[string]interface{}{"github.com/project-slug": "org/backend"}, backend.Entity.Metadata["annotations"])
+
+	assert.Equal(t, KindAPI, document.Services[1].Entity.Kind)
+}
+
+func TestToEntity(t *testing.T) {
+	// Case: Service created through the API
+	entity := ToEntity(Service{Name: "payment service", Description: "this service takes payments"}, "user:test-gmail.com", map[string]string{
+		AnnotationServiceID: "3",
+	})
+
+	assert.Equal(t, "backstage.io/v1alpha1", entity.APIVersion)
+	assert.Equal(t, KindComponent, entity.Kind)
+	assert.Equal(t, "payment-service", entity.Metadata["name"])
+	assert.Equal(t, "payment service", entity.Metadata["title"])
+	assert.Equal(t, map[string]interface{}{AnnotationServiceID: "3"}, entity.Metadata["annotations"])
+	assert.Equal(t, map[string]interface{}{"type": "service", "lifecycle": "production", "owner": "user:test-gmail.com"}, entity.Spec)
+
+	// Case: Service imported from Backstage keeps it's kind and spec
+	entities, _ := DecodeBackstage(strings.NewReader(catalogInfo))
+	document, _ := FromEntities(entities)
+	entity = ToEntity(document.Services[1], "user:test-gmail.com", nil)
+
+	assert.Equal(t, KindAPI, entity.Kind)
+	assert.Equal(t, "group:platform", entity.Spec["owner"])
+	assert.Equal(t, "openapi: 3.0.0", entity.Spec["definition"])
+
+	var buf bytes.Buffer
+	if err := EncodeBackstage(&buf, []Entity{entity, entity}); err != nil {
+		t.Fatal("Failed to encode entities:", err)
+	}
+	assert.Contains(t, buf.String(), "---\n")
+	assert.Contains(t, buf.String(), "kind: API")
+
+	// Case: An empty catalog is an empty file
+	buf.Reset()
+	assert.NoError(t, EncodeBackstage(&buf, nil))
+	assert.Empty(t, buf.String())
+}
+
+func TestEntityName(t *testing.T) {
+	assert.Equal(t, "test-gmail.com", EntityName("test@gmail.com"))
+	assert.Equal(t, "payment-service", EntityName(" payment service! "))
+	assert.Len(t, EntityName(strings.Repeat("a", 100)), 63)
+}
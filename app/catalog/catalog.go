@@ -0,0 +1,311 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported document formats
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatCSV  = "csv"
+)
+
+// csvHeader is the header of a catalog in the CSV format, a row is written for each version of a service
+var csvHeader = []string{"service_name", "service_description", "version", "changelog"}
+
+// ErrUnsupportedFormat is returned when the format of the document is not supported
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// Document is the catalog of services with their versions
+type Document struct {
+	Services []Service `json:"services" yaml:"services"`
+}
+
+// Service is a service of the catalog
+type Service struct {
+	Name        string    `json:"name" yaml:"name" validate:"required,min=3,max=255"`
+	Description string    `json:"description" yaml:"description" validate:"required,min=20"`
+	Versions    []Version `json:"versions" yaml:"versions,omitempty"`
+
+	// Row is the location of the service in the document, used to report errors
+	Row string `json:"-" yaml:"-" validate:"-"`
+
+	// Entity is the Backstage descriptor of the service, see FromEntities
+	Entity *Entity `json:"-" yaml:"-" validate:"-"`
+}
+
+// Version is a version of a service of the catalog
+type Version struct {
+	Version   string   `json:"version" yaml:"version" validate:"required,min=2,max=64"`
+	Changelog string   `json:"changelog,omitempty" yaml:"changelog,omitempty" validate:"required_without=Changes,omitempty,min=10"`
+	Changes   []Change `json:"changes,omitempty" yaml:"changes,omitempty" validate:"omitempty,min=1,dive"`
+
+	// Row is the location of the version in the document, used to report errors
+	Row string `json:"-" yaml:"-" validate:"-"`
+}
+
+// Change is a categorized changelog entry of a version
+type Change struct {
+	Category    string `json:"category" yaml:"category" validate:"required,oneof=Added Changed Deprecated Removed Fixed Security"`
+	Description string `json:"description" yaml:"description" validate:"required"`
+}
+
+// FormatFromMediaType returns the format of a media type such as `text/csv; charset=utf-8`
+func FormatFromMediaType(mediaType string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return "", ErrUnsupportedFormat
+	}
+
+	switch mediaType {
+	case "application/json":
+		return FormatJSON, nil
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return FormatYAML, nil
+	case "text/csv":
+		return FormatCSV, nil
+	}
+
+	return "", ErrUnsupportedFormat
+}
+
+// MediaType returns the media type used to write a document in the given format
+func MediaType(format string) string {
+	switch format {
+	case FormatYAML:
+		return "application/yaml"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	}
+
+	return "application/json; charset=utf-8"
+}
+
+// Decode reads a document in the given format
+func Decode(format string, r io.Reader) (Document, error) {
+	var (
+		document Document
+		err      error
+	)
+
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&document)
+	case FormatYAML:
+		decoder := yaml.NewDecoder(r)
+		decoder.KnownFields(true)
+		err = decoder.Decode(&document)
+		if err == io.EOF {
+			err = nil
+		}
+	case FormatCSV:
+		return decodeCSV(r)
+	default:
+		return Document{}, ErrUnsupportedFormat
+	}
+
+	if err != nil {
+		return Document{}, err
+	}
+
+	for i := range document.Services {
+		service := &document.Services[i]
+		service.Row = fmt.Sprintf("services[%d]", i)
+		for j := range service.Versions {
+			service.Versions[j].Row = fmt.Sprintf("services[%d].versions[%d]", i, j)
+		}
+	}
+
+	return document, nil
+}
+
+// decodeCSV reads a document in the CSV format, rows of the same service are grouped in the order they appear
+func decodeCSV(r io.Reader) (Document, error) {
+	var document Document
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(csvHeader)
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return document, nil
+	}
+	if err != nil {
+		return Document{}, err
+	}
+
+	for i, column := range csvHeader {
+		if strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")) != column {
+			return Document{}, fmt.Errorf("invalid header, expected %q", strings.Join(csvHeader, ","))
+		}
+	}
+
+	indexByName := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Document{}, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := fmt.Sprintf("row %d", line)
+
+		name := strings.TrimSpace(record[0])
+		description := strings.TrimSpace(record[1])
+		index, ok := indexByName[name]
+		if ok && description != "" && description != document.Services[index].Description {
+			return Document{}, fmt.Errorf("%s: description of service %q differs from %s", row, name, document.Services[index].Row)
+		}
+		if !ok {
+			document.Services = append(document.Services, Service{
+				Name:        name,
+				Description: description,
+				Row:         row,
+			})
+			index = len(document.Services) - 1
+			indexByName[name] = index
+		}
+
+		if strings.TrimSpace(record[2]) == "" {
+			continue
+		}
+
+		document.Services[index].Versions = append(document.Services[index].Versions, Version{
+			Version:   strings.TrimSpace(record[2]),
+			Changelog: record[3],
+			Row:       row,
+		})
+	}
+
+	return document, nil
+}
+
+// Encode writes the document in the given format
+func Encode(format string, w io.Writer, document Document) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(document)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(document); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case FormatCSV:
+		return encodeCSV(w, document)
+	}
+
+	return ErrUnsupportedFormat
+}
+
+// encodeCSV writes the document in the CSV format, structured changes are only written through the changelog
+func encodeCSV(w io.Writer, document Document) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, service := range document.Services {
+		if len(service.Versions) == 0 {
+			if err := writer.Write([]string{service.Name, service.Description, "", ""}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, version := range service.Versions {
+			if err := writer.Write([]string{service.Name, service.Description, version.Version, version.Changelog}); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// RowError is a validation error of a service or a version of the document
+type RowError struct {
+	Row   string `json:"row"`
+	Field string `json:"field,omitempty"`
+	Msg   string `json:"msg"`
+}
+
+// Validate validates every service and version of the document, all the errors are returned
+func Validate(document Document) []RowError {
+	var rowErrors []RowError
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.Split(field.Tag.Get("json"), ",")[0]
+	})
+
+	addErrors := func(row string, err error) {
+		var validationErrors validator.ValidationErrors
+		if !errors.As(err, &validationErrors) {
+			rowErrors = append(rowErrors, RowError{Row: row, Msg: err.Error()})
+			return
+		}
+
+		for _, fieldErr := range validationErrors {
+			msg := fmt.Sprintf("failed on the '%s' tag", fieldErr.Tag())
+			if fieldErr.Param() != "" {
+				msg = fmt.Sprintf("failed on the '%s=%s' tag", fieldErr.Tag(), fieldErr.Param())
+			}
+
+			// Drop the struct name from the namespace, e.g. `Version.changes[0].category`
+			field := fieldErr.Namespace()
+			if i := strings.Index(field, "."); i >= 0 {
+				field = field[i+1:]
+			}
+
+			rowErrors = append(rowErrors, RowError{Row: row, Field: field, Msg: msg})
+		}
+	}
+
+	names := make(map[string]string)
+	for _, service := range document.Services {
+		if err := validate.Struct(service); err != nil {
+			addErrors(service.Row, err)
+		}
+
+		if row, ok := names[service.Name]; ok {
+			rowErrors = append(rowErrors, RowError{Row: service.Row, Field: "name", Msg: "duplicate of " + row})
+		}
+		names[service.Name] = service.Row
+
+		versions := make(map[string]string)
+		for _, version := range service.Versions {
+			if err := validate.Struct(version); err != nil {
+				addErrors(version.Row, err)
+			}
+
+			if row, ok := versions[version.Version]; ok {
+				rowErrors = append(rowErrors, RowError{Row: version.Row, Field: "version", Msg: "duplicate of " + row})
+			}
+			versions[version.Version] = version.Row
+		}
+	}
+
+	return rowErrors
+}
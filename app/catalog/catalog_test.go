@@ -0,0 +1,94 @@
+package catalog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var document = Document{
+	Services: []Service{
+		{
+			Name:        "backend",
+			Description: "this service has the backend",
+			Versions: []Version{
+				{Version: "v1.0.0", Changelog: "first stable release"},
+				{Version: "v1.1.0", Changelog: "### Added\n- Artifact lookup", Changes: []Change{{Category: "Added", Description: "Artifact lookup"}}},
+			},
+		},
+		{
+			Name:        "frontend",
+			Description: "this service is the frontend",
+		},
+	},
+}
+
+func TestEncodeDecode(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatYAML} {
+		var buf bytes.Buffer
+		if err := Encode(format, &buf, document); err != nil {
+			t.Fatal("Failed to encode document:", err)
+		}
+
+		decoded, err := Decode(format, &buf)
+		if err != nil {
+			t.Fatal("Failed to decode document:", err)
+		}
+
+		assert.Equal(t, "services[1]", decoded.Services[1].Row)
+		assert.Equal(t, "services[0].versions[1]", decoded.Services[0].Versions[1].Row)
+		assert.Equal(t, document.Services[0].Versions[1].Changes, decoded.Services[0].Versions[1].Changes)
+		assert.Equal(t, "frontend", decoded.Services[1].Name)
+		assert.Empty(t, Validate(decoded))
+	}
+}
+
+func TestDecodeCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(FormatCSV, &buf, document); err != nil {
+		t.Fatal("Failed to encode document:", err)
+	}
+
+	decoded, err := Decode(FormatCSV, &buf)
+	if err != nil {
+		t.Fatal("Failed to decode document:", err)
+	}
+
+	assert.Len(t, decoded.Services, 2)
+	assert.Len(t, decoded.Services[0].Versions, 2)
+	assert.Equal(t, "row 3", decoded.Services[0].Versions[1].Row)
+	assert.Equal(t, "### Added\n- Artifact lookup", decoded.Services[0].Versions[1].Changelog)
+	assert.Empty(t, decoded.Services[1].Versions)
+
+	// Case fail: Invalid header
+	_, err = Decode(FormatCSV, strings.NewReader("name,description\nbackend,backend\n"))
+	assert.Error(t, err)
+
+	// Case fail: Different descriptions for the same service
+	_, err = Decode(FormatCSV, strings.NewReader("service_name,service_description,version,changelog\nbackend,first description,,\nbackend,second description,,\n"))
+	assert.EqualError(t, err, `row 3: description of service "backend" differs from row 2`)
+}
+
+func TestValidate(t *testing.T) {
+	invalid, err := Decode(FormatJSON, strings.NewReader(`{"services": [
+		{"name": "backend", "description": "this service has the backend", "versions": [
+			{"version": "v1.0.0", "changelog": "short"},
+			{"version": "v1.0.0", "changes": [{"category": "Improved", "description": "faster"}]},
+			{"version": "v1.1.0", "changes": []}
+		]},
+		{"name": "be", "description": "this service has the backend"}
+	]}`))
+	if err != nil {
+		t.Fatal("Failed to decode document:", err)
+	}
+
+	assert.Equal(t, []RowError{
+		{Row: "services[0].versions[0]", Field: "changelog", Msg: "failed on the 'min=10' tag"},
+		{Row: "services[0].versions[1]", Field: "changes[0].category", Msg: "failed on the 'oneof=Added Changed Deprecated Removed Fixed Security' tag"},
+		{Row: "services[0].versions[1]", Field: "version", Msg: "duplicate of services[0].versions[0]"},
+		{Row: "services[0].versions[2]", Field: "changes", Msg: "failed on the 'min=1' tag"},
+		{Row: "services[1]", Field: "name", Msg: "failed on the 'min=3' tag"},
+	}, Validate(invalid))
+}
@@ -0,0 +1,269 @@
+package changelog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Change categories defined by Keep a Changelog (https://keepachangelog.com)
+const (
+	CategoryAdded      = "Added"
+	CategoryChanged    = "Changed"
+	CategoryDeprecated = "Deprecated"
+	CategoryRemoved    = "Removed"
+	CategoryFixed      = "Fixed"
+	CategorySecurity   = "Security"
+)
+
+// Categories is the list of categories in the order they are rendered
+var Categories = []string{
+	CategoryAdded,
+	CategoryChanged,
+	CategoryDeprecated,
+	CategoryRemoved,
+	CategoryFixed,
+	CategorySecurity,
+}
+
+const dateLayout = "2006-01-02"
+
+// Change is a single entry of a release
+type Change struct {
+	Category    string
+	Description string
+}
+
+// Release is a version of the changelog along with it's changes
+type Release struct {
+	Version string
+	Date    *time.Time
+	// Notes is free text written under the release heading, used when a release has no categorized changes
+	Notes   string
+	Changes []Change
+}
+
+// IsCategory reports whether the given value is a Keep a Changelog category
+func IsCategory(value string) bool {
+	for _, category := range Categories {
+		if category == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseError is returned when the changelog does not follow the Keep a Changelog format
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Parse parses a CHANGELOG.md written in the Keep a Changelog format.
+// The `Unreleased` section and link references are ignored. Releases are returned in the order they appear.
+func Parse(r io.Reader) ([]Release, error) {
+	var (
+		releases []Release
+		current  *Release
+		category string
+		skip     bool
+		lineNo   int
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "## "):
+			version, date, err := parseReleaseHeading(strings.TrimSpace(trimmed[3:]))
+			if err != nil {
+				return nil, &ParseError{Line: lineNo, Msg: err.Error()}
+			}
+
+			category = ""
+			if strings.EqualFold(version, "unreleased") {
+				skip = true
+				current = nil
+				continue
+			}
+
+			skip = false
+			releases = append(releases, Release{Version: version, Date: date})
+			current = &releases[len(releases)-1]
+
+		case strings.HasPrefix(trimmed, "### "):
+			if skip {
+				continue
+			}
+			if current == nil {
+				return nil, &ParseError{Line: lineNo, Msg: "category outside of a release"}
+			}
+
+			category = strings.TrimSpace(trimmed[4:])
+			if !IsCategory(category) {
+				return nil, &ParseError{Line: lineNo, Msg: fmt.Sprintf("unknown category %q", category)}
+			}
+
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			if skip || current == nil {
+				continue
+			}
+			if category == "" {
+				current.Notes = appendLine(current.Notes, trimmed)
+				continue
+			}
+
+			// Nested bullets are kept as part of the parent entry
+			if line != trimmed && len(current.Changes) > 0 {
+				last := &current.Changes[len(current.Changes)-1]
+				last.Description += " " + strings.TrimSpace(trimmed[2:])
+				continue
+			}
+
+			current.Changes = append(current.Changes, Change{
+				Category:    category,
+				Description: strings.TrimSpace(trimmed[2:]),
+			})
+
+		case trimmed == "" || isLinkReference(trimmed) || strings.HasPrefix(trimmed, "# "):
+			continue
+
+		default:
+			if skip || current == nil {
+				continue
+			}
+
+			// Continuation of the previous entry
+			if category != "" && len(current.Changes) > 0 {
+				last := &current.Changes[len(current.Changes)-1]
+				last.Description += " " + trimmed
+				continue
+			}
+
+			current.Notes = appendLine(current.Notes, trimmed)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return releases, nil
+}
+
+// parseReleaseHeading parses headings like `[1.0.0] - 2017-06-20`, `1.0.0 - 2017-06-20` or `[1.0.0] - 2017-06-20 [YANKED]`
+func parseReleaseHeading(heading string) (string, *time.Time, error) {
+	var version, rest string
+
+	if strings.HasPrefix(heading, "[") {
+		end := strings.Index(heading, "]")
+		if end < 0 {
+			return "", nil, fmt.Errorf("invalid release heading %q", heading)
+		}
+		version = heading[1:end]
+		rest = heading[end+1:]
+	} else {
+		fields := strings.Fields(heading)
+		if len(fields) == 0 {
+			return "", nil, fmt.Errorf("invalid release heading %q", heading)
+		}
+		version = fields[0]
+		rest = strings.TrimPrefix(heading, version)
+	}
+
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return "", nil, fmt.Errorf("invalid release heading %q", heading)
+	}
+
+	rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), "-"))
+	if rest == "" {
+		return version, nil, nil
+	}
+
+	date, err := time.Parse(dateLayout, strings.Fields(rest)[0])
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid release date in %q", heading)
+	}
+
+	return version, &date, nil
+}
+
+// isLinkReference reports whether the line is a link reference such as `[1.0.0]: https://...`
+func isLinkReference(line string) bool {
+	if !strings.HasPrefix(line, "[") {
+		return false
+	}
+
+	end := strings.Index(line, "]:")
+	return end > 0
+}
+
+func appendLine(text, line string) string {
+	if text == "" {
+		return line
+	}
+
+	return text + "\n" + line
+}
+
+// Render writes the releases in the Keep a Changelog format, in the order they are given
+func Render(w io.Writer, title string, releases []Release) error {
+	var b strings.Builder
+
+	if title != "" {
+		fmt.Fprintf(&b, "# %s\n", title)
+	}
+
+	for _, release := range releases {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+
+		b.WriteString("## [" + release.Version + "]")
+		if release.Date != nil {
+			b.WriteString(" - " + release.Date.Format(dateLayout))
+		}
+		b.WriteString("\n")
+
+		if release.Notes != "" {
+			b.WriteString("\n" + release.Notes + "\n")
+		}
+
+		RenderChanges(&b, release.Changes)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// RenderChanges writes the changes grouped by category using `###` headings
+func RenderChanges(b *strings.Builder, changes []Change) {
+	for _, category := range Categories {
+		var descriptions []string
+		for _, change := range changes {
+			if change.Category == category {
+				descriptions = append(descriptions, change.Description)
+			}
+		}
+
+		if len(descriptions) == 0 {
+			continue
+		}
+
+		b.WriteString("\n### " + category + "\n")
+		for _, description := range descriptions {
+			b.WriteString("- " + description + "\n")
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const keepAChangelog = `# Changelog
+All notable changes to this project will be documented in this file.
+
+## [Unreleased]
+### Added
+- Not released yet.
+
+## [1.1.0] - 2024-03-05
+### Added
+- Version navigation.
+- Links to latest released version
+  in previous versions.
+
+### Fixed
+* Fixed typos in the README.
+
+## [1.0.0] - 2024-01-20 [YANKED]
+First stable release.
+
+## 0.1.0
+### Security
+- Escape the service description.
+
+[unreleased]: https://example.com/compare/v1.1.0...HEAD
+[1.1.0]: https://example.com/compare/v1.0.0...v1.1.0
+`
+
+func TestParse(t *testing.T) {
+	releases, err := Parse(strings.NewReader(keepAChangelog))
+	if err != nil {
+		t.Fatal("Failed to parse changelog:", err)
+	}
+
+	assert.Len(t, releases, 3)
+
+	assert.Equal(t, "1.1.0", releases[0].Version)
+	assert.Equal(t, "2024-03-05", releases[0].Date.Format("2006-01-02"))
+	assert.Equal(t, []Change{
+		{Category: CategoryAdded, Description: "Version navigation."},
+		{Category: CategoryAdded, Description: "Links to latest released version in previous versions."},
+		{Category: CategoryFixed, Description: "Fixed typos in the README."},
+	}, releases[0].Changes)
+
+	assert.Equal(t, "1.0.0", releases[1].Version)
+	assert.Equal(t, "First stable release.", releases[1].Notes)
+	assert.Empty(t, releases[1].Changes)
+
+	assert.Equal(t, "0.1.0", releases[2].Version)
+	assert.Nil(t, releases[2].Date)
+	assert.Equal(t, CategorySecurity, releases[2].Changes[0].Category)
+
+	// Case fail: Unknown category
+	_, err = Parse(strings.NewReader("## [1.0.0] - 2024-01-20\n### Improved\n- Something\n"))
+	assert.EqualError(t, err, `line 2: unknown category "Improved"`)
+
+	// Case fail: Invalid date
+	_, err = Parse(strings.NewReader("## [1.0.0] - 20-01-2024\n"))
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	releases, err := Parse(strings.NewReader(keepAChangelog))
+	if err != nil {
+		t.Fatal("Failed to parse changelog:", err)
+	}
+
+	var b strings.Builder
+	if err := Render(&b, "Changelog", releases); err != nil {
+		t.Fatal("Failed to render changelog:", err)
+	}
+
+	expected := `# Changelog
+
+## [1.1.0] - 2024-03-05
+
+### Added
+- Version navigation.
+- Links to latest released version in previous versions.
+
+### Fixed
+- Fixed typos in the README.
+
+## [1.0.0] - 2024-01-20
+
+First stable release.
+
+## [0.1.0]
+
+### Security
+- Escape the service description.
+`
+	assert.Equal(t, expected, b.String())
+
+	// The rendered changelog can be parsed back
+	parsed, err := Parse(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal("Failed to parse rendered changelog:", err)
+	}
+	assert.Equal(t, releases, parsed)
+}
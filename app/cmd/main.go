@@ -1,16 +1,30 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/ZiyanK/service-catalog-api/app/db"
+	"github.com/ZiyanK/service-catalog-api/app/health"
 	"github.com/ZiyanK/service-catalog-api/app/logger"
+	"github.com/ZiyanK/service-catalog-api/app/metrics"
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/outbox"
 	"github.com/ZiyanK/service-catalog-api/app/route"
-	"github.com/gin-contrib/cors"
+	"github.com/ZiyanK/service-catalog-api/app/rpc"
+	"github.com/ZiyanK/service-catalog-api/app/stream"
+	"github.com/ZiyanK/service-catalog-api/app/webhook"
 	_ "github.com/lib/pq"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -28,22 +42,131 @@ func main() {
 		log.Fatal("Failed to conenct to the database", zap.Error(err))
 	}
 
+	// `migrate up|down|status|redo` migrates the database instead of starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
+	// the metrics of the connection pool and of the catalog are collected when /metrics is scraped
+	metrics.Register()
+
+	// SIGTERM is sent by Kubernetes when the pod is stopped, a second signal stops the server without draining it
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	migrateOnBoot(ctx)
+
+	workers := newWorkers()
+
+	webhook.AllowPrivateNetworks = config.WebhookAllowPrivateNetworks
+
+	// The events streamed to the clients are passed to the hub of every instance with Postgres NOTIFY,
+	// a SQLite database is only used by a single instance so they are passed to it's hub
+	var streamPublisher outbox.Publisher = stream.GetHub()
+	if !db.GetDBInstance().IsSQLite() {
+		streamPublisher = stream.Notifier{}
+		workers.Go("event stream listener", stream.GetHub().Listen)
+	}
+
+	// Outbox relay, the events are published to the webhooks and optionally to a file and an HTTP endpoint, then they are
+	// streamed once published
+	sinks := []outbox.Sink{
+		{Name: "webhooks", Publisher: outbox.PublisherFunc(webhook.Publish)},
+	}
+	if config.OutboxFile != "" {
+		sinks = append(sinks, outbox.Sink{Name: "file", Publisher: outbox.NewFilePublisher(config.OutboxFile)})
+	}
+	if config.OutboxURL != "" {
+		sinks = append(sinks, outbox.Sink{Name: "http", Publisher: &outbox.HTTPPublisher{
+			URL:    config.OutboxURL,
+			Client: &http.Client{Timeout: 10 * time.Second},
+		}})
+	}
+	relay := outbox.NewRelay(sinks...)
+	relay.Stream = streamPublisher
+	workers.Go("outbox relay", relay.Run)
+
+	// Webhook deliveries
+	workers.Go("webhook dispatcher", webhook.NewDispatcher().Run)
+
+	// Idempotency keys are kept for IDEMPOTENCY_KEY_TTL, the expired keys are deleted every IDEMPOTENCY_KEY_PURGE_INTERVAL
+	workers.Go("idempotency keys purge", func(ctx context.Context) {
+		middleware.PurgeIdempotencyKeys(ctx, middleware.IdempotencyKeyPurgeInterval())
+	})
+
+	// gRPC API, it shares the repositories and the auth tokens with the HTTP API
+	var grpcServer *grpc.Server
+	if config.GRPCPort != "" {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%v", config.GRPCPort))
+		if err != nil {
+			log.Fatal("Failed to listen on the gRPC port", zap.Error(err))
+		}
+
+		grpcServer = rpc.NewServer(model.NewSQLRepositories())
+		go func() {
+			err := grpcServer.Serve(listener)
+			if err != nil {
+				log.Fatal("Failed to start gRPC server", zap.Error(err))
+			}
+		}()
+	}
+
 	// HTTP API
 	router := route.AddRouter()
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:8080", "*"}, // TODO: restrict origins
-		AllowMethods:     []string{http.MethodGet, http.MethodPatch, http.MethodPost, http.MethodHead, http.MethodDelete, http.MethodOptions},
-		AllowHeaders:     []string{"Content-Type", "X-XSRF-TOKEN", "Accept", "Origin", "X-Requested-With", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-	}))
 	router.RemoveExtraSlash = true
 
-	log.Info("Server up and running")
-	err := router.Run(fmt.Sprintf(":%v", config.Port))
+	// Liveness and readiness of the server, the liveness does not depend on the database so that the server is not
+	// restarted when the database is down
+	checker := health.GetChecker()
+	checker.AddLiveness("workers", workers.Check)
+	checker.AddReadiness("database", func(ctx context.Context) error {
+		return db.GetDBInstance().Sqlx.PingContext(ctx)
+	})
+	// The version the schema has to be migrated to is read once so that the probe only queries the latest applied one
+	schemaVersion, err := db.GetDBInstance().LatestMigrationVersion()
 	if err != nil {
+		log.Fatal("Failed to read the migrations", zap.Error(err))
+	}
+	checker.AddReadiness("migrations", db.GetDBInstance().CheckSchemaVersion(schemaVersion))
+
+	// Metrics, served on their own port so that they are not exposed with the API. Without METRICS_PORT they are served
+	// on the API port to the scrapes with METRICS_TOKEN.
+	var metricsServer *http.Server
+	if config.MetricsPort != "" {
+		metricsServer = newMetricsServer(route.AddMetricsRouter())
+		go func() {
+			err := metricsServer.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				log.Fatal("Failed to start metrics server", zap.Error(err))
+			}
+		}()
+	}
+
+	server := newHTTPServer(router)
+	// The event streams are closed so that the server does not wait for them to drain
+	server.RegisterOnShutdown(stream.GetHub().Close)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	log.Info("Server up and running")
+
+	select {
+	case err := <-serverErr:
 		log.Fatal("Failed to start server", zap.Error(err))
+	case <-ctx.Done():
+		stop()
+		log.Info("Shutting down the server")
 	}
+
+	// The server is not ready anymore, it keeps serving until the load balancers stop sending it requests
+	checker.ShutDown()
+	time.Sleep(config.ShutdownDelay)
+
+	shutdown(server, metricsServer, grpcServer, workers)
 }
 
 // configration is a struct used to get the environment variable from the config.yaml file
@@ -51,6 +174,31 @@ type configuration struct {
 	DSN       string `mapstructure:"DSN"`
 	Port      string `mapstructure:"PORT"`
 	JWTSecret string `mapstructure:"JWT_SECRET"`
+
+	// GRPCPort is the port of the gRPC API, it is not started when empty
+	GRPCPort string `mapstructure:"GRPC_PORT"`
+
+	// MetricsPort is the internal port /metrics is served on instead of the API port
+	MetricsPort string `mapstructure:"METRICS_PORT"`
+
+	// OutboxFile and OutboxURL are the optional file and HTTP endpoint the catalog events are published to
+	OutboxFile string `mapstructure:"OUTBOX_FILE"`
+	OutboxURL  string `mapstructure:"OUTBOX_URL"`
+
+	// WebhookAllowPrivateNetworks allows the webhooks to be delivered to the loopback and private networks, for local setups
+	WebhookAllowPrivateNetworks bool `mapstructure:"WEBHOOK_ALLOW_PRIVATE_NETWORKS"`
+
+	// Timeouts of the HTTP server, e.g. `30s`. The in-flight requests are drained for up to ShutdownTimeout on SIGTERM.
+	ReadTimeout     time.Duration `mapstructure:"READ_TIMEOUT"`
+	WriteTimeout    time.Duration `mapstructure:"WRITE_TIMEOUT"`
+	IdleTimeout     time.Duration `mapstructure:"IDLE_TIMEOUT"`
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+
+	// ShutdownDelay is how long the server keeps serving once it is not ready on SIGTERM, before it is drained
+	ShutdownDelay time.Duration `mapstructure:"SHUTDOWN_DELAY"`
+
+	// AutoMigrate applies the pending migrations at startup, otherwise the server does not start when the schema is behind
+	AutoMigrate bool `mapstructure:"AUTO_MIGRATE"`
 }
 
 var (
@@ -70,12 +218,34 @@ func LoadConfig() error {
 		dsn := viper.GetString("DSN")
 		jwtSecret := viper.GetString("JWT_SECRET")
 		port := viper.GetString("PORT")
+		grpcPort := viper.GetString("GRPC_PORT")
+		metricsPort := viper.GetString("METRICS_PORT")
+		outboxFile := viper.GetString("OUTBOX_FILE")
+		outboxURL := viper.GetString("OUTBOX_URL")
+		webhookAllowPrivateNetworks := viper.GetBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS")
+		autoMigrate := viper.GetBool("AUTO_MIGRATE")
+		readTimeout := viper.GetDuration("READ_TIMEOUT")
+		writeTimeout := viper.GetDuration("WRITE_TIMEOUT")
+		idleTimeout := viper.GetDuration("IDLE_TIMEOUT")
+		shutdownTimeout := viper.GetDuration("SHUTDOWN_TIMEOUT")
+		shutdownDelay := viper.GetDuration("SHUTDOWN_DELAY")
 
 		log.Info("config", zap.Any("DSN", dsn))
 
 		config.DSN = dsn
 		config.JWTSecret = jwtSecret
 		config.Port = port
+		config.GRPCPort = grpcPort
+		config.MetricsPort = metricsPort
+		config.OutboxFile = outboxFile
+		config.OutboxURL = outboxURL
+		config.WebhookAllowPrivateNetworks = webhookAllowPrivateNetworks
+		config.AutoMigrate = autoMigrate
+		config.ReadTimeout = readTimeout
+		config.WriteTimeout = writeTimeout
+		config.IdleTimeout = idleTimeout
+		config.ShutdownTimeout = shutdownTimeout
+		config.ShutdownDelay = shutdownDelay
 	} else {
 		if err := viper.Unmarshal(&config); err != nil {
 			log.Fatal("unable to decode into struct", zap.String("err", err.Error()))
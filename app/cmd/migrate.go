@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/db"
+	"go.uber.org/zap"
+)
+
+const migrateUsage = "usage: service-catalog migrate up|down|status|redo"
+
+// runMigrate runs the `migrate` subcommand on the embedded migrations
+func runMigrate(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	database := db.GetDBInstance()
+
+	var err error
+	switch args[0] {
+	case "up":
+		var applied []db.Migration
+		applied, err = database.AutoMigrate(ctx)
+		if err == nil && len(applied) == 0 {
+			fmt.Println("The schema is up to date")
+		}
+		for _, migration := range applied {
+			fmt.Println("Applied", migration.Name)
+		}
+	case "down":
+		var migration *db.Migration
+		migration, err = database.MigrateDown(ctx)
+		if err == nil {
+			fmt.Println("Rolled back", migration.Name)
+		}
+	case "redo":
+		var migration *db.Migration
+		migration, err = database.MigrateRedo(ctx)
+		if err == nil {
+			fmt.Println("Reapplied", migration.Name)
+		}
+	case "status":
+		err = printMigrations(ctx, database)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal("Failed to migrate the database", zap.Error(err))
+	}
+}
+
+// printMigrations prints the embedded migrations with the time they were applied at
+func printMigrations(ctx context.Context, database *db.Database) error {
+	migrations, err := database.Migrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Applied At\tMigration")
+	for _, migration := range migrations {
+		appliedAt := "Pending"
+		if migration.AppliedAt != nil {
+			appliedAt = migration.AppliedAt.UTC().Format(time.RFC3339)
+		} else if migration.Applied {
+			appliedAt = "Applied"
+		}
+		fmt.Fprintf(w, "%v\t%v\n", appliedAt, migration.Name)
+	}
+
+	return w.Flush()
+}
+
+// migrateOnBoot applies the pending migrations when AUTO_MIGRATE is set, otherwise the server does not start
+// with a schema which is behind the binary
+func migrateOnBoot(ctx context.Context) {
+	database := db.GetDBInstance()
+
+	if config.AutoMigrate {
+		if _, err := database.AutoMigrate(ctx); err != nil {
+			log.Fatal("Failed to migrate the database", zap.Error(err))
+		}
+		return
+	}
+
+	pending, err := database.PendingMigrations(ctx)
+	if err != nil {
+		log.Fatal("Failed to check the migrations of the database", zap.Error(err))
+	}
+
+	if len(pending) > 0 {
+		log.Fatal("The database schema is behind, run `migrate up` or set AUTO_MIGRATE",
+			zap.Int("pending", len(pending)), zap.String("next", pending[0].Name))
+	}
+}
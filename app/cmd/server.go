@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/db"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// Timeouts of the HTTP server when they are not configured.
+// The shutdown timeout is below the 30 seconds Kubernetes waits for a pod to stop before killing it.
+const (
+	defaultReadTimeout     = 15 * time.Second
+	defaultWriteTimeout    = 30 * time.Second
+	defaultIdleTimeout     = 2 * time.Minute
+	defaultShutdownTimeout = 25 * time.Second
+)
+
+// newHTTPServer returns the HTTP server of the API with the configured timeouts
+func newHTTPServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%v", config.Port),
+		Handler:           handler,
+		ReadHeaderTimeout: durationOr(config.ReadTimeout, defaultReadTimeout),
+		ReadTimeout:       durationOr(config.ReadTimeout, defaultReadTimeout),
+		WriteTimeout:      durationOr(config.WriteTimeout, defaultWriteTimeout),
+		IdleTimeout:       durationOr(config.IdleTimeout, defaultIdleTimeout),
+	}
+}
+
+// newMetricsServer returns the HTTP server of the internal metrics port
+func newMetricsServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%v", config.MetricsPort),
+		Handler:           handler,
+		ReadHeaderTimeout: durationOr(config.ReadTimeout, defaultReadTimeout),
+		ReadTimeout:       durationOr(config.ReadTimeout, defaultReadTimeout),
+		WriteTimeout:      durationOr(config.WriteTimeout, defaultWriteTimeout),
+		IdleTimeout:       durationOr(config.IdleTimeout, defaultIdleTimeout),
+	}
+}
+
+// durationOr returns the duration, or the fallback when it is not set
+func durationOr(duration, fallback time.Duration) time.Duration {
+	if duration > 0 {
+		return duration
+	}
+	return fallback
+}
+
+// workers runs the background workers of the server until they are stopped
+type workers struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu sync.Mutex
+	// failed are the workers which returned or panicked before they were stopped
+	failed map[string]error
+}
+
+func newWorkers() *workers {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &workers{ctx: ctx, cancel: cancel, failed: make(map[string]error)}
+}
+
+// Go runs a worker in the background, it must return once it's context is done.
+// A worker which panics does not stop the server, it is reported by Check.
+func (w *workers) Go(name string, run func(ctx context.Context)) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer func() {
+			if p := recover(); p != nil {
+				log.Error("Worker panicked", zap.String("worker", name), zap.Any("panic", p), zap.Stack("stack"))
+				w.fail(name, fmt.Errorf("%v panicked: %v", name, p))
+			}
+		}()
+
+		run(w.ctx)
+
+		if w.ctx.Err() == nil {
+			log.Error("Worker returned before it was stopped", zap.String("worker", name))
+			w.fail(name, fmt.Errorf("%v returned before it was stopped", name))
+			return
+		}
+		log.Info("Worker stopped", zap.String("worker", name))
+	}()
+}
+
+func (w *workers) fail(name string, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.failed[name] = err
+}
+
+// Check returns an error when a worker is not running while the server is, it is a check of the liveness
+func (w *workers) Check(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var errs []error
+	for _, err := range w.failed {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Stop cancels the context of the workers and waits for them to return
+func (w *workers) Stop(ctx context.Context) error {
+	w.cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shutdown stops the server in order within SHUTDOWN_TIMEOUT: the HTTP, metrics and gRPC servers stop accepting connections
+// and drain the in-flight requests, then the background workers are stopped and the connections of the database are closed
+func shutdown(httpServer, metricsServer *http.Server, grpcServer *grpc.Server, workers *workers) {
+	ctx, cancel := context.WithTimeout(context.Background(), durationOr(config.ShutdownTimeout, defaultShutdownTimeout))
+	defer cancel()
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Error("Failed to drain the HTTP connections", zap.Error(err))
+		httpServer.Close()
+	}
+
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			log.Error("Failed to drain the metrics connections", zap.Error(err))
+			metricsServer.Close()
+		}
+	}
+
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			log.Error("Failed to drain the gRPC connections", zap.Error(ctx.Err()))
+			grpcServer.Stop()
+		}
+	}
+
+	if err := workers.Stop(ctx); err != nil {
+		log.Error("Failed to stop the background workers", zap.Error(err))
+	}
+
+	if err := db.GetDBInstance().Close(); err != nil {
+		log.Error("Failed to close the database connections", zap.Error(err))
+	}
+
+	log.Info("Server stopped")
+}
@@ -0,0 +1,168 @@
+package conventional
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ZiyanK/service-catalog-api/app/changelog"
+	"github.com/ZiyanK/service-catalog-api/app/semver"
+)
+
+// headerRegexp matches the header of a commit, e.g. `feat(api)!: add the version endpoint`
+var headerRegexp = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()]*)\))?(!)?: (.+)$`)
+
+// breakingFooters are the footer tokens used to describe a breaking change
+var breakingFooters = []string{"BREAKING CHANGE:", "BREAKING-CHANGE:"}
+
+// Commit is a commit message following the Conventional Commits specification (https://www.conventionalcommits.org)
+type Commit struct {
+	Type        string `json:"type"`
+	Scope       string `json:"scope,omitempty"`
+	Description string `json:"description"`
+	Breaking    bool   `json:"breaking"`
+	// BreakingNote is the description given in the `BREAKING CHANGE` footer
+	BreakingNote string `json:"breaking_note,omitempty"`
+}
+
+// Parse parses a commit message, false is returned if the message does not follow the specification
+func Parse(message string) (Commit, bool) {
+	lines := strings.Split(strings.ReplaceAll(strings.TrimSpace(message), "\r\n", "\n"), "\n")
+
+	match := headerRegexp.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if match == nil {
+		return Commit{}, false
+	}
+
+	commit := Commit{
+		Type:        strings.ToLower(match[1]),
+		Scope:       strings.TrimSpace(match[2]),
+		Description: strings.TrimSpace(match[4]),
+		Breaking:    match[3] == "!",
+	}
+
+	// The breaking change footer may span multiple lines until the next footer or the end of the message
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		for _, footer := range breakingFooters {
+			if !strings.HasPrefix(line, footer) {
+				continue
+			}
+
+			commit.Breaking = true
+			note := []string{strings.TrimSpace(strings.TrimPrefix(line, footer))}
+			for j := i + 1; j < len(lines); j++ {
+				next := strings.TrimSpace(lines[j])
+				if next == "" || isFooter(next) {
+					break
+				}
+				note = append(note, next)
+			}
+			commit.BreakingNote = strings.TrimSpace(strings.Join(note, " "))
+		}
+	}
+
+	return commit, true
+}
+
+// isFooter reports whether the line starts a git trailer like footer such as `Refs: #123`
+func isFooter(line string) bool {
+	for _, footer := range breakingFooters {
+		if strings.HasPrefix(line, footer) {
+			return true
+		}
+	}
+
+	// The footer token cannot contain whitespace, `-` is used instead
+	for _, separator := range []string{": ", " #"} {
+		if i := strings.Index(line, separator); i > 0 && !strings.Contains(line[:i], " ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Bump returns the version bump required by the commit
+func (c Commit) Bump() semver.Bump {
+	switch {
+	case c.Breaking:
+		return semver.BumpMajor
+	case c.Type == "feat":
+		return semver.BumpMinor
+	case c.Type == "fix" || c.Type == "perf" || c.Type == "revert":
+		return semver.BumpPatch
+	}
+
+	return semver.BumpNone
+}
+
+// Change returns the changelog entry for the commit, false is returned for commits which are not released such as `chore` or `docs`
+func (c Commit) Change() (changelog.Change, bool) {
+	var category string
+
+	switch {
+	case c.Type == "feat":
+		category = changelog.CategoryAdded
+	case c.Type == "fix" && strings.EqualFold(c.Scope, "security"):
+		category = changelog.CategorySecurity
+	case c.Type == "fix":
+		category = changelog.CategoryFixed
+	case c.Type == "perf" || c.Type == "refactor" || c.Type == "revert":
+		category = changelog.CategoryChanged
+	case c.Breaking:
+		category = changelog.CategoryChanged
+	default:
+		return changelog.Change{}, false
+	}
+
+	description := c.Description
+	if c.Scope != "" {
+		description = "**" + c.Scope + ":** " + description
+	}
+	if c.Breaking {
+		note := c.BreakingNote
+		if note == "" {
+			note = c.Description
+		}
+		description = "**BREAKING:** " + description
+		if note != c.Description {
+			description += " (" + note + ")"
+		}
+	}
+
+	return changelog.Change{Category: category, Description: description}, true
+}
+
+// Release is the next version proposed for a list of commits
+type Release struct {
+	Bump    semver.Bump
+	Changes []changelog.Change
+	// Commits is the list of commits following the specification
+	Commits []Commit
+	// Ignored is the list of commit messages which do not follow the specification
+	Ignored []string
+}
+
+// Analyze parses the commit messages and returns the highest bump along with the changelog entries
+func Analyze(messages []string) Release {
+	var release Release
+
+	for _, message := range messages {
+		commit, ok := Parse(message)
+		if !ok {
+			release.Ignored = append(release.Ignored, message)
+			continue
+		}
+
+		release.Commits = append(release.Commits, commit)
+		if bump := commit.Bump(); bump > release.Bump {
+			release.Bump = bump
+		}
+		if change, ok := commit.Change(); ok {
+			release.Changes = append(release.Changes, change)
+		}
+	}
+
+	return release
+}
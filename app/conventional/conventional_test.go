@@ -0,0 +1,49 @@
+package conventional
+
+import (
+	"testing"
+
+	"github.com/ZiyanK/service-catalog-api/app/changelog"
+	"github.com/ZiyanK/service-catalog-api/app/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	commit, ok := Parse("feat(api)!: drop the v0 routes")
+	assert.True(t, ok)
+	assert.Equal(t, Commit{Type: "feat", Scope: "api", Description: "drop the v0 routes", Breaking: true}, commit)
+
+	commit, ok = Parse("fix: handle empty changelog\n\nThe handler used to panic.\n\nBREAKING CHANGE: the changelog is now\nrequired for every version\nRefs: #42")
+	assert.True(t, ok)
+	assert.True(t, commit.Breaking)
+	assert.Equal(t, "the changelog is now required for every version", commit.BreakingNote)
+
+	// Case fail: Not a conventional commit
+	_, ok = Parse("Merge branch 'main' into feature")
+	assert.False(t, ok)
+}
+
+func TestAnalyze(t *testing.T) {
+	release := Analyze([]string{
+		"fix(security): escape the service description",
+		"feat: add artifact lookup",
+		"chore: bump dependencies",
+		"update readme",
+	})
+
+	assert.Equal(t, semver.BumpMinor, release.Bump)
+	assert.Equal(t, []changelog.Change{
+		{Category: changelog.CategorySecurity, Description: "**security:** escape the service description"},
+		{Category: changelog.CategoryAdded, Description: "add artifact lookup"},
+	}, release.Changes)
+	assert.Len(t, release.Commits, 3)
+	assert.Equal(t, []string{"update readme"}, release.Ignored)
+
+	release = Analyze([]string{"docs: fix typo", "refactor!: rename the service table"})
+	assert.Equal(t, semver.BumpMajor, release.Bump)
+	assert.Equal(t, "**BREAKING:** rename the service table", release.Changes[0].Description)
+
+	release = Analyze([]string{"docs: fix typo"})
+	assert.Equal(t, semver.BumpNone, release.Bump)
+	assert.Empty(t, release.Changes)
+}
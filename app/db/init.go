@@ -1,9 +1,19 @@
 package db
 
 import (
+	"net/url"
+	"strings"
+
 	"github.com/ZiyanK/service-catalog-api/app/logger"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Drivers of the supported databases, the database is selected by the scheme of the DSN
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite3"
 )
 
 var (
@@ -13,22 +23,72 @@ var (
 
 type Database struct {
 	Sqlx *sqlx.DB
+
+	// source is the data source of the connections, the listeners open their own connection with it
+	source string
 }
 
 // InitConn is a function used to initiate the connect with the database
 func InitConn(dsn string) error {
-	db, err := sqlx.Connect("postgres", dsn)
+	driver, source := ParseDSN(dsn)
+
+	db, err := open(driver, source)
 	if err != nil {
 		log.Fatal(err.Error())
 		return err
 	}
 
 	DB.Sqlx = db
+	DB.source = source
 
 	return nil
 }
 
+// ParseDSN returns the driver and the data source of a DSN.
+// `sqlite://<path>` is a SQLite database file, the foreign keys are enforced and the transactions wait for each other
+// as SQLite has a single writer. Any other DSN is a PostgreSQL connection string.
+func ParseDSN(dsn string) (string, string) {
+	path, ok := strings.CutPrefix(dsn, "sqlite://")
+	if !ok {
+		path, ok = strings.CutPrefix(dsn, "sqlite3://")
+	}
+	if !ok {
+		return DriverPostgres, dsn
+	}
+
+	path, rawQuery, _ := strings.Cut(path, "?")
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		params = url.Values{}
+	}
+
+	defaults := map[string]string{
+		"_foreign_keys": "on",
+		"_journal_mode": "WAL",
+		"_busy_timeout": "5000",
+		// The write lock is taken when a transaction begins so that concurrent transactions wait instead of failing
+		"_txlock": "immediate",
+	}
+	for name, value := range defaults {
+		if !params.Has(name) {
+			params.Set(name, value)
+		}
+	}
+
+	return DriverSQLite, "file:" + path + "?" + params.Encode()
+}
+
+// Close closes the connections of the pool, it is called once the server and the workers are stopped
+func (d *Database) Close() error {
+	return d.Sqlx.Close()
+}
+
 // GetDBInstance gets the initalised instance of the database
 func GetDBInstance() *Database {
 	return &DB
 }
+
+// IsSQLite reports whether the database is SQLite, the queries which are not portable have a SQLite variant
+func (d *Database) IsSQLite() bool {
+	return d.Sqlx.DriverName() == DriverSQLite
+}
@@ -0,0 +1,243 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// queryDuration is the time the statements take by operation and table, the time to read the rows of a query is not included.
+// The statements are timed by the driver, so the ones run by the Database wrapper and within the transactions are included.
+var queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "db_query_duration_seconds",
+	Help:    "Duration of the database statements by operation, table and status.",
+	Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation", "table", "status"})
+
+// queryTableRegexp matches the table a statement operates on
+var queryTableRegexp = regexp.MustCompile(`(?i)\b(?:from|into|update)\s+"?([a-z_][a-z0-9_]*)`)
+
+// queryCommentRegexp matches the comments of a statement, so that their words are not taken for a table
+var queryCommentRegexp = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+
+// operations are the operations the statements are labeled with, the others are labeled as OTHER.
+// Only the statements reading or changing rows are labeled with their table.
+var operations = map[string]bool{
+	"SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true, "WITH": true,
+	"SAVEPOINT": false, "RELEASE": false, "ROLLBACK": false, "CREATE": false, "ALTER": false, "DROP": false,
+}
+
+// open opens a pool of connections whose statements are timed, the driver name is kept so that the queries are bound
+// for the database
+func open(driverName, source string) (*sqlx.DB, error) {
+	// The driver is only opened to be wrapped
+	base, err := sql.Open(driverName, "")
+	if err != nil {
+		return nil, err
+	}
+	d := base.Driver()
+	base.Close()
+
+	conn := sqlx.NewDb(sql.OpenDB(&instrumentedConnector{driver: d, source: source}), driverName)
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// queryLabels returns the operation and the table of a statement
+func queryLabels(query string) (string, string) {
+	query = queryCommentRegexp.ReplaceAllString(query, " ")
+
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return "OTHER", ""
+	}
+
+	operation := strings.ToUpper(words[0])
+	dml, known := operations[operation]
+	if !known {
+		return "OTHER", ""
+	}
+
+	table := ""
+	if match := queryTableRegexp.FindStringSubmatch(query); dml && match != nil {
+		table = strings.ToLower(match[1])
+	}
+
+	return operation, table
+}
+
+// observeQuery records the duration of a statement
+func observeQuery(query string, start time.Time, err error) {
+	status := "ok"
+	if err != nil && err != driver.ErrSkip {
+		status = "error"
+	}
+
+	operation, table := queryLabels(query)
+	queryDuration.WithLabelValues(operation, table, status).Observe(time.Since(start).Seconds())
+}
+
+// instrumentedConnector opens the connections of the driver wrapped to time their statements
+type instrumentedConnector struct {
+	driver driver.Driver
+	source string
+}
+
+func (c *instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.source)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: conn}, nil
+}
+
+func (c *instrumentedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// instrumentedConn times the statements of a connection. The optional interfaces it implements are passed to the wrapped connection,
+// database/sql falls back to the required ones when the wrapped connection does not implement them.
+type instrumentedConn struct {
+	driver.Conn
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var s driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = preparer.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedStmt{Stmt: s, query: query}, nil
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		observeQuery(query, start, err)
+	}
+	return result, err
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		observeQuery(query, start, err)
+	}
+	return rows, err
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() //nolint:staticcheck // The driver does not support the options
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *instrumentedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// instrumentedStmt times the executions of a prepared statement
+type instrumentedStmt struct {
+	driver.Stmt
+	query string
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+
+	var result driver.Result
+	var err error
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(values(args)) //nolint:staticcheck // The driver does not support the context
+	}
+
+	observeQuery(s.query, start, err)
+	return result, err
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(values(args)) //nolint:staticcheck // The driver does not support the context
+	}
+
+	observeQuery(s.query, start, err)
+	return rows, err
+}
+
+func (s *instrumentedStmt) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// values returns the values of the arguments for the drivers which do not support the named arguments
+func values(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+// histogramCount returns the number of statements observed with the labels
+func histogramCount(t *testing.T, operation, table, status string) uint64 {
+	var metric dto.Metric
+	observer := queryDuration.WithLabelValues(operation, table, status).(prometheus.Metric)
+	if err := observer.Write(&metric); err != nil {
+		t.Fatal("Failed to read the histogram:", err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestQueryLabels(t *testing.T) {
+	cases := []struct {
+		query     string
+		operation string
+		table     string
+	}{
+		{`SELECT uuid FROM users WHERE email = $1`, "SELECT", "users"},
+		{"\n\tINSERT INTO services(name) VALUES(?)", "INSERT", "services"},
+		{`update service_versions SET version = $1`, "UPDATE", "service_versions"},
+		{`DELETE FROM "webhooks" WHERE id = $1`, "DELETE", "webhooks"},
+		{`SAVEPOINT sp_1`, "SAVEPOINT", ""},
+		{`PRAGMA foreign_keys = ON`, "OTHER", ""},
+		{"-- +goose Up\n-- add the index to the table\nCREATE INDEX idx ON services(name)", "CREATE", ""},
+		{"/* from the relay */ SELECT id FROM outbox_events", "SELECT", "outbox_events"},
+	}
+
+	for _, c := range cases {
+		operation, table := queryLabels(c.query)
+		assert.Equal(t, c.operation, operation, c.query)
+		assert.Equal(t, c.table, table, c.query)
+	}
+}
+
+func TestOpenObservesQueries(t *testing.T) {
+	driver, source := ParseDSN("sqlite://" + filepath.Join(t.TempDir(), "instrument.db"))
+	conn, err := open(driver, source)
+	if err != nil {
+		t.Fatal("Failed to connect to the database:", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	assert.Equal(t, driver, conn.DriverName())
+
+	_, err = conn.Exec(`CREATE TABLE metrics_items (name TEXT NOT NULL)`)
+	assert.NoError(t, err)
+
+	_, err = conn.Exec(`INSERT INTO metrics_items(name) VALUES(?)`, "item")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), histogramCount(t, "INSERT", "metrics_items", "ok"))
+
+	// Case: The queries and the failed statements are observed
+	var count int
+	assert.NoError(t, conn.Get(&count, `SELECT COUNT(1) FROM metrics_items`))
+	assert.Equal(t, 1, count)
+	assert.Equal(t, uint64(1), histogramCount(t, "SELECT", "metrics_items", "ok"))
+
+	_, err = conn.Exec(`INSERT INTO metrics_items(name) VALUES(NULL)`)
+	assert.Error(t, err)
+	assert.Equal(t, uint64(1), histogramCount(t, "INSERT", "metrics_items", "error"))
+}
@@ -0,0 +1,416 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/migrations"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// The applied migrations are recorded in the table of goose,
+// so that the databases migrated with the goose CLI and by the binary are interchangeable
+const (
+	queryCreateMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS goose_db_version (
+		id SERIAL PRIMARY KEY,
+		version_id BIGINT NOT NULL,
+		is_applied BOOLEAN NOT NULL,
+		tstamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	queryCreateMigrationsTableSQLite = `
+	CREATE TABLE IF NOT EXISTS goose_db_version (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		version_id INTEGER NOT NULL,
+		is_applied INTEGER NOT NULL,
+		tstamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	querySelectMigrationVersions = `SELECT version_id, is_applied, tstamp FROM goose_db_version ORDER BY id DESC`
+
+	querySelectSchemaVersion = `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`
+
+	queryInsertMigrationVersion = `INSERT INTO goose_db_version(version_id, is_applied) VALUES(:version_id, TRUE)`
+
+	queryDeleteMigrationVersion = `DELETE FROM goose_db_version WHERE version_id = :version_id`
+
+	queryLockMigrations   = `SELECT pg_advisory_lock(:lock_id)`
+	queryUnlockMigrations = `SELECT pg_advisory_unlock(:lock_id)`
+)
+
+// migrationsLockID is the key of the advisory lock held while the migrations are applied by AutoMigrate
+const migrationsLockID = 7313
+
+var ErrNoAppliedMigration = errors.New("no migration has been applied")
+
+// ErrNoTransaction is returned for a migration annotated with `-- +goose NO TRANSACTION`, the migrations are always
+// applied within a transaction along with their version
+var ErrNoTransaction = errors.New("migrations without a transaction are not supported")
+
+// Migration is a migration of the schema, it's version is the timestamp prefixing it's file name
+type Migration struct {
+	Version   int64
+	Name      string
+	Up        string
+	Down      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// Migrations returns the embedded migrations of the database ordered by version, along with whether they are applied
+func (d *Database) Migrations(ctx context.Context) ([]Migration, error) {
+	list, err := d.loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range list {
+		if at, ok := applied[list[i].Version]; ok {
+			list[i].Applied = true
+			list[i].AppliedAt = at
+		}
+	}
+
+	return list, nil
+}
+
+// PendingMigrations returns the migrations which are not applied yet, the schema is behind the binary when there are any
+func (d *Database) PendingMigrations(ctx context.Context) ([]Migration, error) {
+	list, err := d.Migrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range list {
+		if !migration.Applied {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// LatestMigrationVersion returns the version of the latest embedded migration, the version the schema is migrated to
+func (d *Database) LatestMigrationVersion() (int64, error) {
+	list, err := d.loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(list) == 0 {
+		return 0, nil
+	}
+
+	return list[len(list)-1].Version, nil
+}
+
+// CheckSchemaVersion returns a check which fails when the latest migration applied to the schema is behind version.
+// It only reads the latest applied version, the migrations missing before it are checked when the server starts.
+func (d *Database) CheckSchemaVersion(version int64) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var applied int64
+
+		err := d.Sqlx.GetContext(ctx, &applied, querySelectSchemaVersion)
+		if err != nil {
+			return err
+		}
+
+		if applied < version {
+			return fmt.Errorf("the schema is at version %v, the binary needs version %v", applied, version)
+		}
+
+		return nil
+	}
+}
+
+// MigrateUp applies the pending migrations in the order of their versions and returns them
+func (d *Database) MigrateUp(ctx context.Context) ([]Migration, error) {
+	pending, err := d.PendingMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, migration := range pending {
+		err = d.applyMigration(ctx, migration.Up, queryInsertMigrationVersion, migration.Version)
+		if err != nil {
+			return pending[:i], fmt.Errorf("migration %v: %w", migration.Name, err)
+		}
+		log.Info("Migration applied", zap.String("migration", migration.Name))
+	}
+
+	return pending, nil
+}
+
+// MigrateDown rolls back the latest applied migration and returns it
+func (d *Database) MigrateDown(ctx context.Context) (*Migration, error) {
+	list, err := d.Migrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(list) - 1; i >= 0; i-- {
+		if !list[i].Applied {
+			continue
+		}
+
+		migration := list[i]
+		err = d.applyMigration(ctx, migration.Down, queryDeleteMigrationVersion, migration.Version)
+		if err != nil {
+			return nil, fmt.Errorf("migration %v: %w", migration.Name, err)
+		}
+		log.Info("Migration rolled back", zap.String("migration", migration.Name))
+
+		migration.Applied = false
+		migration.AppliedAt = nil
+		return &migration, nil
+	}
+
+	return nil, ErrNoAppliedMigration
+}
+
+// MigrateRedo rolls back the latest applied migration and applies it again
+func (d *Database) MigrateRedo(ctx context.Context) (*Migration, error) {
+	migration, err := d.MigrateDown(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.applyMigration(ctx, migration.Up, queryInsertMigrationVersion, migration.Version)
+	if err != nil {
+		return nil, fmt.Errorf("migration %v: %w", migration.Name, err)
+	}
+	log.Info("Migration applied", zap.String("migration", migration.Name))
+
+	migration.Applied = true
+	return migration, nil
+}
+
+// AutoMigrate applies the pending migrations, it is used by `migrate up` and at startup.
+// On Postgres the instances migrating together wait for each other on an advisory lock, SQLite only has a single writer.
+func (d *Database) AutoMigrate(ctx context.Context) ([]Migration, error) {
+	if d.IsSQLite() {
+		return d.MigrateUp(ctx)
+	}
+
+	// The advisory lock belongs to the session, so it is taken and released on the same connection
+	conn, err := d.Sqlx.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	q, args, err := sqlx.BindNamed(sqlx.BindType(d.Sqlx.DriverName()), queryLockMigrations, map[string]interface{}{
+		"lock_id": migrationsLockID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.ExecContext(ctx, q, args...)
+	if err != nil {
+		log.Error("error locking migrations", zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		q, args, err := sqlx.BindNamed(sqlx.BindType(d.Sqlx.DriverName()), queryUnlockMigrations, map[string]interface{}{
+			"lock_id": migrationsLockID,
+		})
+		if err == nil {
+			_, err = conn.ExecContext(context.Background(), q, args...)
+		}
+		if err != nil {
+			log.Error("error unlocking migrations", zap.Error(err))
+		}
+	}()
+
+	return d.MigrateUp(ctx)
+}
+
+// applyMigration runs the statements of a migration and records it's version within a transaction
+func (d *Database) applyMigration(ctx context.Context, statements, versionQuery string, version int64) error {
+	tx, err := d.Sqlx.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if hasStatements(statements) {
+		_, err = tx.ExecContext(ctx, statements)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	q, args, err := sqlx.BindNamed(sqlx.BindType(d.Sqlx.DriverName()), versionQuery, map[string]interface{}{
+		"version_id": version,
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// appliedMigrations returns the applied versions along with when they were applied, creating the table of goose if needed
+func (d *Database) appliedMigrations(ctx context.Context) (map[int64]*time.Time, error) {
+	query := queryCreateMigrationsTable
+	if d.IsSQLite() {
+		query = queryCreateMigrationsTableSQLite
+	}
+
+	_, err := d.Sqlx.ExecContext(ctx, query)
+	if err != nil {
+		log.Error("error creating migrations table", zap.Error(err))
+		return nil, err
+	}
+
+	var rows []struct {
+		VersionID int64        `db:"version_id"`
+		IsApplied bool         `db:"is_applied"`
+		Tstamp    sql.NullTime `db:"tstamp"`
+	}
+
+	err = d.Sqlx.SelectContext(ctx, &rows, querySelectMigrationVersions)
+	if err != nil {
+		log.Error("error querying migrations", zap.Error(err))
+		return nil, err
+	}
+
+	// The latest row of a version tells whether it is applied
+	applied := make(map[int64]*time.Time)
+	seen := make(map[int64]bool)
+	for _, row := range rows {
+		if seen[row.VersionID] {
+			continue
+		}
+		seen[row.VersionID] = true
+
+		if row.IsApplied {
+			var at *time.Time
+			if row.Tstamp.Valid {
+				at = &row.Tstamp.Time
+			}
+			applied[row.VersionID] = at
+		}
+	}
+
+	return applied, nil
+}
+
+// loadMigrations reads the embedded migrations of the database ordered by version
+func (d *Database) loadMigrations() ([]Migration, error) {
+	dir := "."
+	if d.IsSQLite() {
+		dir = "sqlite"
+	}
+
+	entries, err := fs.ReadDir(migrations.FS, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Migration
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %v: the file name does not start with a version", entry.Name())
+		}
+
+		content, err := fs.ReadFile(migrations.FS, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		up, down, err := parseMigration(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("migration %v: %w", entry.Name(), err)
+		}
+		list = append(list, Migration{
+			Version: version,
+			Name:    entry.Name(),
+			Up:      up,
+			Down:    down,
+		})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+
+	return list, nil
+}
+
+// parseMigration splits a goose migration into the statements migrating up and down.
+// The statements of a direction are run together, so the StatementBegin and StatementEnd annotations are not needed.
+// ErrNoTransaction is returned for the migrations which cannot be run within a transaction.
+func parseMigration(content string) (string, string, error) {
+	var up, down strings.Builder
+
+	var section *strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch strings.TrimSpace(line) {
+		case "-- +goose NO TRANSACTION":
+			return "", "", ErrNoTransaction
+		case "-- +goose Up":
+			section = &up
+			continue
+		case "-- +goose Down":
+			section = &down
+			continue
+		}
+
+		if section == nil || strings.HasPrefix(strings.TrimSpace(line), "-- +goose") {
+			continue
+		}
+
+		section.WriteString(line)
+		section.WriteString("\n")
+	}
+
+	return up.String(), down.String(), scanner.Err()
+}
+
+// hasStatements reports whether the statements are not only comments
+func hasStatements(statements string) bool {
+	for _, line := range strings.Split(statements, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMigration(t *testing.T) {
+	up, down, err := parseMigration(`-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "users" ("user_uuid" TEXT PRIMARY KEY);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE "users";
+`)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE \"users\" (\"user_uuid\" TEXT PRIMARY KEY);\n\n", up)
+	assert.Equal(t, "DROP TABLE \"users\";\n", down)
+
+	// Case fail: The migrations are always applied within a transaction
+	_, _, err = parseMigration(`-- +goose NO TRANSACTION
+-- +goose Up
+CREATE INDEX CONCURRENTLY "idx_users_email" ON "users" ("email");
+`)
+	assert.Equal(t, ErrNoTransaction, err)
+
+	assert.False(t, hasStatements("-- The foreign key is declared with the table\n\n"))
+}
+
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+
+	driver, source := ParseDSN("sqlite://" + filepath.Join(t.TempDir(), "catalog.db"))
+	conn, err := sqlx.Connect(driver, source)
+	if err != nil {
+		t.Fatal("Failed to connect to the database:", err)
+	}
+	defer conn.Close()
+	database := &Database{Sqlx: conn}
+
+	applied, err := database.MigrateUp(ctx)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, applied)
+
+	pending, err := database.PendingMigrations(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	latest, err := database.LatestMigrationVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, applied[len(applied)-1].Version, latest)
+	assert.NoError(t, database.CheckSchemaVersion(latest)(ctx))
+
+	// Case: Redo rolls back the latest migration and applies it again
+	migration, err := database.MigrateRedo(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, applied[len(applied)-1].Version, migration.Version)
+
+	// Case: Every migration can be rolled back
+	for range applied {
+		_, err = database.MigrateDown(ctx)
+		assert.NoError(t, err)
+	}
+
+	_, err = database.MigrateDown(ctx)
+	assert.Equal(t, ErrNoAppliedMigration, err)
+
+	// Case: The schema is behind the binary
+	assert.Error(t, database.CheckSchemaVersion(latest)(ctx))
+
+	pending, err = database.PendingMigrations(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, len(applied))
+
+	// Case: The schema can be migrated again after being rolled back
+	_, err = database.MigrateUp(ctx)
+	assert.NoError(t, err)
+}
+
+func TestMigrateDuplicateServices(t *testing.T) {
+	ctx := context.Background()
+
+	driver, source := ParseDSN("sqlite://" + filepath.Join(t.TempDir(), "catalog.db"))
+	conn, err := sqlx.Connect(driver, source)
+	if err != nil {
+		t.Fatal("Failed to connect to the database:", err)
+	}
+	defer conn.Close()
+	database := &Database{Sqlx: conn}
+
+	// The schema is migrated up to the unique constraints, before which the duplicates could be created
+	pending, err := database.PendingMigrations(ctx)
+	assert.NoError(t, err)
+	for _, migration := range pending {
+		if migration.Version >= 20240511090000 {
+			break
+		}
+		assert.NoError(t, database.applyMigration(ctx, migration.Up, queryInsertMigrationVersion, migration.Version))
+	}
+
+	conn.MustExec(`INSERT INTO services(service_id, name, user_uuid) VALUES
+		(1, 'payments', 'd90f9b49-dcd9-4feb-8250-d013098e45ee'),
+		(2, 'payments', 'd90f9b49-dcd9-4feb-8250-d013098e45ee')`)
+	conn.MustExec(`INSERT INTO service_versions(sv_id, service_id, version) VALUES (1, 1, '1.0.0'), (2, 1, '1.0.0'), (3, 2, '1.0.0')`)
+	conn.MustExec(`INSERT INTO service_version_changes(sv_id, category, description) VALUES
+		(1, 'Added', 'Refunds'), (2, 'Added', 'Refunds'), (2, 'Fixed', 'Rounding')`)
+	conn.MustExec(`INSERT INTO service_version_artifacts(sv_id, type, uri, digest, size_bytes) VALUES
+		(2, 'docker', 'registry/payments:1.0.0', 'sha256:1', 1)`)
+
+	_, err = database.MigrateUp(ctx)
+	assert.NoError(t, err)
+
+	// Case: The duplicate service is renamed
+	var names []string
+	assert.NoError(t, conn.Select(&names, `SELECT name FROM services ORDER BY service_id`))
+	assert.Equal(t, []string{"payments", "payments (2)"}, names)
+
+	// Case: The duplicate version is merged into the oldest one
+	var versions []int
+	assert.NoError(t, conn.Select(&versions, `SELECT sv_id FROM service_versions ORDER BY sv_id`))
+	assert.Equal(t, []int{1, 3}, versions)
+
+	var changes []string
+	assert.NoError(t, conn.Select(&changes, `SELECT description FROM service_version_changes WHERE sv_id = 1 ORDER BY change_id`))
+	assert.Equal(t, []string{"Refunds", "Rounding"}, changes)
+
+	var artifacts int
+	assert.NoError(t, conn.Get(&artifacts, `SELECT COUNT(1) FROM service_version_artifacts WHERE sv_id = 1`))
+	assert.Equal(t, 1, artifacts)
+}
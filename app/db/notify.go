@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Notify sends the payload to the listeners of the channel of every instance, it is only supported by Postgres
+func (d *Database) Notify(ctx context.Context, channel, payload string) error {
+	_, err := d.Sqlx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, channel, payload)
+	return err
+}
+
+// Listen calls fn with the payload of every notification sent to the channel until the context is cancelled, it is
+// only supported by Postgres. The listener has it's own connection, which is opened again when it is lost, the
+// notifications sent while it is down are lost.
+func (d *Database) Listen(ctx context.Context, channel string, fn func(payload string)) error {
+	listener := pq.NewListener(d.source, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Error("Error on the connection of the listener", zap.String("channel", channel), zap.Error(err))
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(channel)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			// A nil notification is sent once the connection is opened again
+			if notification != nil {
+				fn(notification.Extra)
+			}
+		}
+	}
+}
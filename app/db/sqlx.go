@@ -17,6 +17,22 @@ func (d *Database) NamedSelectContext(ctx context.Context, dest interface{}, que
 	return d.Sqlx.SelectContext(ctx, dest, q, args...)
 }
 
+// NamedSelectInContext is used to fetch multiple rows from the database with a query having `IN (:list)` clauses,
+// the slices in the arguments are expanded to one bind variable per element
+func (d *Database) NamedSelectInContext(ctx context.Context, dest interface{}, query string, arg interface{}) error {
+	q, args, err := sqlx.Named(query, arg)
+	if err != nil {
+		return err
+	}
+
+	q, args, err = sqlx.In(q, args...)
+	if err != nil {
+		return err
+	}
+
+	return d.Sqlx.SelectContext(ctx, dest, d.Sqlx.Rebind(q), args...)
+}
+
 // NamedGetContext is used to fetch a single row from the database
 func (d *Database) NamedGetContext(ctx context.Context, dest interface{}, query string, arg interface{}) error {
 	q, args, err := sqlx.BindNamed(sqlx.BindType(d.Sqlx.DriverName()), query, arg)
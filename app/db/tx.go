@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+)
+
+// maxTxAttempts is the number of times a transaction is run before it's serialization failure is returned
+const maxTxAttempts = 3
+
+// txRetryDelay is the delay before a transaction is run again, it grows with each attempt
+var txRetryDelay = 20 * time.Millisecond
+
+// txOptions are the options of the transactions run by WithTx. They are serializable, so a transaction which depends
+// on rows changed by a concurrent transaction fails with a serialization failure on Postgres and is run again.
+// SQLite ignores the isolation level as it's transactions are serializable.
+var txOptions = &sql.TxOptions{Isolation: sql.LevelSerializable}
+
+// txKey is the key of the transaction carried by a context
+type txKey struct{}
+
+// ContextWithTx returns a context carrying the transaction, the transactions run with it are nested in it
+func ContextWithTx(ctx context.Context, tx *sqlx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// WithTx runs fn in a transaction, which is committed when fn returns nil and rolled back otherwise or when fn panics.
+// The transaction is run again when it fails because of a concurrent transaction (a serialization failure or a deadlock
+// on Postgres, a busy database on SQLite), so fn must not have side effects outside of the transaction.
+// When the context carries a transaction, fn runs in a savepoint of it instead and is committed along with it.
+// The transaction is serializable, WithTxOptions runs it with other options.
+func (d *Database) WithTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	return d.WithTxOptions(ctx, txOptions, fn)
+}
+
+// WithTxOptions runs fn in a transaction with the given options like WithTx, a nested transaction uses the options of
+// the transaction it is nested in
+func (d *Database) WithTxOptions(ctx context.Context, opts *sql.TxOptions, fn func(tx *sqlx.Tx) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return runSavepoint(ctx, tx, fn)
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = d.runTx(ctx, opts, fn)
+		if err == nil || attempt == maxTxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		log.Info("Retrying transaction", zap.Int("attempt", attempt), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * txRetryDelay):
+		}
+	}
+}
+
+// runTx runs fn in a single transaction
+func (d *Database) runTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *sqlx.Tx) error) (err error) {
+	tx, err := d.Sqlx.BeginTxx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// runSavepoint runs fn in a savepoint of the transaction, only the statements of fn are rolled back when it fails
+func runSavepoint(ctx context.Context, tx *sqlx.Tx, fn func(tx *sqlx.Tx) error) (err error) {
+	_, err = tx.ExecContext(ctx, "SAVEPOINT nested_tx")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT nested_tx")
+			panic(p)
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT nested_tx")
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT nested_tx")
+	return err
+}
+
+// isRetryable reports whether a transaction failed because of a concurrent transaction and can be run again
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// serialization_failure and deadlock_detected
+		return pqErr.Code == "40001" || pqErr.Code == "40P01"
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+
+	return false
+}
@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTxTest(t *testing.T) *Database {
+	driver, source := ParseDSN("sqlite://" + filepath.Join(t.TempDir(), "tx.db"))
+	conn, err := sqlx.Connect(driver, source)
+	if err != nil {
+		t.Fatal("Failed to connect to the database:", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	_, err = conn.Exec(`CREATE TABLE items (name TEXT NOT NULL)`)
+	if err != nil {
+		t.Fatal("Failed to create table:", err)
+	}
+
+	txRetryDelay = 0
+	return &Database{Sqlx: conn}
+}
+
+func countItems(t *testing.T, database *Database) int {
+	var count int
+	if err := database.Sqlx.Get(&count, `SELECT COUNT(1) FROM items`); err != nil {
+		t.Fatal("Failed to count items:", err)
+	}
+	return count
+}
+
+func insertItem(ctx context.Context, tx *sqlx.Tx, name string) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO items(name) VALUES(?)`, name)
+	return err
+}
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	database := setupTxTest(t)
+
+	err := database.WithTx(ctx, func(tx *sqlx.Tx) error {
+		return insertItem(ctx, tx, "committed")
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, countItems(t, database))
+
+	// Case fail: The statements are rolled back when fn fails
+	failure := errors.New("failure")
+	err = database.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := insertItem(ctx, tx, "rolled back"); err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.Equal(t, failure, err)
+	assert.Equal(t, 1, countItems(t, database))
+
+	// Case fail: The statements are rolled back when fn panics
+	assert.Panics(t, func() {
+		database.WithTx(ctx, func(tx *sqlx.Tx) error {
+			insertItem(ctx, tx, "rolled back")
+			panic("failure")
+		})
+	})
+	assert.Equal(t, 1, countItems(t, database))
+}
+
+func TestWithTxRetry(t *testing.T) {
+	ctx := context.Background()
+	database := setupTxTest(t)
+
+	// Case: A busy database is retried
+	attempts := 0
+	err := database.WithTx(ctx, func(tx *sqlx.Tx) error {
+		attempts++
+		if err := insertItem(ctx, tx, "retried"); err != nil {
+			return err
+		}
+		if attempts == 1 {
+			return sqlite3.Error{Code: sqlite3.ErrBusy}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, 1, countItems(t, database))
+
+	// Case fail: The transaction is run at most maxTxAttempts times
+	attempts = 0
+	err = database.WithTx(ctx, func(tx *sqlx.Tx) error {
+		attempts++
+		return sqlite3.Error{Code: sqlite3.ErrBusy}
+	})
+	assert.Error(t, err)
+	assert.Equal(t, maxTxAttempts, attempts)
+
+	// Case fail: Other errors are not retried
+	attempts = 0
+	err = database.WithTx(ctx, func(tx *sqlx.Tx) error {
+		attempts++
+		return errors.New("failure")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestWithTxNested(t *testing.T) {
+	ctx := context.Background()
+	database := setupTxTest(t)
+
+	err := database.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := insertItem(ctx, tx, "outer"); err != nil {
+			return err
+		}
+
+		txCtx := ContextWithTx(ctx, tx)
+
+		// Case fail: Only the statements of the failed nested transaction are rolled back
+		err := database.WithTx(txCtx, func(tx *sqlx.Tx) error {
+			if err := insertItem(ctx, tx, "rolled back"); err != nil {
+				return err
+			}
+			return errors.New("failure")
+		})
+		assert.Error(t, err)
+
+		return database.WithTx(txCtx, func(tx *sqlx.Tx) error {
+			return insertItem(ctx, tx, "nested")
+		})
+	})
+	assert.NoError(t, err)
+
+	var names []string
+	assert.NoError(t, database.Sqlx.Select(&names, `SELECT name FROM items ORDER BY rowid`))
+	assert.Equal(t, []string{"outer", "nested"}, names)
+}
+
+// setupPostgresTxTest connects to the Postgres database of the configuration and creates a table for the test, it
+// returns the name of the table. The test is skipped when the configuration has no Postgres database.
+func setupPostgresTxTest(t *testing.T) (*Database, string) {
+	config := viper.New()
+	config.AddConfigPath("../..")
+	config.SetConfigName("config")
+	config.SetConfigType("yaml")
+	config.ReadInConfig()
+
+	driver, source := ParseDSN(config.GetString("dsn"))
+	if source == "" || driver != DriverPostgres {
+		t.Skip("Postgres is not configured")
+	}
+
+	conn, err := sqlx.Connect(driver, source)
+	if err != nil {
+		t.Skip("Postgres is not available: ", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	table := fmt.Sprintf("tx_test_items_%d", time.Now().UnixNano())
+	_, err = conn.Exec(`CREATE TABLE ` + table + ` (name TEXT NOT NULL)`)
+	if err != nil {
+		t.Fatal("Failed to create table:", err)
+	}
+	t.Cleanup(func() { conn.Exec(`DROP TABLE ` + table) })
+
+	txRetryDelay = 0
+	return &Database{Sqlx: conn}, table
+}
+
+func TestWithTxSerializationRetry(t *testing.T) {
+	ctx := context.Background()
+	database, table := setupPostgresTxTest(t)
+
+	// Case: Two transactions insert a row when they count none, one of them fails with a serialization failure once
+	// both counted and is run again, so only one of them inserts
+	var (
+		mu       sync.Mutex
+		attempts int
+		counted  sync.WaitGroup
+		wg       sync.WaitGroup
+		inserted [2]bool
+		errs     [2]error
+	)
+	counted.Add(2)
+
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			first := true
+			errs[i] = database.WithTx(ctx, func(tx *sqlx.Tx) error {
+				mu.Lock()
+				attempts++
+				mu.Unlock()
+
+				var count int
+				if err := tx.GetContext(ctx, &count, `SELECT COUNT(1) FROM `+table); err != nil {
+					return err
+				}
+
+				// The first attempts wait for each other to count before inserting
+				if first {
+					first = false
+					counted.Done()
+					counted.Wait()
+				}
+
+				inserted[i] = false
+				if count > 0 {
+					return nil
+				}
+
+				_, err := tx.ExecContext(ctx, `INSERT INTO `+table+`(name) VALUES($1)`, fmt.Sprint("item-", i))
+				inserted[i] = err == nil
+				return err
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+	assert.Equal(t, 3, attempts)
+	assert.True(t, inserted[0] != inserted[1])
+
+	var count int
+	assert.NoError(t, database.Sqlx.Get(&count, `SELECT COUNT(1) FROM `+table))
+	assert.Equal(t, 1, count)
+}
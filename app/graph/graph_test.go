@@ -0,0 +1,159 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// testSchema has a list of parents, each parent loading it's children through a loader
+func testSchema(loader *Loader[int, []string]) graphql.Schema {
+	parentType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Parent",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.Int,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source, nil
+				},
+			},
+			"children": &graphql.Field{
+				Type: graphql.NewList(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loader.Load(p.Context, p.Source.(int)), nil
+				},
+			},
+		},
+	})
+
+	schema, _ := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"parents": &graphql.Field{
+					Type: graphql.NewList(parentType),
+					Args: graphql.FieldConfigArgument{
+						"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 3},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						parents := []int{}
+						for i := 1; i <= p.Args["limit"].(int); i++ {
+							parents = append(parents, i)
+						}
+						return parents, nil
+					},
+				},
+			},
+		}),
+	})
+
+	return schema
+}
+
+func TestLoaderBatchesLoads(t *testing.T) {
+	var batches [][]int
+	loader := NewLoader(func(ctx context.Context, keys []int) (map[int][]string, error) {
+		batches = append(batches, append([]int{}, keys...))
+
+		children := make(map[int][]string)
+		for _, key := range keys {
+			if key != 2 {
+				children[key] = []string{"child"}
+			}
+		}
+		return children, nil
+	})
+
+	result, executed := Execute(context.Background(), testSchema(loader), Request{
+		Query: `{ parents { id children } again: parents { children } }`,
+	}, DefaultLimits)
+
+	assert.True(t, executed)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, [][]int{{1, 2, 3}}, batches)
+
+	parents := result.Data.(map[string]interface{})["parents"].([]interface{})
+	assert.Len(t, parents, 3)
+	assert.Equal(t, []interface{}{"child"}, parents[0].(map[string]interface{})["children"])
+	assert.Empty(t, parents[1].(map[string]interface{})["children"])
+}
+
+func TestLimits(t *testing.T) {
+	schema, _ := graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		valid     bool
+	}{
+		{
+			name:  "Services with versions and changes",
+			query: `{ me { email } services { name versions { version changes { category description } } } }`,
+			valid: true,
+		},
+		{
+			name:  "Introspection is not counted",
+			query: `{ __schema { types { name fields { name type { name ofType { name ofType { name ofType { name } } } } } } } }`,
+			valid: true,
+		},
+		{
+			name:  "Large page of services with versions",
+			query: `{ services(limit: 1000) { name versions { version changes { category } } } }`,
+			valid: false,
+		},
+		{
+			name:      "Large page of services from a variable",
+			query:     `query($limit: Int) { services(limit: $limit) { name versions { version changes { category } } } }`,
+			variables: map[string]interface{}{"limit": float64(1000)},
+			valid:     false,
+		},
+		{
+			name: "Nested fragments",
+			query: `
+			{ services { ...service } }
+			fragment service on Service { versions { ...version } }
+			fragment version on Version { changes { category } }`,
+			valid: true,
+		},
+	}
+
+	for _, test := range tests {
+		result, executed := Execute(context.Background(), schema, Request{
+			Query:     test.query,
+			Variables: test.variables,
+		}, Limits{MaxDepth: 6, MaxComplexity: 10000, ListSize: 10})
+
+		if test.valid {
+			// Executing without the context of a request fails in the resolvers, not before
+			assert.True(t, executed, test.name)
+		} else {
+			assert.False(t, executed, test.name)
+			assert.NotEmpty(t, result.Errors, test.name)
+		}
+	}
+}
+
+func TestLimitsDepth(t *testing.T) {
+	loader := NewLoader(func(ctx context.Context, keys []int) (map[int][]string, error) {
+		return nil, nil
+	})
+	schema := testSchema(loader)
+
+	result, executed := Execute(context.Background(), schema, Request{Query: `{ parents { id } }`}, Limits{MaxDepth: 1, MaxComplexity: 100, ListSize: 10})
+	assert.False(t, executed)
+	assert.Equal(t, "query depth 2 exceeds the maximum depth of 1", result.Errors[0].Message)
+
+	result, executed = Execute(context.Background(), schema, Request{Query: `{ parents(limit: 20) { id } }`}, Limits{MaxDepth: 2, MaxComplexity: 20, ListSize: 10})
+	assert.False(t, executed)
+	assert.Equal(t, "query complexity 21 exceeds the maximum complexity of 20", result.Errors[0].Message)
+
+	_, executed = Execute(context.Background(), schema, Request{Query: `{ parents { ... on Parent { id } } }`}, Limits{MaxDepth: 2, MaxComplexity: 100, ListSize: 10})
+	assert.True(t, executed)
+
+	// Invalid queries are rejected by the validation
+	_, executed = Execute(context.Background(), schema, Request{Query: `{ parents { unknown } }`}, DefaultLimits)
+	assert.False(t, executed)
+}
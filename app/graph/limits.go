@@ -0,0 +1,173 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits bound the cost of a query, they are checked before the query is executed
+type Limits struct {
+	// MaxDepth is the maximum nesting of fields
+	MaxDepth int
+	// MaxComplexity is the maximum estimated number of fields resolved
+	MaxComplexity int
+	// ListSize is the number of items assumed for lists without a `limit` argument
+	ListSize int
+}
+
+// DefaultLimits allow the services with their versions and changes of a page but not much deeper queries
+var DefaultLimits = Limits{
+	MaxDepth:      6,
+	MaxComplexity: 10000,
+	ListSize:      10,
+}
+
+// cost is the depth and the complexity of a selection set
+type cost struct {
+	depth      int
+	complexity int
+}
+
+// Check returns an error when an operation of the document exceeds the limits.
+// The complexity of a field is 1 plus the complexity of it's selections, multiplied by the size of the list for lists.
+// Introspection fields are not counted as they only read the schema.
+func (l Limits) Check(schema graphql.Schema, document *ast.Document, variables map[string]interface{}) error {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		root := schema.QueryType()
+		if operation.Operation == ast.OperationTypeMutation {
+			root = schema.MutationType()
+		}
+		if root == nil {
+			continue
+		}
+
+		w := walker{limits: l, fragments: fragments, variables: variables}
+		c := w.selectionSet(root, operation.SelectionSet, map[string]bool{})
+
+		if c.depth > l.MaxDepth {
+			return fmt.Errorf("query depth %d exceeds the maximum depth of %d", c.depth, l.MaxDepth)
+		}
+		if c.complexity > l.MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the maximum complexity of %d", c.complexity, l.MaxComplexity)
+		}
+	}
+
+	return nil
+}
+
+// walker computes the cost of the selection sets of an operation
+type walker struct {
+	limits    Limits
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+func (w walker) selectionSet(parent *graphql.Object, set *ast.SelectionSet, visited map[string]bool) cost {
+	var c cost
+	if set == nil || parent == nil {
+		return c
+	}
+
+	for _, selection := range set.Selections {
+		var s cost
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			s = w.field(parent, selection, visited)
+		case *ast.InlineFragment:
+			s = w.selectionSet(parent, selection.SelectionSet, visited)
+			s.depth--
+		case *ast.FragmentSpread:
+			// Cycles are reported by the validation, they are only cut here
+			name := selection.Name.Value
+			fragment, ok := w.fragments[name]
+			if !ok || visited[name] {
+				continue
+			}
+			visited[name] = true
+			s = w.selectionSet(parent, fragment.SelectionSet, visited)
+			s.depth--
+			delete(visited, name)
+		}
+
+		c.complexity += s.complexity
+		if s.depth+1 > c.depth {
+			c.depth = s.depth + 1
+		}
+	}
+
+	return c
+}
+
+func (w walker) field(parent *graphql.Object, field *ast.Field, visited map[string]bool) cost {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return cost{}
+	}
+
+	definition, ok := parent.Fields()[field.Name.Value]
+	if !ok {
+		return cost{complexity: 1}
+	}
+
+	multiplier := 1
+	fieldType := definition.Type
+	if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+		fieldType = nonNull.OfType
+	}
+	if list, ok := fieldType.(*graphql.List); ok {
+		multiplier = w.listSize(field)
+		fieldType = list.OfType
+		if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+			fieldType = nonNull.OfType
+		}
+	}
+
+	object, _ := fieldType.(*graphql.Object)
+	children := w.selectionSet(object, field.SelectionSet, visited)
+
+	return cost{
+		depth:      children.depth,
+		complexity: 1 + multiplier*children.complexity,
+	}
+}
+
+// listSize returns the `limit` argument of a list field or the default list size
+func (w walker) listSize(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "limit" {
+			continue
+		}
+
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if size, err := strconv.Atoi(value.Value); err == nil && size > 0 {
+				return size
+			}
+		case *ast.Variable:
+			if size, ok := w.variables[value.Name.Value].(float64); ok && size > 0 {
+				return int(size)
+			}
+			if size, ok := w.variables[value.Name.Value].(int); ok && size > 0 {
+				return size
+			}
+		}
+	}
+
+	return w.limits.ListSize
+}
@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"context"
+	"sync"
+)
+
+// Loader batches the loads of a request into a single fetch, it avoids running a query per parent in lists.
+// Load returns a thunk which the executor calls once all the fields of the current level are resolved,
+// the first thunk called fetches every key collected so far. The results are cached for the rest of the request.
+type Loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	cache   map[K]V
+}
+
+// NewLoader returns a loader using fetch to load a batch of keys, keys missing from the result get the zero value
+func NewLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch: fetch,
+		cache: make(map[K]V),
+	}
+}
+
+// Load queues a key to be fetched with the next batch and returns a thunk resolving it's value
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.cache[key]; !ok {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		return l.load(ctx, key)
+	}
+}
+
+// load returns the value of a key, fetching the pending keys if it has not been fetched yet
+func (l *Loader[K, V]) load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if value, ok := l.cache[key]; ok {
+		return value, nil
+	}
+
+	keys := unique(append(l.pending, key))
+	l.pending = nil
+
+	values, err := l.fetch(ctx, keys)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	for _, k := range keys {
+		l.cache[k] = values[k]
+	}
+
+	return l.cache[key], nil
+}
+
+// unique removes the duplicated keys keeping their order
+func unique[K comparable](keys []K) []K {
+	seen := make(map[K]bool, len(keys))
+	result := keys[:0]
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, key)
+		}
+	}
+	return result
+}
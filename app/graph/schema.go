@@ -0,0 +1,351 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/ZiyanK/service-catalog-api/app/logger"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	"go.uber.org/zap"
+)
+
+var (
+	log = logger.CreateLogger()
+)
+
+// contextKey is the type of the keys of the values stored in the context of a request
+type contextKey struct{}
+
+// loaders are the loaders of a request, they are created for each request so that nothing is cached across users
+type loaders struct {
+	userUUID uuid.UUID
+	services model.ServiceRepository
+
+	versions *Loader[int, []model.ServiceVersion]
+	entities *Loader[int, model.ServiceEntity]
+	users    *Loader[uuid.UUID, *model.User]
+}
+
+// NewContext returns a context carrying the user of the request and the loaders used to resolve it's query,
+// the query reads through the given repositories
+func NewContext(ctx context.Context, userUUID uuid.UUID, repos model.Repositories) context.Context {
+	return context.WithValue(ctx, contextKey{}, &loaders{
+		userUUID: userUUID,
+		services: repos.Services,
+		versions: NewLoader(func(ctx context.Context, serviceIDs []int) (map[int][]model.ServiceVersion, error) {
+			return repos.Versions.GetVersionsOfServices(ctx, serviceIDs, userUUID)
+		}),
+		entities: NewLoader(func(ctx context.Context, serviceIDs []int) (map[int]model.ServiceEntity, error) {
+			return repos.Catalog.GetServiceEntities(ctx, serviceIDs, userUUID)
+		}),
+		users: NewLoader(func(ctx context.Context, userUUIDs []uuid.UUID) (map[uuid.UUID]*model.User, error) {
+			users := make(map[uuid.UUID]*model.User, len(userUUIDs))
+			for _, id := range userUUIDs {
+				user, err := repos.Users.GetUserByID(ctx, id)
+				if err != nil {
+					return nil, err
+				}
+				users[id] = user
+			}
+			return users, nil
+		}),
+	})
+}
+
+// fromContext returns the loaders of the request
+func fromContext(ctx context.Context) (*loaders, error) {
+	l, ok := ctx.Value(contextKey{}).(*loaders)
+	if !ok {
+		return nil, errors.New("unauthorized")
+	}
+	return l, nil
+}
+
+var changeType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Change",
+	Description: "A change listed in the changelog of a version",
+	Fields: graphql.Fields{
+		"category": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.ServiceVersionChange).Category, nil
+			},
+		},
+		"description": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.ServiceVersionChange).Description, nil
+			},
+		},
+	},
+})
+
+var versionType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Version",
+	Description: "A version of a service",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.ServiceVersion).SvID, nil
+			},
+		},
+		"version": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.ServiceVersion).Version, nil
+			},
+		},
+		"changelog": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.ServiceVersion).Changelog, nil
+			},
+		},
+		"changes": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(changeType))),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				changes := p.Source.(model.ServiceVersion).Changes
+				if changes == nil {
+					changes = []model.ServiceVersionChange{}
+				}
+				return changes, nil
+			},
+		},
+		"createdAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.ServiceVersion).CreatedAt, nil
+			},
+		},
+		"updatedAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.ServiceVersion).UpdatedAt, nil
+			},
+		},
+	},
+})
+
+var userType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "User",
+	Description: "A user of the catalog, the owner of it's services",
+	Fields: graphql.Fields{
+		"email": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*model.User).Email, nil
+			},
+		},
+	},
+})
+
+var serviceType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Service",
+	Description: "A service of the catalog",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.Service).ServiceID, nil
+			},
+		},
+		"name": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.Service).Name, nil
+			},
+		},
+		"description": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.Service).Description, nil
+			},
+		},
+		"versionsCount": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.Service).VersionsCount, nil
+			},
+		},
+		"createdAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.Service).CreatedAt, nil
+			},
+		},
+		"updatedAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.Service).UpdatedAt, nil
+			},
+		},
+		"owner": &graphql.Field{
+			Type: graphql.NewNonNull(userType),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				l, err := fromContext(p.Context)
+				if err != nil {
+					return nil, err
+				}
+				return l.users.Load(p.Context, l.userUUID), nil
+			},
+		},
+		"versions": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(versionType))),
+			Description: "The versions of the service, newest first",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				l, err := fromContext(p.Context)
+				if err != nil {
+					return nil, err
+				}
+
+				thunk := l.versions.Load(p.Context, p.Source.(model.Service).ServiceID)
+				return func() (interface{}, error) {
+					versions, err := thunk()
+					if err != nil {
+						return nil, err
+					}
+					if versions.([]model.ServiceVersion) == nil {
+						return []model.ServiceVersion{}, nil
+					}
+					return versions, nil
+				}, nil
+			},
+		},
+		"dependencies": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+			Description: "The entity references listed in `spec.dependsOn` of the Backstage entity the service was imported from",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				l, err := fromContext(p.Context)
+				if err != nil {
+					return nil, err
+				}
+
+				thunk := l.entities.Load(p.Context, p.Source.(model.Service).ServiceID)
+				return func() (interface{}, error) {
+					entity, err := thunk()
+					if err != nil {
+						return nil, err
+					}
+					return dependencies(entity.(model.ServiceEntity)), nil
+				}, nil
+			},
+		},
+	},
+})
+
+var queryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"me": &graphql.Field{
+			Type:        graphql.NewNonNull(userType),
+			Description: "The user making the request",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				l, err := fromContext(p.Context)
+				if err != nil {
+					return nil, err
+				}
+				return l.users.Load(p.Context, l.userUUID), nil
+			},
+		},
+		"services": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(serviceType))),
+			Description: "The services of the user, with the same filters and pagination as `GET /services`",
+			Args: graphql.FieldConfigArgument{
+				"name":   &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: ""},
+				"limit":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10},
+				"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				"order":  &graphql.ArgumentConfig{Type: orderType, DefaultValue: "ASC"},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				l, err := fromContext(p.Context)
+				if err != nil {
+					return nil, err
+				}
+
+				services, err := l.services.GetServices(p.Context, l.userUUID, p.Args["limit"].(int), p.Args["offset"].(int),
+					p.Args["name"].(string), p.Args["order"].(string))
+				if err != nil {
+					return nil, errors.New("error fetching services")
+				}
+				if services == nil {
+					services = []model.Service{}
+				}
+
+				return services, nil
+			},
+		},
+		"service": &graphql.Field{
+			Type:        serviceType,
+			Description: "A service of the user, null if it does not exist",
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				l, err := fromContext(p.Context)
+				if err != nil {
+					return nil, err
+				}
+
+				service, err := l.services.GetServiceByID(p.Context, p.Args["id"].(int), l.userUUID)
+				if err != nil {
+					if errors.Is(err, model.ErrServiceNotFound) {
+						return nil, nil
+					}
+					return nil, errors.New("error fetching service")
+				}
+
+				return *service, nil
+			},
+		},
+	},
+})
+
+var orderType = graphql.NewEnum(graphql.EnumConfig{
+	Name:        "Order",
+	Description: "The order of the services by creation date",
+	Values: graphql.EnumValueConfigMap{
+		"ASC":  &graphql.EnumValueConfig{Value: "ASC"},
+		"DESC": &graphql.EnumValueConfig{Value: "DESC"},
+	},
+})
+
+// Schema is the GraphQL schema of the catalog, it only has queries
+var Schema graphql.Schema
+
+func init() {
+	var err error
+	Schema, err = graphql.NewSchema(graphql.SchemaConfig{
+		Query: queryType,
+	})
+	if err != nil {
+		log.Fatal("Error creating the GraphQL schema", zap.Error(err))
+	}
+}
+
+// dependencies returns the entity references in `spec.dependsOn` of a Backstage entity
+func dependencies(entity model.ServiceEntity) []string {
+	result := []string{}
+	if entity.Spec == "" {
+		return result
+	}
+
+	var spec struct {
+		DependsOn []string `json:"dependsOn"`
+	}
+
+	err := json.Unmarshal([]byte(entity.Spec), &spec)
+	if err != nil {
+		log.Info("invalid spec of service entity", zap.Int("service_id", entity.ServiceID), zap.Error(err))
+		return result
+	}
+
+	if spec.DependsOn != nil {
+		result = spec.DependsOn
+	}
+
+	return result
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// digestRegexp matches a SHA-256 digest in the `sha256:<hex>` form used by OCI registries
+var digestRegexp = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// ArtifactInput is a struct used to take the reference, checksum and size of an artifact for a given service version
+type ArtifactInput struct {
+	Type      string `json:"type" validate:"required,oneof=image tarball sbom"`
+	URI       string `json:"uri" validate:"required,max=2048"`
+	Digest    string `json:"digest" validate:"required"`
+	SizeBytes int64  `json:"size_bytes" validate:"required,gt=0"`
+}
+
+// normalizeDigest lowercases the digest and adds the `sha256:` prefix if only the hex checksum is given
+func normalizeDigest(digest string) (string, bool) {
+	digest = strings.ToLower(strings.TrimSpace(digest))
+	if !strings.HasPrefix(digest, "sha256:") {
+		digest = "sha256:" + digest
+	}
+
+	return digest, digestRegexp.MatchString(digest)
+}
+
+// HandlerCreateArtifact attaches a new artifact to a given service version
+func HandlerCreateArtifact(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	serviceIDstring := c.Param("id")
+	serviceID, err := strconv.Atoi(serviceIDstring)
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	svIDstring := c.Param("vid")
+	svID, err := strconv.Atoi(svIDstring)
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	var body ArtifactInput
+
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		c.Error(problem.InvalidBody(err))
+		return
+	}
+
+	err = validate.Struct(body)
+	if err != nil {
+		c.Error(problem.Validation(err))
+		return
+	}
+
+	digest, ok := normalizeDigest(body.Digest)
+	if !ok {
+		c.Error(problem.New(http.StatusBadRequest, "invalid_digest", "Invalid digest. Expected a SHA-256 checksum."))
+		return
+	}
+
+	artifact := model.Artifact{
+		SvID:      svID,
+		Type:      body.Type,
+		URI:       body.URI,
+		Digest:    digest,
+		SizeBytes: body.SizeBytes,
+	}
+
+	err = repos.Artifacts.CreateArtifact(c.Request.Context(), userUUID, serviceID, &artifact)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+// HandlerGetArtifacts fetches all the artifacts attached to a given service version
+func HandlerGetArtifacts(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	serviceIDstring := c.Param("id")
+	serviceID, err := strconv.Atoi(serviceIDstring)
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	svIDstring := c.Param("vid")
+	svID, err := strconv.Atoi(svIDstring)
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	artifacts, err := repos.Artifacts.GetServiceVersionArtifacts(c.Request.Context(), userUUID, serviceID, svID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if len(artifacts) == 0 {
+		c.JSON(http.StatusNoContent, gin.H{
+			"msg": "No artifacts found.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "Artifacts fetched successfully.",
+		"data": artifacts,
+	})
+}
+
+// HandlerLookupArtifact finds the service version an artifact digest belongs to
+func HandlerLookupArtifact(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	digest, ok := normalizeDigest(c.Query("digest"))
+	if !ok {
+		c.Error(problem.InvalidParameter("digest", err))
+		return
+	}
+
+	lookup, err := repos.Artifacts.GetArtifactByDigest(c.Request.Context(), userUUID, digest)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "Artifact fetched successfully.",
+		"data": lookup,
+	})
+}
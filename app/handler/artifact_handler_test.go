@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerCreateArtifact(t *testing.T) {
+	router := SetupDBTest(t)
+
+	route := "/service/:id/version/:vid/artifact"
+	router.Use(middleware.VerifyAuthToken)
+	router.POST(route, HandlerCreateArtifact)
+	body := ArtifactInput{
+		Type:      "image",
+		URI:       "registry.example.com/backend@sha256:" + strings.Repeat("b", 64),
+		Digest:    strings.Repeat("b", 64),
+		SizeBytes: 1024,
+	}
+	jsonValue, _ := json.Marshal(body)
+
+	// Case fail: Service version does not exist
+	req, _ := http.NewRequest(http.MethodPost, "/service/1000/version/1000/artifact", bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Case fail: Digest is not a SHA-256 checksum
+	body.Digest = "md5:1234"
+	jsonValue, _ = json.Marshal(body)
+
+	req, _ = http.NewRequest(http.MethodPost, "/service/1000/version/1000/artifact", bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandlerLookupArtifact(t *testing.T) {
+	router := SetupDBTest(t)
+
+	route := "/artifacts/lookup"
+	router.Use(middleware.VerifyAuthToken)
+	router.GET(route, HandlerLookupArtifact)
+
+	// Case fail: Unknown digest
+	req, _ := http.NewRequest(http.MethodGet, route+"?digest=sha256:"+strings.Repeat("c", 64), nil)
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Case fail: Invalid digest
+	req, _ = http.NewRequest(http.MethodGet, route+"?digest=invalid", nil)
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
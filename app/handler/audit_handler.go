@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// auditVerifyBatchSize is the number of entries read at once when verifying the audit log
+const auditVerifyBatchSize = 1000
+
+// HandlerGetAuditEvents fetches the audit log of the changes made by the user with filters and pagination.
+// The from and to query parameters are RFC 3339 timestamps.
+func HandlerGetAuditEvents(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filter := model.AuditFilter{
+		ActorUUID:  userUUID,
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		RequestID:  c.Query("request_id"),
+	}
+
+	// Get the limit and offset query parameters from the URL
+	filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.Error(problem.InvalidParameter("limit", err))
+		return
+	}
+
+	filter.Offset, err = strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.Error(problem.InvalidParameter("offset", err))
+		return
+	}
+
+	if from := c.Query("from"); from != "" {
+		filter.From, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			c.Error(problem.InvalidParameter("from", err))
+			return
+		}
+	}
+
+	if to := c.Query("to"); to != "" {
+		filter.To, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			c.Error(problem.InvalidParameter("to", err))
+			return
+		}
+	}
+
+	events, err := repos.Audit.GetAuditEvents(c.Request.Context(), filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if len(events) == 0 {
+		c.JSON(http.StatusNoContent, gin.H{
+			"msg": "No audit events found.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "Audit events fetched successfully.",
+		"data": events,
+	})
+}
+
+// HandlerVerifyAuditLog recomputes the hash chain of the audit log to check that no entry was modified or deleted
+func HandlerVerifyAuditLog(c *gin.Context) {
+	verification, err := repos.Audit.VerifyAuditChain(c.Request.Context(), auditVerifyBatchSize)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if !verification.Valid {
+		c.JSON(http.StatusConflict, gin.H{
+			"msg":  "Audit log was tampered with.",
+			"data": verification,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "Audit log verified successfully.",
+		"data": verification,
+	})
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerGetAuditEvents(t *testing.T) {
+	router := SetupDBTest(t)
+
+	route := "/audit"
+	router.Use(middleware.VerifyAuthToken)
+	router.GET(route, HandlerGetAuditEvents)
+
+	req, _ := http.NewRequest(http.MethodGet, route+"?target_type=service&limit=10", nil)
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Contains(t, []int{http.StatusOK, http.StatusNoContent}, w.Code)
+
+	// Case fail: Invalid timestamp
+	req, _ = http.NewRequest(http.MethodGet, route+"?from=yesterday", nil)
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid from value")
+}
+
+func TestHandlerVerifyAuditLog(t *testing.T) {
+	router := SetupDBTest(t)
+
+	route := "/audit/verify"
+	router.Use(middleware.VerifyAuthToken)
+	router.GET(route, HandlerVerifyAuditLog)
+
+	req, _ := http.NewRequest(http.MethodGet, route, nil)
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"valid":true`)
+}
@@ -1,16 +1,14 @@
 package handler
 
 import (
-	"context"
-	"database/sql"
 	"net/http"
 	"time"
 
 	"github.com/ZiyanK/service-catalog-api/app/logger"
 	"github.com/ZiyanK/service-catalog-api/app/middleware"
 	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
 	"github.com/spf13/viper"
@@ -19,7 +17,8 @@ import (
 )
 
 var (
-	log = logger.CreateLogger()
+	log      = logger.CreateLogger()
+	validate = problem.NewValidator()
 )
 
 // AuthInput is a struct used to get the email and the password from the user
@@ -34,26 +33,20 @@ func HandlerSignUp(c *gin.Context) {
 
 	err := c.ShouldBindJSON(&body)
 	if err != nil {
-		log.Error("Error while reading request body for signup", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"msg": "Invalid body.",
-		})
+		c.Error(problem.InvalidBody(err))
 		return
 	}
 
-	err = validator.New().Struct(body)
+	err = validate.Struct(body)
 	if err != nil {
-		log.Info("validator error", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"msg": "Invalid body.",
-		})
+		c.Error(problem.Validation(err))
 		return
 	}
 
 	// hash password
 	password, err := middleware.HashValue(body.Password)
 	if err != nil {
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
@@ -63,15 +56,9 @@ func HandlerSignUp(c *gin.Context) {
 		Password: password,
 	}
 
-	err = createUserObj.CreateUser(context.TODO())
+	err = repos.Users.CreateUser(c.Request.Context(), createUserObj)
 	if err != nil {
-		if err.Error() == "mail exists" {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"msg": "Try using a different email.",
-			})
-			return
-		}
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
@@ -82,7 +69,7 @@ func HandlerSignUp(c *gin.Context) {
 	jwtSecret := viper.Get("jwt_secret").(string)
 	token, err := generateAuthToken(jwtSecret, claims)
 	if err != nil {
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
@@ -108,44 +95,32 @@ func HandlerLogin(c *gin.Context) {
 
 	err := c.ShouldBindJSON(&body)
 	if err != nil {
-		log.Info("Error while reading request body for login", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"msg": "Invalid body",
-		})
+		c.Error(problem.InvalidBody(err))
 		return
 	}
 
 	// Validate request body
-	err = validator.New().Struct(body)
+	err = validate.Struct(body)
 	if err != nil {
-		log.Info("validator error", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"msg": "Invalid body",
-		})
+		c.Error(problem.Validation(err))
 		return
 	}
 
 	// Check if user exists
-	user, err := model.GetUserByEmail(context.TODO(), body.Email)
+	user, err := repos.Users.GetUserByEmail(c.Request.Context(), body.Email)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"msg": "Invalid email or password. Please try again.",
-			})
+		if err == model.ErrUserNotFound {
+			c.Error(problem.New(http.StatusUnauthorized, problem.CodeInvalidCredentials, "Invalid email or password. Please try again."))
 			return
 		}
-		log.Error("Error when fetching user by email", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
 	// Check if password matches
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.Password))
 	if err != nil {
-		log.Info("Password does not match")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"msg": "Invalid email or password. Please try again.",
-		})
+		c.Error(problem.New(http.StatusBadRequest, problem.CodeInvalidCredentials, "Invalid email or password. Please try again."))
 		return
 	}
 
@@ -157,7 +132,7 @@ func HandlerLogin(c *gin.Context) {
 	jwtSecret := viper.Get("jwt_secret").(string)
 	token, err := generateAuthToken(jwtSecret, claims)
 	if err != nil {
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
@@ -2,14 +2,22 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/ZiyanK/service-catalog-api/app/db"
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/model/memory"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
@@ -24,27 +32,113 @@ type configuration struct {
 var (
 	config   configuration
 	UserUUID uuid.UUID
+
+	// repo is shared by the tests as the database would be
+	repo *memory.Repository
+
+	setupDBOnce sync.Once
+	setupDBErr  error
+	sqliteDir   string
 )
 
+// SetupTest returns a router whose handlers use the in-memory repository, it is seeded with the test user
 func SetupTest() *gin.Engine {
+	// The tokens of the tests are signed with this secret
+	viper.Set("jwt_secret", "secret")
+
+	if repo == nil {
+		repo = memory.NewRepository()
+		repo.CreateUser(context.Background(), &model.User{
+			UserUUID: uuid.MustParse("d90f9b49-dcd9-4feb-8250-d013098e45ee"),
+			Email:    "test@gmail.com",
+			Password: "$2a$04$YnrU9OJGE8ywjQ9yxIDZguyyRJucS4a5doOFk/3cde4OXxos6AkO.",
+		})
+	}
+	// The memory repository stores the users, the services and their versions, the other repositories are the database
+	repositories := model.NewSQLRepositories()
+	repositories.Users, repositories.Services, repositories.Versions = repo, repo, repo
+	UseRepositories(repositories)
+
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(middleware.HandleErrors())
+	return r
+}
+
+// SetupDBTest returns a router whose handlers use the database of the configuration, the tests run against a new
+// SQLite database when there is none. The test is skipped when the database is not available.
+func SetupDBTest(t *testing.T) *gin.Engine {
+	setupDBOnce.Do(func() {
+		setupDBErr = setupDB()
+	})
+	if setupDBErr != nil {
+		t.Skip("database is not available: ", setupDBErr)
+	}
+
+	UseRepositories(model.NewSQLRepositories())
+
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(middleware.HandleErrors())
+	return r
+}
+
+// setupDB connects to the database of the configuration or creates a SQLite database when there is none
+func setupDB() error {
 	viper.AddConfigPath("../..")
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatal("Error reading config file", zap.String("err", err.Error()))
+	if err := viper.ReadInConfig(); err == nil {
+		if err := viper.Unmarshal(&config); err != nil {
+			log.Fatal("unable to decode into struct", zap.String("err", err.Error()))
+		}
 	}
 
-	if err := viper.Unmarshal(&config); err != nil {
-		log.Fatal("unable to decode into struct", zap.String("err", err.Error()))
+	if config.DSN == "" {
+		return setupSQLite()
 	}
 
-	gin.SetMode(gin.ReleaseMode)
-	r := gin.New()
-	if err := db.InitConn(config.DSN); err != nil {
-		log.Fatal("Error connecting to db: ", zap.Error(err))
+	driver, source := db.ParseDSN(config.DSN)
+	conn, err := sqlx.Connect(driver, source)
+	if err != nil {
+		return err
 	}
-	return r
+	db.DB.Sqlx = conn
+
+	return nil
+}
+
+// setupSQLite creates a SQLite database in a temporary directory, applies the migrations to it
+func setupSQLite() error {
+	var err error
+	sqliteDir, err = os.MkdirTemp("", "service-catalog")
+	if err != nil {
+		return err
+	}
+
+	if err := db.InitConn("sqlite://" + filepath.Join(sqliteDir, "catalog.db")); err != nil {
+		return err
+	}
+
+	// The migrations seed the test user
+	if _, err := db.DB.MigrateUp(context.Background()); err != nil {
+		return err
+	}
+
+	// The tokens of the tests are signed with this secret
+	viper.Set("jwt_secret", "secret")
+
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+
+	if sqliteDir != "" {
+		os.RemoveAll(sqliteDir)
+	}
+	os.Exit(code)
 }
 
 func TestSignUp(t *testing.T) {
@@ -91,6 +185,17 @@ func TestSignUp(t *testing.T) {
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"code":"email_exists"`)
+
+	// Case fail: Invalid fields are reported
+	jsonValue, _ = json.Marshal(AuthInput{Email: "jd", Password: "johndoe123"})
+	req, _ = http.NewRequest(http.MethodPost, route, bytes.NewBuffer(jsonValue))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"email","rule":"email"`)
 }
 
 func TestLogin(t *testing.T) {
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/ZiyanK/service-catalog-api/app/catalog"
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// HandlerImportBackstage creates services from the Component, API and System entities of a Backstage catalog-info.yaml
+func HandlerImportBackstage(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	dryRun := false
+	if dryRunStr := c.Query("dry_run"); dryRunStr != "" {
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			c.Error(problem.InvalidParameter("dry_run", err))
+			return
+		}
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCatalogSize)
+	entities, err := catalog.DecodeBackstage(c.Request.Body)
+	if err != nil {
+		c.Error(problem.New(http.StatusBadRequest, "invalid_document", "Invalid document: "+err.Error()))
+		return
+	}
+
+	document, skipped := catalog.FromEntities(entities)
+
+	importCatalog(c, userUUID, document, skipped, dryRun)
+}
+
+// HandlerExportBackstage writes all the services of the user as Backstage entities in a single catalog-info.yaml
+func HandlerExportBackstage(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := repos.Users.GetUserByID(c.Request.Context(), userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	services, err := repos.Catalog.ExportCatalog(c.Request.Context(), userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Services which were not imported from Backstage are owned by the user
+	owner := "user:" + catalog.EntityName(user.Email)
+
+	entities := make([]catalog.Entity, 0, len(services))
+	for _, service := range services {
+		exported := catalog.Service{
+			Name:        service.Name,
+			Description: service.Description,
+		}
+
+		if service.Entity != nil {
+			exported.Entity, err = fromServiceEntity(*service.Entity)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+		}
+
+		annotations := map[string]string{
+			catalog.AnnotationServiceID: strconv.Itoa(service.ServiceID),
+		}
+		if latest, prefix, found := latestVersion(service.Versions); found {
+			annotations[catalog.AnnotationLatestVersion] = prefix + latest.String()
+		} else if len(service.Versions) > 0 {
+			annotations[catalog.AnnotationLatestVersion] = service.Versions[len(service.Versions)-1].Version
+		}
+
+		entities = append(entities, catalog.ToEntity(exported, owner, annotations))
+	}
+
+	var buf bytes.Buffer
+	err = catalog.EncodeBackstage(&buf, entities)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="catalog-info.yaml"`)
+	c.Data(http.StatusOK, catalog.MediaType(catalog.FormatYAML), buf.Bytes())
+}
+
+// toServiceEntity converts a Backstage entity into the row stored for a service
+func toServiceEntity(entity catalog.Entity) (*model.ServiceEntity, error) {
+	metadata, err := json.Marshal(entity.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := json.Marshal(entity.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ServiceEntity{
+		APIVersion: entity.APIVersion,
+		Kind:       entity.Kind,
+		Metadata:   string(metadata),
+		Spec:       string(spec),
+	}, nil
+}
+
+// fromServiceEntity converts the row stored for a service back into a Backstage entity
+func fromServiceEntity(entity model.ServiceEntity) (*catalog.Entity, error) {
+	result := catalog.Entity{
+		APIVersion: entity.APIVersion,
+		Kind:       entity.Kind,
+	}
+
+	err := json.Unmarshal([]byte(entity.Metadata), &result.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(entity.Spec), &result.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
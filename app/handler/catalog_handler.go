@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	"github.com/ZiyanK/service-catalog-api/app/catalog"
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxCatalogSize is the maximum size of an imported catalog document
+const maxCatalogSize = 10 << 20
+
+// ImportRow is a struct used to report what was imported for a row of the document
+type ImportRow struct {
+	Row string `json:"row"`
+	model.ImportedService
+}
+
+// ImportReport is a struct used to report the result of a catalog import
+type ImportReport struct {
+	DryRun          bool        `json:"dry_run"`
+	ServicesCreated int         `json:"services_created"`
+	VersionsCreated int         `json:"versions_created"`
+	VersionsSkipped int         `json:"versions_skipped"`
+	Rows            []ImportRow `json:"rows"`
+
+	// Skipped are the documents which are not imported, e.g. Backstage entities of an unsupported kind
+	Skipped []catalog.RowError `json:"skipped,omitempty"`
+}
+
+// HandlerImportCatalog creates services with their versions from a JSON, YAML or CSV document in a single transaction
+func HandlerImportCatalog(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	dryRun := false
+	if dryRunStr := c.Query("dry_run"); dryRunStr != "" {
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			c.Error(problem.InvalidParameter("dry_run", err))
+			return
+		}
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format, err = catalog.FormatFromMediaType(c.GetHeader("Content-Type"))
+		if err != nil {
+			c.Error(problem.New(http.StatusUnsupportedMediaType, "unsupported_media_type", "Unsupported content type. Use application/json, application/yaml or text/csv."))
+			return
+		}
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCatalogSize)
+	document, err := catalog.Decode(format, c.Request.Body)
+	if err != nil {
+		if err == catalog.ErrUnsupportedFormat {
+			c.Error(problem.InvalidParameter("format", err))
+			return
+		}
+		log.Info("Error while decoding catalog", zap.Error(err))
+		c.Error(problem.New(http.StatusBadRequest, "invalid_document", "Invalid document: "+err.Error()))
+		return
+	}
+
+	importCatalog(c, userUUID, document, nil, dryRun)
+}
+
+// HandlerExportCatalog writes all the services of the user with their versions as a JSON, YAML or CSV document
+func HandlerExportCatalog(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Get the format query parameter from the URL, the Accept header is used otherwise
+	format := c.Query("format")
+	if format == "" {
+		switch c.NegotiateFormat(gin.MIMEJSON, "application/yaml", gin.MIMEYAML, "text/csv") {
+		case "application/yaml", gin.MIMEYAML:
+			format = catalog.FormatYAML
+		case "text/csv":
+			format = catalog.FormatCSV
+		default:
+			format = catalog.FormatJSON
+		}
+	}
+
+	services, err := repos.Catalog.ExportCatalog(c.Request.Context(), userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	document := catalog.Document{Services: make([]catalog.Service, 0, len(services))}
+	for _, service := range services {
+		exported := catalog.Service{
+			Name:        service.Name,
+			Description: service.Description,
+		}
+
+		for _, sv := range service.Versions {
+			version := catalog.Version{
+				Version:   sv.Version,
+				Changelog: sv.Changelog,
+			}
+			for _, change := range sv.Changes {
+				version.Changes = append(version.Changes, catalog.Change{
+					Category:    change.Category,
+					Description: change.Description,
+				})
+			}
+			exported.Versions = append(exported.Versions, version)
+		}
+
+		document.Services = append(document.Services, exported)
+	}
+
+	var buf bytes.Buffer
+	err = catalog.Encode(format, &buf, document)
+	if err != nil {
+		if err == catalog.ErrUnsupportedFormat {
+			c.Error(problem.InvalidParameter("format", err))
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="catalog.`+format+`"`)
+	c.Data(http.StatusOK, catalog.MediaType(format), buf.Bytes())
+}
+
+// invalidCatalog reports every invalid row of the document, the row is prefixed to the detail of the field
+func invalidCatalog(rowErrors []catalog.RowError) *problem.Error {
+	invalid := problem.New(http.StatusBadRequest, "invalid_catalog", "Invalid catalog.")
+	for _, rowErr := range rowErrors {
+		invalid.Fields = append(invalid.Fields, problem.FieldError{
+			Field:  rowErr.Field,
+			Detail: rowErr.Row + ": " + rowErr.Msg,
+		})
+	}
+	return invalid
+}
+
+// importCatalog validates the whole document up front and then imports it, every invalid row is reported.
+// The skipped documents are only added to the report.
+func importCatalog(c *gin.Context, userUUID uuid.UUID, document catalog.Document, skipped []catalog.RowError, dryRun bool) {
+	rowErrors := catalog.Validate(document)
+	if len(rowErrors) > 0 {
+		c.Error(invalidCatalog(rowErrors))
+		return
+	}
+
+	if len(document.Services) == 0 {
+		c.Error(problem.New(http.StatusBadRequest, "invalid_catalog", "No services found."))
+		return
+	}
+
+	services := make([]model.CatalogService, 0, len(document.Services))
+	for _, service := range document.Services {
+		imported := model.CatalogService{
+			Service: model.Service{
+				Name:        service.Name,
+				Description: service.Description,
+			},
+		}
+
+		for _, version := range service.Versions {
+			changes := make([]model.ServiceVersionChange, 0, len(version.Changes))
+			for _, change := range version.Changes {
+				changes = append(changes, model.ServiceVersionChange{
+					Category:    change.Category,
+					Description: change.Description,
+				})
+			}
+
+			changelogText := version.Changelog
+			if changelogText == "" {
+				changelogText = renderChangelogText(changes)
+			}
+
+			imported.Versions = append(imported.Versions, model.ServiceVersion{
+				Version:   version.Version,
+				Changelog: changelogText,
+				Changes:   changes,
+			})
+		}
+
+		if service.Entity != nil {
+			entity, err := toServiceEntity(*service.Entity)
+			if err != nil {
+				log.Info("Error while encoding entity", zap.Error(err))
+				c.Error(invalidCatalog([]catalog.RowError{{Row: service.Row, Msg: err.Error()}}))
+				return
+			}
+			imported.Entity = entity
+		}
+
+		services = append(services, imported)
+	}
+
+	imported, err := repos.Catalog.ImportCatalog(c.Request.Context(), userUUID, services, dryRun)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	report := ImportReport{
+		DryRun:  dryRun,
+		Rows:    make([]ImportRow, 0, len(imported)),
+		Skipped: skipped,
+	}
+	for i, service := range imported {
+		if service.Created {
+			report.ServicesCreated++
+		}
+		report.VersionsCreated += len(service.VersionsCreated)
+		report.VersionsSkipped += len(service.VersionsSkipped)
+		report.Rows = append(report.Rows, ImportRow{
+			Row:             document.Services[i].Row,
+			ImportedService: service,
+		})
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"msg":  "Catalog validated successfully.",
+			"data": report,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"msg":  "Catalog imported successfully.",
+		"data": report,
+	})
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerImportCatalog(t *testing.T) {
+	router := SetupDBTest(t)
+
+	route := "/import"
+	router.Use(middleware.VerifyAuthToken)
+	router.POST(route, HandlerImportCatalog)
+
+	// Case fail: Unsupported content type
+	req, _ := http.NewRequest(http.MethodPost, route, bytes.NewBufferString("<services/>"))
+	req.Header.Set("Content-Type", "application/xml")
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	// Case fail: Invalid rows are reported
+	csv := "service_name,service_description,version,changelog\nbe,too short,v1.0.0,short\n"
+	req, _ = http.NewRequest(http.MethodPost, route+"?dry_run=true", bytes.NewBufferString(csv))
+	req.Header.Set("Content-Type", "text/csv")
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"invalid_catalog"`)
+	assert.Contains(t, w.Body.String(), `"detail":"row 2: `)
+
+	// Case fail: A version without a changelog needs at least one change
+	body := `{"services": [{"name": "catalog-importer", "description": "this service was imported from json",
+		"versions": [{"version": "v1.0.0", "changes": []}]}]}`
+	req, _ = http.NewRequest(http.MethodPost, route+"?dry_run=true", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"invalid_catalog"`)
+
+	// Case: Dry run does not persist the services
+	csv = "service_name,service_description,version,changelog\ncatalog-importer,this service was imported from csv,v1.0.0,first stable release\n"
+	req, _ = http.NewRequest(http.MethodPost, route+"?dry_run=true", bytes.NewBufferString(csv))
+	req.Header.Set("Content-Type", "text/csv")
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"dry_run":true`)
+}
+
+func TestHandlerExportCatalog(t *testing.T) {
+	router := SetupDBTest(t)
+
+	route := "/export"
+	router.Use(middleware.VerifyAuthToken)
+	router.GET(route, HandlerExportCatalog)
+
+	req, _ := http.NewRequest(http.MethodGet, route+"?format=csv", nil)
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+
+	// Case fail: Unsupported format
+	req, _ = http.NewRequest(http.MethodGet, route+"?format=xml", nil)
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandlerImportBackstage(t *testing.T) {
+	router := SetupDBTest(t)
+
+	route := "/import/backstage"
+	router.Use(middleware.VerifyAuthToken)
+	router.POST(route, HandlerImportBackstage)
+
+	// Case fail: Invalid YAML
+	req, _ := http.NewRequest(http.MethodPost, route, bytes.NewBufferString("kind: [Component"))
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case: Dry run reports the entities of other kinds as skipped
+	catalogInfo := `apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: backstage-importer
+  description: this service was imported from backstage
+spec:
+  type: service
+  lifecycle: production
+  owner: group:platform
+---
+apiVersion: backstage.io/v1alpha1
+kind: Group
+metadata:
+  name: platform
+`
+	req, _ = http.NewRequest(http.MethodPost, route+"?dry_run=true", bytes.NewBufferString(catalogInfo))
+	req.Header.Set("Content-Type", "application/yaml")
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"row":"document[1]"`)
+}
+
+func TestHandlerExportBackstage(t *testing.T) {
+	router := SetupDBTest(t)
+
+	route := "/export/backstage"
+	router.Use(middleware.VerifyAuthToken)
+	router.POST("/import/backstage", HandlerImportBackstage)
+	router.GET(route, HandlerExportBackstage)
+
+	// The services with the same name are reused, so the component can be imported again
+	catalogInfo := `apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: backstage-exporter
+  description: this service is exported to backstage
+spec:
+  type: service
+  lifecycle: production
+  owner: group:platform
+`
+	req, _ := http.NewRequest(http.MethodPost, "/import/backstage", bytes.NewBufferString(catalogInfo))
+	req.Header.Set("Content-Type", "application/yaml")
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	req, _ = http.NewRequest(http.MethodGet, route, nil)
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "apiVersion: backstage.io/v1alpha1")
+}
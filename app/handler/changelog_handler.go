@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ZiyanK/service-catalog-api/app/changelog"
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/ZiyanK/service-catalog-api/app/semver"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxChangelogSize is the maximum size of an imported CHANGELOG.md
+const maxChangelogSize = 1 << 20
+
+// HandlerImportChangelog creates the versions of a given service from a CHANGELOG.md in the Keep a Changelog format
+func HandlerImportChangelog(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	serviceIDstring := c.Param("id")
+	serviceID, err := strconv.Atoi(serviceIDstring)
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxChangelogSize)
+	raw, err := c.GetRawData()
+	if err != nil {
+		c.Error(problem.InvalidBody(err))
+		return
+	}
+
+	releases, err := changelog.Parse(bytes.NewReader(raw))
+	if err != nil {
+		log.Info("Error while parsing changelog", zap.Error(err))
+		c.Error(problem.New(http.StatusBadRequest, "invalid_changelog", "Invalid changelog: "+err.Error()))
+		return
+	}
+
+	if len(releases) == 0 {
+		c.Error(problem.New(http.StatusBadRequest, "invalid_changelog", "No releases found."))
+		return
+	}
+
+	// Releases are listed newest first, versions are created oldest first
+	versions := make([]model.ServiceVersion, 0, len(releases))
+	for i := len(releases) - 1; i >= 0; i-- {
+		release := releases[i]
+
+		if !semver.IsValid(release.Version) || len(release.Version) > 64 {
+			c.Error(problem.New(http.StatusBadRequest, "invalid_version", "Invalid version "+strconv.Quote(release.Version)+". Expected a semantic version."))
+			return
+		}
+
+		changes := toServiceVersionChanges(release.Changes)
+
+		sv := model.ServiceVersion{
+			Version:   release.Version,
+			Changelog: release.Notes,
+			Changes:   changes,
+		}
+		if sv.Changelog == "" {
+			sv.Changelog = renderChangelogText(changes)
+		}
+		if release.Date != nil {
+			sv.CreatedAt = *release.Date
+		}
+
+		versions = append(versions, sv)
+	}
+
+	created, skipped, err := repos.Versions.ImportServiceVersions(c.Request.Context(), userUUID, serviceID, versions)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"msg": "Changelog imported successfully.",
+		"data": gin.H{
+			"created": created,
+			"skipped": skipped,
+		},
+	})
+}
+
+// HandlerGetChangelog renders the changelog of a given service as Markdown, optionally limited to a version range
+func HandlerGetChangelog(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	serviceIDstring := c.Param("id")
+	serviceID, err := strconv.Atoi(serviceIDstring)
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	// Get the from and to query parameters from the URL, both bounds are inclusive
+	var from, to *semver.Version
+	if fromStr := c.Query("from"); fromStr != "" {
+		v, err := semver.Parse(fromStr)
+		if err != nil {
+			c.Error(problem.InvalidParameter("from", err))
+			return
+		}
+		from = &v
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		v, err := semver.Parse(toStr)
+		if err != nil {
+			c.Error(problem.InvalidParameter("to", err))
+			return
+		}
+		to = &v
+	}
+
+	exists, err := repos.Services.ServiceExists(c.Request.Context(), serviceID, userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !exists {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	versions, err := repos.Versions.GetServiceVersions(c.Request.Context(), serviceID, userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	releases := releasesInRange(versions, from, to)
+	if len(releases) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := changelog.Render(&buf, "Changelog", releases); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", buf.Bytes())
+}
+
+// releasesInRange converts the service versions into releases sorted newest first.
+// Versions which are not semantic versions are only included when no range is given.
+func releasesInRange(versions []model.ServiceVersion, from, to *semver.Version) []changelog.Release {
+	type parsedVersion struct {
+		sv     model.ServiceVersion
+		parsed semver.Version
+		valid  bool
+	}
+
+	var parsed []parsedVersion
+	for _, sv := range versions {
+		v, err := semver.Parse(sv.Version)
+		if err != nil && (from != nil || to != nil) {
+			continue
+		}
+		if err == nil && ((from != nil && v.LessThan(*from)) || (to != nil && to.LessThan(v))) {
+			continue
+		}
+		parsed = append(parsed, parsedVersion{sv: sv, parsed: v, valid: err == nil})
+	}
+
+	// versions are fetched newest first, the sort is stable to keep that order for non semantic versions
+	sort.SliceStable(parsed, func(i, j int) bool {
+		if parsed[i].valid && parsed[j].valid {
+			return parsed[j].parsed.LessThan(parsed[i].parsed)
+		}
+		return parsed[i].valid && !parsed[j].valid
+	})
+
+	releases := make([]changelog.Release, 0, len(parsed))
+	for _, p := range parsed {
+		date := p.sv.CreatedAt
+		release := changelog.Release{
+			Version: p.sv.Version,
+			Date:    &date,
+		}
+
+		for _, change := range p.sv.Changes {
+			release.Changes = append(release.Changes, changelog.Change{
+				Category:    change.Category,
+				Description: change.Description,
+			})
+		}
+		if len(release.Changes) == 0 {
+			release.Notes = p.sv.Changelog
+		}
+
+		releases = append(releases, release)
+	}
+
+	return releases
+}
+
+// renderChangelogText renders the structured changes as the Markdown stored in the changelog column
+func renderChangelogText(changes []model.ServiceVersionChange) string {
+	var b strings.Builder
+
+	entries := make([]changelog.Change, 0, len(changes))
+	for _, change := range changes {
+		entries = append(entries, changelog.Change{
+			Category:    change.Category,
+			Description: change.Description,
+		})
+	}
+	changelog.RenderChanges(&b, entries)
+
+	return strings.TrimSpace(b.String())
+}
+
+// toServiceVersionChanges converts changelog entries into the changes stored for a service version
+func toServiceVersionChanges(entries []changelog.Change) []model.ServiceVersionChange {
+	changes := make([]model.ServiceVersionChange, 0, len(entries))
+	for _, entry := range entries {
+		changes = append(changes, model.ServiceVersionChange{
+			Category:    entry.Category,
+			Description: entry.Description,
+		})
+	}
+
+	return changes
+}
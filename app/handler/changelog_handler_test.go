@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerImportChangelog(t *testing.T) {
+	router := SetupTest()
+
+	route := "/service/:id/changelog"
+	router.Use(middleware.VerifyAuthToken)
+	router.POST(route, HandlerImportChangelog)
+
+	// Case fail: Unknown category
+	req, _ := http.NewRequest(http.MethodPost, "/service/1000/changelog", bytes.NewBufferString("## [1.0.0]\n### Improved\n- Something\n"))
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case fail: Service does not exist
+	req, _ = http.NewRequest(http.MethodPost, "/service/1000/changelog", bytes.NewBufferString("## [1.0.0] - 2024-01-20\n### Added\n- First release\n"))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandlerGetChangelog(t *testing.T) {
+	router := SetupTest()
+
+	route := "/service/:id/changelog"
+	router.Use(middleware.VerifyAuthToken)
+	router.GET(route, HandlerGetChangelog)
+
+	// Case fail: Invalid range
+	req, _ := http.NewRequest(http.MethodGet, "/service/1000/changelog?from=latest", nil)
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case fail: Service does not exist
+	req, _ = http.NewRequest(http.MethodGet, "/service/1000/changelog", nil)
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestReleasesInRange(t *testing.T) {
+	versions := []model.ServiceVersion{
+		{Version: "v1.1.0", CreatedAt: time.Now()},
+		{Version: "nightly", Changelog: "nightly build"},
+		{Version: "v2.0.0", Changes: []model.ServiceVersionChange{{Category: "Removed", Description: "Legacy routes"}}},
+		{Version: "v1.0.0"},
+	}
+
+	releases := releasesInRange(versions, nil, nil)
+	assert.Len(t, releases, 4)
+	assert.Equal(t, "v2.0.0", releases[0].Version)
+	assert.Equal(t, "Removed", releases[0].Changes[0].Category)
+	assert.Equal(t, "v1.1.0", releases[1].Version)
+	assert.Equal(t, "v1.0.0", releases[2].Version)
+	assert.Equal(t, "nightly build", releases[3].Notes)
+
+	from, to := semver.MustParse("1.0.0"), semver.MustParse("1.1.0")
+	releases = releasesInRange(versions, &from, &to)
+	assert.Len(t, releases, 2)
+	assert.Equal(t, "v1.1.0", releases[0].Version)
+	assert.Equal(t, "v1.0.0", releases[1].Version)
+}
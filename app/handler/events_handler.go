@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/outbox"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/ZiyanK/service-catalog-api/app/stream"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// heartbeatInterval is the time between two heartbeats, it keeps idle connections open through proxies
+	heartbeatInterval = 15 * time.Second
+
+	// replayBatchSize is the number of events read at once from the event log when resuming a stream
+	replayBatchSize = 500
+
+	// reconnectDelay is the delay in milliseconds after which clients reconnect to a closed stream
+	reconnectDelay = 3000
+)
+
+// HandlerEventStream streams the changes to the services of the user as Server-Sent Events.
+// The id of each event is it's position in the event stream, assigned in the order the events are published, a client
+// resumes the stream after an event using the Last-Event-ID header or the last_event_id query parameter.
+func HandlerEventStream(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	lastEventIDStr := c.GetHeader("Last-Event-ID")
+	if lastEventIDStr == "" {
+		lastEventIDStr = c.Query("last_event_id")
+	}
+
+	var lastEventID int64 = -1
+	if lastEventIDStr != "" {
+		lastEventID, err = strconv.ParseInt(lastEventIDStr, 10, 64)
+		if err != nil || lastEventID < 0 {
+			c.Error(problem.InvalidParameter("Last-Event-ID", err))
+			return
+		}
+	}
+
+	// Subscribing before reading the event log so that no event is missed in between
+	hub := stream.GetHub()
+	subscription := hub.Subscribe(userUUID)
+	defer hub.Unsubscribe(subscription)
+
+	// The stream is not bound by the write timeout of the server, it ends when the client disconnects or the server shuts down
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", reconnectDelay)
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+
+	// The events of a service are published in order, but the events of the relays of several instances reach the hub
+	// out of order. The last position sent is kept for each service to skip the events received again from the hub once
+	// replayed.
+	lastSent := make(map[int]int64)
+	for lastEventID >= 0 {
+		events, err := repos.Events.GetPublishedEvents(ctx, userUUID, lastEventID, replayBatchSize)
+		if err != nil {
+			return
+		}
+
+		for _, event := range events {
+			if err := writeEvent(c, outbox.FromOutboxEvent(event)); err != nil {
+				return
+			}
+			lastSent[event.ServiceID] = event.PublishedSeq
+			lastEventID = event.PublishedSeq
+		}
+
+		if len(events) < replayBatchSize {
+			break
+		}
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-subscription.Events():
+			// The subscription was dropped as the client did not keep up, it reconnects with the last event id
+			if !ok {
+				return
+			}
+
+			// Already sent
+			if event.PublishedSeq <= lastSent[event.ServiceID] {
+				continue
+			}
+
+			if err := writeEvent(c, event); err != nil {
+				return
+			}
+			lastSent[event.ServiceID] = event.PublishedSeq
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeEvent writes an event in the Server-Sent Events format and flushes it to the client
+func writeEvent(c *gin.Context, event outbox.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.PublishedSeq, event.Event, data)
+	if err != nil {
+		return err
+	}
+
+	c.Writer.Flush()
+	return nil
+}
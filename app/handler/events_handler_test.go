@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/outbox"
+	"github.com/ZiyanK/service-catalog-api/app/stream"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerEventStream(t *testing.T) {
+	router := SetupDBTest(t)
+
+	route := "/events/stream"
+	router.Use(middleware.VerifyAuthToken)
+	router.GET(route, HandlerEventStream)
+
+	// Case fail: Invalid Last-Event-ID header
+	req, _ := http.NewRequest(http.MethodGet, route, nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case fail: Negative last_event_id query parameter
+	req, _ = http.NewRequest(http.MethodGet, route+"?last_event_id=-1", nil)
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case fail: Missing token
+	req, _ = http.NewRequest(http.MethodGet, route, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestHandlerEventStreamSkipsSentEvents(t *testing.T) {
+	router := SetupDBTest(t)
+
+	route := "/events/stream"
+	router.Use(middleware.VerifyAuthToken)
+	router.GET(route, HandlerEventStream)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+route, nil)
+	AddAuthorizationHeader(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal("Failed to open the stream:", err)
+	}
+	defer resp.Body.Close()
+
+	hub := stream.GetHub()
+	assert.Eventually(t, func() bool { return hub.Subscribers() > 0 }, time.Second, 10*time.Millisecond)
+
+	// Case: An event received again is skipped, an earlier event of another service is not
+	userUUID := uuid.MustParse("d90f9b49-dcd9-4feb-8250-d013098e45ee")
+	for _, event := range []outbox.Event{
+		{PublishedSeq: 5, ServiceID: 1},
+		{PublishedSeq: 7, ServiceID: 2},
+		{PublishedSeq: 5, ServiceID: 1},
+		{PublishedSeq: 6, ServiceID: 1},
+	} {
+		event.UserUUID = userUUID
+		event.Event = "service.updated"
+		event.Data = json.RawMessage(`{}`)
+		hub.Publish(req.Context(), event)
+	}
+
+	var ids []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			ids = append(ids, id)
+			if id == "6" {
+				break
+			}
+		}
+	}
+	assert.Equal(t, []string{"5", "7", "6"}, ids)
+}
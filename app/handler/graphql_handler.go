@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ZiyanK/service-catalog-api/app/graph"
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// HandlerGraphQL executes a GraphQL query over the services of the user.
+// Queries which fail to parse, to validate or exceed the depth and complexity limits are rejected with a 400.
+func HandlerGraphQL(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var body graph.Request
+
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		c.Error(problem.InvalidBody(err))
+		return
+	}
+
+	err = validate.Struct(body)
+	if err != nil {
+		c.Error(problem.Validation(err))
+		return
+	}
+
+	result, executed := graph.Execute(graph.NewContext(c.Request.Context(), userUUID, repos), graph.Schema, body, graph.DefaultLimits)
+	if !executed {
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ZiyanK/service-catalog-api/app/graph"
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerGraphQL(t *testing.T) {
+	router := SetupDBTest(t)
+
+	route := "/graphql"
+	router.Use(middleware.VerifyAuthToken)
+	router.POST(route, HandlerGraphQL)
+
+	// Case pass: Services with their versions
+	body := graph.Request{
+		Query: `{ me { email } services(limit: 5) { id name owner { email } versions { version changes { category } } } }`,
+	}
+	jsonValue, _ := json.Marshal(body)
+
+	req, _ := http.NewRequest(http.MethodPost, route, bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Case fail: Query exceeding the complexity limit
+	body.Query = `{ services(limit: 1000) { name versions { version changes { category } } } }`
+	jsonValue, _ = json.Marshal(body)
+
+	req, _ = http.NewRequest(http.MethodPost, route, bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case fail: Unknown field
+	body.Query = `{ services { password } }`
+	jsonValue, _ = json.Marshal(body)
+
+	req, _ = http.NewRequest(http.MethodPost, route, bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case fail: Missing query
+	req, _ = http.NewRequest(http.MethodPost, route, bytes.NewBufferString(`{}`))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ZiyanK/service-catalog-api/app/health"
+	"github.com/gin-gonic/gin"
+)
+
+// HandlerHealthz reports whether the server is alive with the result of each check, it responds with 503 when it is not
+func HandlerHealthz(c *gin.Context) {
+	respondHealth(c, health.GetChecker().Live(c.Request.Context()))
+}
+
+// HandlerReadyz reports whether the server is ready to receive traffic with the result of each check,
+// it responds with 503 when it is not, e.g. when the database is down or the server is shutting down
+func HandlerReadyz(c *gin.Context) {
+	respondHealth(c, health.GetChecker().Ready(c.Request.Context()))
+}
+
+func respondHealth(c *gin.Context, report health.Report) {
+	status := http.StatusOK
+	if report.Status != health.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, report)
+}
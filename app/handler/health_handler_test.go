@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ZiyanK/service-catalog-api/app/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerHealth(t *testing.T) {
+	router := SetupTest()
+
+	router.GET("/healthz", HandlerHealthz)
+	router.GET("/readyz", HandlerReadyz)
+
+	req, _ := http.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"up"`)
+
+	req, _ = http.NewRequest(http.MethodGet, "/readyz", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `{"name":"shutdown","status":"up"`)
+
+	// Case fail: A dependency is down, the server is not ready but it is alive
+	health.GetChecker().AddReadiness("database", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+
+	req, _ = http.NewRequest(http.MethodGet, "/readyz", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"down"`)
+	assert.Contains(t, w.Body.String(), `{"name":"database","status":"down","error":"connection refused"`)
+
+	req, _ = http.NewRequest(http.MethodGet, "/healthz", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ZiyanK/service-catalog-api/app/mergepatch"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// bindMergePatch applies the merge patch in the request body to the current value of a resource and decodes
+// the result into body, which should be empty. The patch is sent as application/merge-patch+json, application/json is accepted as well.
+// Unknown fields are rejected, the result should be validated by the caller.
+func bindMergePatch(c *gin.Context, current, body interface{}) error {
+	contentType := c.ContentType()
+	if contentType != mergepatch.MediaType && contentType != gin.MIMEJSON {
+		return problem.New(http.StatusUnsupportedMediaType, "unsupported_media_type", "Unsupported content type. Use "+mergepatch.MediaType+".")
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		return problem.InvalidBody(err)
+	}
+
+	document, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+
+	merged, err := mergepatch.Apply(document, patch)
+	if err != nil {
+		return problem.InvalidBody(err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(body); err != nil {
+		return problem.InvalidBody(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// entityTag returns the strong entity tag of a resource for it's row version
+func entityTag(rowVersion int64) string {
+	return `"` + strconv.FormatInt(rowVersion, 10) + `"`
+}
+
+// matchesEntityTag reports whether the entity tag is in the list of a If-Match or If-None-Match header.
+// Weak tags only match with the weak comparison used by If-None-Match.
+func matchesEntityTag(header, tag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified responds with 304 Not Modified when the If-None-Match header of a read matches the entity tag
+func notModified(c *gin.Context, tag string) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" || !matchesEntityTag(header, tag, true) {
+		return false
+	}
+
+	c.Header("ETag", tag)
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// ifMatchRowVersion returns the row version a write of the service is conditional on, it is 0 when the write is unconditional.
+// The If-Match header is required when `require_if_match` is set.
+func ifMatchRowVersion(c *gin.Context, current func() (*model.Service, error)) (int64, error) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		if viper.GetBool("require_if_match") {
+			return 0, problem.New(http.StatusPreconditionRequired, "precondition_required", "The If-Match header is required.")
+		}
+		return 0, nil
+	}
+
+	if strings.TrimSpace(header) == "*" {
+		return 0, nil
+	}
+
+	service, err := current()
+	if err != nil {
+		return 0, err
+	}
+
+	if !matchesEntityTag(header, entityTag(service.RowVersion), false) {
+		return 0, model.ErrServiceModified
+	}
+
+	return service.RowVersion, nil
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesEntityTag(t *testing.T) {
+	tests := []struct {
+		header string
+		weak   bool
+		match  bool
+	}{
+		{header: `"3"`, match: true},
+		{header: `"2"`, match: false},
+		{header: `"1", "3"`, match: true},
+		{header: `*`, match: true},
+		{header: `W/"3"`, match: false},
+		{header: `W/"3"`, weak: true, match: true},
+		{header: `"30"`, weak: true, match: false},
+		{header: `3`, match: false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.match, matchesEntityTag(test.header, entityTag(3), test.weak), test.header)
+	}
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ZiyanK/service-catalog-api/app/conventional"
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/ZiyanK/service-catalog-api/app/semver"
+	"github.com/gin-gonic/gin"
+)
+
+// ReleaseInput is a struct used to take the commit messages since the last version of a given service
+type ReleaseInput struct {
+	Commits []string `json:"commits" validate:"required,min=1,max=1000,dive,required"`
+	Create  bool     `json:"create"`
+}
+
+// ReleaseProposal is a struct used to return the next version proposed for the commits
+type ReleaseProposal struct {
+	CurrentVersion string                       `json:"current_version"`
+	NextVersion    string                       `json:"next_version"`
+	Bump           string                       `json:"bump"`
+	Changes        []model.ServiceVersionChange `json:"changes"`
+	Changelog      string                       `json:"changelog"`
+	Ignored        []string                     `json:"ignored"`
+	Created        bool                         `json:"created"`
+}
+
+// HandlerProposeRelease proposes the next version and changelog of a given service from Conventional Commits and optionally creates it
+func HandlerProposeRelease(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	serviceIDstring := c.Param("id")
+	serviceID, err := strconv.Atoi(serviceIDstring)
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	var body ReleaseInput
+
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		c.Error(problem.InvalidBody(err))
+		return
+	}
+
+	err = validate.Struct(body)
+	if err != nil {
+		c.Error(problem.Validation(err))
+		return
+	}
+
+	exists, err := repos.Services.ServiceExists(c.Request.Context(), serviceID, userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !exists {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	versions, err := repos.Versions.GetServiceVersions(c.Request.Context(), serviceID, userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	release := conventional.Analyze(body.Commits)
+	changes := toServiceVersionChanges(release.Changes)
+
+	proposal := ReleaseProposal{
+		Bump:      release.Bump.String(),
+		Changes:   changes,
+		Changelog: renderChangelogText(changes),
+		Ignored:   release.Ignored,
+	}
+
+	current, prefix, found := latestVersion(versions)
+	if found {
+		proposal.CurrentVersion = prefix + current.String()
+	}
+	if release.Bump != semver.BumpNone {
+		proposal.NextVersion = prefix + current.Increment(release.Bump).String()
+	}
+
+	if !body.Create {
+		c.JSON(http.StatusOK, gin.H{
+			"msg":  "Release proposed successfully.",
+			"data": proposal,
+		})
+		return
+	}
+
+	if release.Bump == semver.BumpNone {
+		c.Error(problem.New(http.StatusBadRequest, "no_releasable_commits", "No releasable commits."))
+		return
+	}
+
+	serviceVersion := model.ServiceVersion{
+		Version:   proposal.NextVersion,
+		Changelog: proposal.Changelog,
+		ServiceID: serviceID,
+		Changes:   changes,
+	}
+
+	err = repos.Versions.CreateServiceVersion(c.Request.Context(), userUUID, &serviceVersion)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	proposal.Created = true
+
+	c.JSON(http.StatusCreated, gin.H{
+		"msg":  "Release created successfully.",
+		"data": proposal,
+	})
+}
+
+// latestVersion returns the highest semantic version of the service along with it's `v` prefix.
+// If the service has no semantic version, 0.0.0 is returned with the `v` prefix used by the catalog.
+func latestVersion(versions []model.ServiceVersion) (semver.Version, string, bool) {
+	var (
+		latest semver.Version
+		prefix = "v"
+		found  bool
+	)
+
+	for _, sv := range versions {
+		v, err := semver.Parse(sv.Version)
+		if err != nil {
+			continue
+		}
+
+		if !found || latest.LessThan(v) {
+			latest = v
+			found = true
+			prefix = ""
+			if strings.HasPrefix(sv.Version, "v") {
+				prefix = "v"
+			}
+		}
+	}
+
+	return latest, prefix, found
+}
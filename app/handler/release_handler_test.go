@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerProposeRelease(t *testing.T) {
+	router := SetupTest()
+
+	route := "/service/:id/release"
+	router.Use(middleware.VerifyAuthToken)
+	router.POST(route, HandlerProposeRelease)
+
+	// Case fail: No commits
+	jsonValue, _ := json.Marshal(ReleaseInput{})
+	req, _ := http.NewRequest(http.MethodPost, "/service/1000/release", bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case fail: Service does not exist
+	jsonValue, _ = json.Marshal(ReleaseInput{Commits: []string{"feat: add artifact lookup"}})
+	req, _ = http.NewRequest(http.MethodPost, "/service/1000/release", bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestLatestVersion(t *testing.T) {
+	latest, prefix, found := latestVersion([]model.ServiceVersion{
+		{Version: "v1.2.0"},
+		{Version: "nightly"},
+		{Version: "v1.10.0"},
+		{Version: "v1.9.3"},
+	})
+	assert.True(t, found)
+	assert.Equal(t, "v", prefix)
+	assert.Equal(t, "1.10.0", latest.String())
+
+	latest, prefix, found = latestVersion(nil)
+	assert.False(t, found)
+	assert.Equal(t, "v", prefix)
+	assert.Equal(t, "0.0.0", latest.String())
+}
@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+)
+
+// repos are the repositories the handlers read and write through, they are the database unless set otherwise
+var repos = model.NewSQLRepositories()
+
+// UseRepositories sets the repositories of the handlers and of the authentication middleware
+func UseRepositories(repositories model.Repositories) {
+	repos = repositories
+	middleware.UseUserRepository(repositories.Users)
+}
@@ -1,15 +1,14 @@
 package handler
 
 import (
-	"context"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/ZiyanK/service-catalog-api/app/middleware"
 	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
-	"go.uber.org/zap"
 )
 
 // ServiceInput is a struct used to take the name and description of the service
@@ -22,8 +21,7 @@ type ServiceInput struct {
 func HandlerCreateService(c *gin.Context) {
 	userUUID, err := middleware.GetUserUUID(c)
 	if err != nil {
-		log.Error("Error getting user_uuid", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
@@ -31,20 +29,14 @@ func HandlerCreateService(c *gin.Context) {
 
 	err = c.ShouldBindJSON(&body)
 	if err != nil {
-		log.Error("Error while reading request body for signup", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"msg": "Invalid body.",
-		})
+		c.Error(problem.InvalidBody(err))
 		return
 	}
 
 	// Validate request body
-	err = validator.New().Struct(body)
+	err = validate.Struct(body)
 	if err != nil {
-		log.Info("validator error", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"msg": "Invalid body.",
-		})
+		c.Error(problem.Validation(err))
 		return
 	}
 
@@ -55,17 +47,9 @@ func HandlerCreateService(c *gin.Context) {
 	}
 
 	// Create new service for user
-	err = service.CreateService(context.TODO())
+	err = repos.Services.CreateService(c.Request.Context(), service)
 	if err != nil {
-		if err.Error() == "service exists" {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"msg": "Service with same name exists.",
-			})
-			return
-		}
-
-		log.Error("Error while creating service", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
@@ -76,8 +60,7 @@ func HandlerCreateService(c *gin.Context) {
 func HandlerGetServices(c *gin.Context) {
 	userUUID, err := middleware.GetUserUUID(c)
 	if err != nil {
-		log.Error("Error getting user_uuid", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
@@ -85,7 +68,7 @@ func HandlerGetServices(c *gin.Context) {
 	limitStr := c.Query("limit")
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid limit value"})
+		c.Error(problem.InvalidParameter("limit", err))
 		return
 	}
 
@@ -93,7 +76,7 @@ func HandlerGetServices(c *gin.Context) {
 	offsetStr := c.Query("offset")
 	offset, err := strconv.Atoi(offsetStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid offset value"})
+		c.Error(problem.InvalidParameter("offset", err))
 		return
 	}
 
@@ -101,10 +84,9 @@ func HandlerGetServices(c *gin.Context) {
 	name := c.Query("name")
 	orderBy := c.Query("orderBy")
 
-	services, err := model.GetServices(context.TODO(), userUUID, limit, offset, name, orderBy)
+	services, err := repos.Services.GetServices(c.Request.Context(), userUUID, limit, offset, name, orderBy)
 	if err != nil {
-		log.Error("Error while fetching services", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
@@ -121,35 +103,65 @@ func HandlerGetServices(c *gin.Context) {
 	})
 }
 
-// HandlerGetService fetches as service and all the versions available for the service
+// HandlerGetService fetches as service and all the versions available for the service.
+// The as_of query parameter (RFC 3339) fetches the service and it's versions as they were at that time.
 func HandlerGetService(c *gin.Context) {
 	userUUID, err := middleware.GetUserUUID(c)
 	if err != nil {
-		log.Error("Error getting user_uuid", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
 	serviceIDstring := c.Param("id")
 	serviceID, err := strconv.Atoi(serviceIDstring)
 	if err != nil {
-		log.Info("invalid service id")
-		c.Status(http.StatusNotFound)
+		c.Error(problem.NotFound())
+		return
+	}
+
+	// A past state of the service is fetched with the as_of query parameter
+	if asOfStr := c.Query("as_of"); asOfStr != "" {
+		asOf, err := time.Parse(time.RFC3339, asOfStr)
+		if err != nil {
+			c.Error(problem.InvalidParameter("as_of", err))
+			return
+		}
+
+		service, err := repos.Services.GetServiceAsOf(c.Request.Context(), serviceID, userUUID, asOf)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		if len(service) == 0 {
+			c.Error(problem.NotFound())
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data": service,
+			"msg":  "Service fetched successfully.",
+		})
 		return
 	}
 
-	service, err := model.GetService(context.TODO(), serviceID, userUUID)
+	service, err := repos.Services.GetService(c.Request.Context(), serviceID, userUUID)
 	if err != nil {
-		log.Error("Error fetching service", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
 	if len(service) == 0 {
-		c.Status(http.StatusNotFound)
+		c.Error(problem.NotFound())
 		return
 	}
 
+	tag := entityTag(service[0].RowVersion)
+	if notModified(c, tag) {
+		return
+	}
+
+	c.Header("ETag", tag)
 	c.JSON(http.StatusOK, gin.H{
 		"data": service,
 		"msg":  "Service fetched successfully.",
@@ -160,16 +172,14 @@ func HandlerGetService(c *gin.Context) {
 func HandlerUpdateService(c *gin.Context) {
 	userUUID, err := middleware.GetUserUUID(c)
 	if err != nil {
-		log.Error("Error getting user_uuid", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
 	serviceIDstring := c.Param("id")
 	serviceID, err := strconv.Atoi(serviceIDstring)
 	if err != nil {
-		log.Info("invalid service id")
-		c.Status(http.StatusNotFound)
+		c.Error(problem.NotFound())
 		return
 	}
 
@@ -177,19 +187,21 @@ func HandlerUpdateService(c *gin.Context) {
 
 	err = c.ShouldBindJSON(&body)
 	if err != nil {
-		log.Error("Error while reading request body for signup", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"msg": "Invalid body.",
-		})
+		c.Error(problem.InvalidBody(err))
 		return
 	}
 
-	err = validator.New().Struct(body)
+	err = validate.Struct(body)
 	if err != nil {
-		log.Info("validator error", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"msg": "Invalid body.",
-		})
+		c.Error(problem.Validation(err))
+		return
+	}
+
+	rowVersion, err := ifMatchRowVersion(c, func() (*model.Service, error) {
+		return repos.Services.GetServiceByID(c.Request.Context(), serviceID, userUUID)
+	})
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -198,50 +210,125 @@ func HandlerUpdateService(c *gin.Context) {
 		UserUUID:    userUUID,
 		Name:        body.Name,
 		Description: body.Description,
+		RowVersion:  rowVersion,
 	}
 
-	err = service.UpdateService(context.TODO())
+	err = repos.Services.UpdateService(c.Request.Context(), &service)
 	if err != nil {
-		if err.Error() == "service does not exist" {
-			c.Status(http.StatusNotFound)
-			return
-		}
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", entityTag(service.RowVersion))
 	c.Status(http.StatusOK)
 }
 
+// HandlerPatchService updates the name and/or the description of a service with a JSON merge patch.
+// The patched service is validated as a whole.
+func HandlerPatchService(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	serviceIDstring := c.Param("id")
+	serviceID, err := strconv.Atoi(serviceIDstring)
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	current, err := repos.Services.GetServiceByID(c.Request.Context(), serviceID, userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	rowVersion, err := ifMatchRowVersion(c, func() (*model.Service, error) {
+		return current, nil
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// The fields removed by the patch are left empty
+	var body ServiceInput
+	err = bindMergePatch(c, ServiceInput{
+		Name:        current.Name,
+		Description: current.Description,
+	}, &body)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = validate.Struct(body)
+	if err != nil {
+		c.Error(problem.Validation(err))
+		return
+	}
+
+	service := model.Service{
+		ServiceID:   serviceID,
+		UserUUID:    userUUID,
+		Name:        body.Name,
+		Description: body.Description,
+		RowVersion:  rowVersion,
+	}
+
+	err = repos.Services.UpdateService(c.Request.Context(), &service)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	updated, err := repos.Services.GetServiceByID(c.Request.Context(), serviceID, userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", entityTag(updated.RowVersion))
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "Service updated successfully.",
+		"data": updated,
+	})
+}
+
 // HandlerDeleteService deletes a service and all the versions associated to the service
 func HandlerDeleteService(c *gin.Context) {
 	userUUID, err := middleware.GetUserUUID(c)
 	if err != nil {
-		log.Error("Error getting user_uuid", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
 	serviceIDstring := c.Param("id")
 	serviceID, err := strconv.Atoi(serviceIDstring)
 	if err != nil {
-		log.Info("invalid service id")
-		c.Status(http.StatusNotFound)
+		c.Error(problem.NotFound())
+		return
+	}
+
+	rowVersion, err := ifMatchRowVersion(c, func() (*model.Service, error) {
+		return repos.Services.GetServiceByID(c.Request.Context(), serviceID, userUUID)
+	})
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	service := model.Service{
-		ServiceID: serviceID,
-		UserUUID:  userUUID,
+		ServiceID:  serviceID,
+		UserUUID:   userUUID,
+		RowVersion: rowVersion,
 	}
 
-	err = service.DeleteService(context.TODO())
+	err = repos.Services.DeleteService(c.Request.Context(), &service)
 	if err != nil {
-		if err.Error() == "service does not exist" {
-			c.Status(http.StatusNotFound)
-			return
-		}
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
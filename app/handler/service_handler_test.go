@@ -167,7 +167,7 @@ func TestHandlerUpdateService(t *testing.T) {
 	router.Use(middleware.VerifyAuthToken)
 	router.PUT(route, HandlerUpdateService)
 	body := ServiceInput{
-		Name:        "frontend",
+		Name:        "web",
 		Description: "this service has the frontend",
 	}
 	jsonValue, _ := json.Marshal(body)
@@ -178,6 +178,111 @@ func TestHandlerUpdateService(t *testing.T) {
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Case fail: Renamed to the name of another service
+	jsonValue, _ = json.Marshal(ServiceInput{Name: "frontend", Description: "this service has the frontend"})
+	req, _ = http.NewRequest(http.MethodPut, "/service/1", bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"service_exists"`)
+}
+
+func TestHandlerServiceConditionalRequests(t *testing.T) {
+	router := SetupTest()
+
+	route := "/service/:id"
+	router.Use(middleware.VerifyAuthToken)
+	router.GET(route, HandlerGetService)
+	router.PUT(route, HandlerUpdateService)
+
+	req, _ := http.NewRequest(http.MethodGet, "/service/1", nil)
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	// Case: The service did not change
+	req, _ = http.NewRequest(http.MethodGet, "/service/1", nil)
+	req.Header.Set("If-None-Match", etag)
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	// Case: The update applies to the fetched version and changes the ETag
+	jsonValue, _ := json.Marshal(ServiceInput{Name: "web", Description: "this service has the frontend"})
+	req, _ = http.NewRequest(http.MethodPut, "/service/1", bytes.NewBuffer(jsonValue))
+	req.Header.Set("If-Match", etag)
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	// Case fail: The fetched version is stale
+	req, _ = http.NewRequest(http.MethodPut, "/service/1", bytes.NewBuffer(jsonValue))
+	req.Header.Set("If-Match", etag)
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"service_modified"`)
+}
+
+func TestHandlerPatchService(t *testing.T) {
+	router := SetupTest()
+
+	route := "/service/:id"
+	router.Use(middleware.VerifyAuthToken)
+	router.PATCH(route, HandlerPatchService)
+
+	// Case: Only the description is changed
+	req, _ := http.NewRequest(http.MethodPatch, "/service/1", bytes.NewBufferString(`{"description":"this service has the patched frontend"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"web"`)
+	assert.Contains(t, w.Body.String(), `"description":"this service has the patched frontend"`)
+
+	// Case fail: The patched service is validated
+	req, _ = http.NewRequest(http.MethodPatch, "/service/1", bytes.NewBufferString(`{"name":null}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"name","rule":"required"`)
+
+	// Case fail: Unknown fields are rejected
+	req, _ = http.NewRequest(http.MethodPatch, "/service/1", bytes.NewBufferString(`{"owner":"platform"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case fail: Unsupported content type
+	req, _ = http.NewRequest(http.MethodPatch, "/service/1", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
 }
 
 func TestHandlerDeleteService(t *testing.T) {
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// RevertInput is a struct used to take the revision a service is reverted to
+type RevertInput struct {
+	Revision int64 `json:"revision" validate:"required,min=1"`
+}
+
+// HandlerGetServiceHistory fetches the revisions of a service with the changes made in each of them
+func HandlerGetServiceHistory(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	revisions, err := repos.Services.GetServiceHistory(c.Request.Context(), serviceID, userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "Service history fetched successfully.",
+		"data": revisions,
+	})
+}
+
+// HandlerRevertService restores the name, the description and the versions a service had in a previous revision
+func HandlerRevertService(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	var body RevertInput
+
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		c.Error(problem.InvalidBody(err))
+		return
+	}
+
+	err = validate.Struct(body)
+	if err != nil {
+		c.Error(problem.Validation(err))
+		return
+	}
+
+	rowVersion, err := ifMatchRowVersion(c, func() (*model.Service, error) {
+		return repos.Services.GetServiceByID(c.Request.Context(), serviceID, userUUID)
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	service := model.Service{
+		ServiceID:  serviceID,
+		UserUUID:   userUUID,
+		RowVersion: rowVersion,
+	}
+
+	err = repos.Services.RevertService(c.Request.Context(), &service, body.Revision)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	reverted, err := repos.Services.GetServiceByID(c.Request.Context(), serviceID, userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", entityTag(reverted.RowVersion))
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "Service reverted successfully.",
+		"data": reverted,
+	})
+}
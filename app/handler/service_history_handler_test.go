@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerServiceHistory(t *testing.T) {
+	router := SetupTest()
+
+	router.Use(middleware.VerifyAuthToken)
+	router.GET("/service/:id", HandlerGetService)
+	router.PUT("/service/:id", HandlerUpdateService)
+	router.GET("/service/:id/history", HandlerGetServiceHistory)
+	router.POST("/service/:id/revert", HandlerRevertService)
+
+	service := &model.Service{
+		Name:        "archive",
+		Description: "this service is archived",
+		UserUUID:    uuid.MustParse("d90f9b49-dcd9-4feb-8250-d013098e45ee"),
+	}
+	if !assert.NoError(t, repos.Services.CreateService(context.Background(), service)) {
+		return
+	}
+	route := fmt.Sprintf("/service/%d", service.ServiceID)
+
+	// The timestamps are truncated to the second
+	time.Sleep(time.Second)
+	before := time.Now().UTC().Format(time.RFC3339)
+
+	jsonValue, _ := json.Marshal(ServiceInput{Name: "history", Description: "this service has a history of changes"})
+	req, _ := http.NewRequest(http.MethodPut, route, bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Case: The update is the latest revision
+	req, _ = http.NewRequest(http.MethodGet, route+"/history", nil)
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var history struct {
+		Data []struct {
+			Revision int64  `json:"revision"`
+			Name     string `json:"name"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &history)
+	if !assert.GreaterOrEqual(t, len(history.Data), 2) {
+		return
+	}
+	assert.Equal(t, "history", history.Data[len(history.Data)-1].Name)
+
+	// Case: The service is fetched as it was before the update
+	req, _ = http.NewRequest(http.MethodGet, route+"?as_of="+before, nil)
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), `"name":"history"`)
+
+	// Case: The service is reverted to the revision before the update
+	previous := history.Data[len(history.Data)-2]
+	jsonValue, _ = json.Marshal(RevertInput{Revision: previous.Revision})
+	req, _ = http.NewRequest(http.MethodPost, route+"/revert", bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"`+previous.Name+`"`)
+
+	// Case fail: Invalid timestamp
+	req, _ = http.NewRequest(http.MethodGet, route+"?as_of=last-month", nil)
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case fail: The revision does not exist
+	jsonValue, _ = json.Marshal(RevertInput{Revision: 100000})
+	req, _ = http.NewRequest(http.MethodPost, route+"/revert", bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"service_revision_not_found"`)
+}
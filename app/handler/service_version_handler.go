@@ -1,37 +1,40 @@
 package handler
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 
 	"github.com/ZiyanK/service-catalog-api/app/middleware"
 	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
-	"go.uber.org/zap"
 )
 
 // ServiceVersionInput is a struct used to take the version and changelog of a new version for a given service
 type ServiceVersionInput struct {
-	Version   string `json:"version" validate:"required,min=2"`
-	Changelog string `json:"changelog"  validate:"required,min=10"`
+	Version   string        `json:"version" validate:"required,min=2,max=64"`
+	Changelog string        `json:"changelog" validate:"required_without=Changes,omitempty,min=10"`
+	Changes   []ChangeInput `json:"changes" validate:"omitempty,min=1,dive"`
+}
+
+// ChangeInput is a struct used to take a categorized changelog entry of a service version
+type ChangeInput struct {
+	Category    string `json:"category" validate:"required,oneof=Added Changed Deprecated Removed Fixed Security"`
+	Description string `json:"description" validate:"required"`
 }
 
 // HandlerCreateServiceVersion created a new version for a given service
 func HandlerCreateServiceVersion(c *gin.Context) {
 	userUUID, err := middleware.GetUserUUID(c)
 	if err != nil {
-		log.Error("Error getting user_uuid", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
 	serviceIDstring := c.Param("id")
 	serviceID, err := strconv.Atoi(serviceIDstring)
 	if err != nil {
-		log.Info("invalid service id")
-		c.Status(http.StatusNotFound)
+		c.Error(problem.NotFound())
 		return
 	}
 
@@ -39,43 +42,40 @@ func HandlerCreateServiceVersion(c *gin.Context) {
 
 	err = c.ShouldBindJSON(&body)
 	if err != nil {
-		log.Error("Error while reading request body for signup", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"msg": "Invalid body.",
-		})
+		c.Error(problem.InvalidBody(err))
 		return
 	}
 
-	err = validator.New().Struct(body)
+	err = validate.Struct(body)
 	if err != nil {
-		log.Info("validator error", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"msg": "Invalid body.",
-		})
+		c.Error(problem.Validation(err))
 		return
 	}
 
+	changes := make([]model.ServiceVersionChange, 0, len(body.Changes))
+	for _, change := range body.Changes {
+		changes = append(changes, model.ServiceVersionChange{
+			Category:    change.Category,
+			Description: change.Description,
+		})
+	}
+
+	// Structured changes are also rendered into the changelog text when it is not given
+	changelogText := body.Changelog
+	if changelogText == "" {
+		changelogText = renderChangelogText(changes)
+	}
+
 	serviceVersion := model.ServiceVersion{
 		Version:   body.Version,
-		Changelog: body.Changelog,
+		Changelog: changelogText,
 		ServiceID: serviceID,
+		Changes:   changes,
 	}
 
-	err = serviceVersion.CreateServiceVersion(context.TODO(), userUUID)
+	err = repos.Versions.CreateServiceVersion(c.Request.Context(), userUUID, &serviceVersion)
 	if err != nil {
-		switch err.Error() {
-		case "service does not exist":
-			c.Status(http.StatusNotFound)
-			return
-		case "service version exists":
-			c.JSON(http.StatusBadRequest, gin.H{
-				"msg": "Service with same version exists.",
-			})
-			return
-		}
-
-		log.Error("Error while creating service version", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
@@ -86,35 +86,27 @@ func HandlerCreateServiceVersion(c *gin.Context) {
 func HandlerDeleteServiceVersion(c *gin.Context) {
 	userUUID, err := middleware.GetUserUUID(c)
 	if err != nil {
-		log.Error("Error getting user_uuid", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
 	serviceIDstring := c.Param("id")
 	serviceID, err := strconv.Atoi(serviceIDstring)
 	if err != nil {
-		log.Info("invalid service id")
-		c.Status(http.StatusNotFound)
+		c.Error(problem.NotFound())
 		return
 	}
 
 	svIDstring := c.Param("vid")
 	svID, err := strconv.Atoi(svIDstring)
 	if err != nil {
-		log.Info("invalid service id")
-		c.Status(http.StatusNotFound)
+		c.Error(problem.NotFound())
 		return
 	}
 
-	err = model.DeleteServiceVersion(context.TODO(), userUUID, serviceID, svID)
+	err = repos.Versions.DeleteServiceVersion(c.Request.Context(), userUUID, serviceID, svID)
 	if err != nil {
-		if err.Error() == "service version does not exist" {
-			c.Status(http.StatusNotFound)
-			return
-		}
-		log.Error("Error while deleting service version", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
@@ -37,6 +37,15 @@ func TestHandlerCreateServiceVersion(t *testing.T) {
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case fail: An empty list of changes does not replace the changelog
+	req, _ = http.NewRequest(http.MethodPost, "/service/2/version", bytes.NewBufferString(`{"version":"v1.0.1","changes":[]}`))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"changes","rule":"min"`)
 }
 
 func TestHandlerDeleteServiceVersion(t *testing.T) {
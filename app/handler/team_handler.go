@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// TeamInput is a struct used to take the name of a team
+type TeamInput struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// TeamMemberInput is a struct used to take the email of the user invited to a team
+type TeamMemberInput struct {
+	Email string `json:"email" validate:"required,email,max=50"`
+}
+
+// HandlerCreateTeam creates a new team with the user as it's first member
+func HandlerCreateTeam(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var body TeamInput
+
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		c.Error(problem.InvalidBody(err))
+		return
+	}
+
+	err = validate.Struct(body)
+	if err != nil {
+		c.Error(problem.Validation(err))
+		return
+	}
+
+	team := &model.Team{
+		Name: body.Name,
+	}
+
+	err = repos.Teams.CreateTeam(c.Request.Context(), userUUID, team)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"msg":  "Team created successfully.",
+		"data": team,
+	})
+}
+
+// HandlerGetTeams fetches all the teams of the user, along with the teams it is invited to
+func HandlerGetTeams(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	teams, err := repos.Teams.GetTeams(c.Request.Context(), userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if len(teams) == 0 {
+		c.JSON(http.StatusNoContent, gin.H{
+			"msg": "No teams found.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "Teams fetched successfully.",
+		"data": teams,
+	})
+}
+
+// HandlerGetTeam fetches a team of the user along with it's members, invitations and services
+func HandlerGetTeam(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	teamID, err := strconv.Atoi(c.Param("tid"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	team, err := repos.Teams.GetTeam(c.Request.Context(), userUUID, teamID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "Team fetched successfully.",
+		"data": team,
+	})
+}
+
+// HandlerAddTeamMember invites the user with the given email to a team of the user, it joins the team once it accepts.
+// The response is the same whether or not the email is registered.
+func HandlerAddTeamMember(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	teamID, err := strconv.Atoi(c.Param("tid"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	var body TeamMemberInput
+
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		c.Error(problem.InvalidBody(err))
+		return
+	}
+
+	err = validate.Struct(body)
+	if err != nil {
+		c.Error(problem.Validation(err))
+		return
+	}
+
+	err = repos.Teams.AddTeamMember(c.Request.Context(), userUUID, teamID, body.Email)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+// HandlerRemoveTeamMember removes the user with the given email from a team of the user or cancels it's invitation,
+// an invited user can decline it's invitation by removing itself
+func HandlerRemoveTeamMember(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	teamID, err := strconv.Atoi(c.Param("tid"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	err = repos.Teams.RemoveTeamMember(c.Request.Context(), userUUID, teamID, c.Param("email"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// HandlerAcceptTeamInvitation adds the user to a team it is invited to
+func HandlerAcceptTeamInvitation(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	teamID, err := strconv.Atoi(c.Param("tid"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	err = repos.Teams.AcceptTeamInvitation(c.Request.Context(), userUUID, teamID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// HandlerAddTeamService adds a service of the user to a team of the user, the webhooks of the team get it's events
+func HandlerAddTeamService(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	teamID, err := strconv.Atoi(c.Param("tid"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	err = repos.Teams.AddTeamService(c.Request.Context(), userUUID, teamID, serviceID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// HandlerRemoveTeamService removes a service of the user from a team
+func HandlerRemoveTeamService(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	teamID, err := strconv.Atoi(c.Param("tid"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	err = repos.Teams.RemoveTeamService(c.Request.Context(), userUUID, teamID, serviceID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerCreateTeam(t *testing.T) {
+	router := SetupDBTest(t)
+
+	route := "/team"
+	router.Use(middleware.VerifyAuthToken)
+	router.POST(route, HandlerCreateTeam)
+	router.POST("/team/:tid/member", HandlerAddTeamMember)
+	router.POST("/team/:tid/accept", HandlerAcceptTeamInvitation)
+	router.PUT("/team/:tid/service/:id", HandlerAddTeamService)
+	body := TeamInput{
+		Name: "platform",
+	}
+	jsonValue, _ := json.Marshal(body)
+
+	req, _ := http.NewRequest(http.MethodPost, route, bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"team_id":`)
+
+	// Case fail: Missing name
+	req, _ = http.NewRequest(http.MethodPost, route, bytes.NewBufferString(`{}`))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case fail: The user is not a member of the team
+	req, _ = http.NewRequest(http.MethodPost, "/team/1000000/member", bytes.NewBufferString(`{"email":"test@example.com"}`))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Case: Inviting an email which is not registered is not told apart from a registered one
+	var created struct {
+		Data struct {
+			TeamID int `json:"team_id"`
+		} `json:"data"`
+	}
+	req, _ = http.NewRequest(http.MethodPost, route, bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	member := fmt.Sprintf("/team/%d/member", created.Data.TeamID)
+	req, _ = http.NewRequest(http.MethodPost, member, bytes.NewBufferString(`{"email":"unknown-user@example.com"}`))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// Case fail: The user is not invited to the team
+	req, _ = http.NewRequest(http.MethodPost, "/team/1000000/accept", nil)
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Case fail: The service does not belong to the user
+	req, _ = http.NewRequest(http.MethodPut, fmt.Sprintf("/team/%d/service/1000000", created.Data.TeamID), nil)
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
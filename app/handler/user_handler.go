@@ -1,29 +1,29 @@
 package handler
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/ZiyanK/service-catalog-api/app/middleware"
-	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
-	"go.uber.org/zap"
 )
 
+// UserInput is a struct used to take the email of the user
+type UserInput struct {
+	Email string `json:"email" validate:"required,email,max=50"`
+}
+
 // HandlerGetUser fetches the user details (email)
 func HandlerGetUser(c *gin.Context) {
 	userUUID, err := middleware.GetUserUUID(c)
 	if err != nil {
-		log.Error("Error getting user_uuid", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
-	user, err := model.GetUserByID(context.TODO(), userUUID)
+	user, err := repos.Users.GetUserByID(c.Request.Context(), userUUID)
 	if err != nil {
-		log.Error("Error fetching user info", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
@@ -37,48 +37,79 @@ func HandlerGetUser(c *gin.Context) {
 func HandlerUpdateUser(c *gin.Context) {
 	userUUID, err := middleware.GetUserUUID(c)
 	if err != nil {
-		log.Error("Error getting user_uuid", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
 		return
 	}
 
-	type updateUserReq struct {
-		Email string `json:"email" validate:"required,email,max=50"`
-	}
-
-	var body updateUserReq
+	var body UserInput
 	err = c.ShouldBindJSON(&body)
 	if err != nil {
-		log.Error("Error while reading request body for signup", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"msg": "Invalid body",
-		})
+		c.Error(problem.InvalidBody(err))
 		return
 	}
 
-	err = validator.New().Struct(body)
+	err = validate.Struct(body)
 	if err != nil {
-		log.Info("validator error", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"msg": "Invalid body",
-		})
+		c.Error(problem.Validation(err))
 		return
 	}
 
 	// check for existing email and update if not present
-	err = model.UpdateUser(context.TODO(), body.Email, userUUID)
+	err = repos.Users.UpdateUser(c.Request.Context(), body.Email, userUUID)
 	if err != nil {
-		if err.Error() == "mail exists" {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"msg": "User with this mail already exists.",
-			})
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// HandlerPatchUser updates the user email with a JSON merge patch
+func HandlerPatchUser(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	current, err := repos.Users.GetUserByID(c.Request.Context(), userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// The fields removed by the patch are left empty
+	var body UserInput
+	err = bindMergePatch(c, UserInput{
+		Email: current.Email,
+	}, &body)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = validate.Struct(body)
+	if err != nil {
+		c.Error(problem.Validation(err))
+		return
+	}
+
+	if body.Email != current.Email {
+		err = repos.Users.UpdateUser(c.Request.Context(), body.Email, userUUID)
+		if err != nil {
+			c.Error(err)
 			return
 		}
+	}
 
-		log.Error("Error while updating user email", zap.Error(err))
-		c.Status(http.StatusInternalServerError)
+	user, err := repos.Users.GetUserByID(c.Request.Context(), userUUID)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.Status(http.StatusOK)
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "User updated successfully",
+		"data": user,
+	})
 }
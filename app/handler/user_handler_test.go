@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -23,3 +24,29 @@ func TestHandlerGetUser(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 
 }
+
+func TestHandlerPatchUser(t *testing.T) {
+	router := SetupTest()
+
+	route := "/user"
+	router.Use(middleware.VerifyAuthToken)
+	router.PATCH(route, HandlerPatchUser)
+
+	// Case: An empty patch keeps the user as it is
+	req, _ := http.NewRequest(http.MethodPatch, route, bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Case fail: The email cannot be removed
+	req, _ = http.NewRequest(http.MethodPatch, route, bytes.NewBufferString(`{"email":null}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
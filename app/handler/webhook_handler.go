@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/ZiyanK/service-catalog-api/app/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// The urls of the internal network are refused, the address is checked again once resolved at delivery time
+	validate.RegisterValidation("public_url", func(fl validator.FieldLevel) bool {
+		return webhook.ValidateURL(fl.Field().String()) == nil
+	})
+}
+
+// WebhookInput is a struct used to take the url and the events of a webhook
+type WebhookInput struct {
+	URL    string   `json:"url" validate:"required,http_url,public_url,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=* service.created service.updated service.deleted version.created version.deleted artifact.created"`
+	Secret string   `json:"secret" validate:"omitempty,min=16,max=64"`
+	TeamID int      `json:"team_id" validate:"omitempty,min=1"`
+}
+
+// WebhookUpdateInput is a struct used to take the url, the events and the state of a webhook
+type WebhookUpdateInput struct {
+	URL    string   `json:"url" validate:"required,http_url,public_url,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=* service.created service.updated service.deleted version.created version.deleted artifact.created"`
+	Active *bool    `json:"active" validate:"required"`
+}
+
+// HandlerCreateWebhook creates a new webhook for the user or for a team of the user, the secret used to sign the payloads is only returned here
+func HandlerCreateWebhook(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var body WebhookInput
+
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		c.Error(problem.InvalidBody(err))
+		return
+	}
+
+	err = validate.Struct(body)
+	if err != nil {
+		c.Error(problem.Validation(err))
+		return
+	}
+
+	secret := body.Secret
+	if secret == "" {
+		secret, err = webhook.GenerateSecret()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	hook := &model.Webhook{
+		UserUUID: userUUID,
+		URL:      body.URL,
+		Secret:   secret,
+		Events:   body.Events,
+	}
+
+	if body.TeamID != 0 {
+		hook.TeamID = &body.TeamID
+	}
+
+	err = repos.Webhooks.CreateWebhook(c.Request.Context(), hook)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"msg":  "Webhook created successfully.",
+		"data": hook,
+	})
+}
+
+// HandlerGetWebhooks fetches all the webhooks of the user and of it's teams
+func HandlerGetWebhooks(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	webhooks, err := repos.Webhooks.GetWebhooks(c.Request.Context(), userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if len(webhooks) == 0 {
+		c.JSON(http.StatusNoContent, gin.H{
+			"msg": "No webhooks found.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "Webhooks fetched successfully.",
+		"data": webhooks,
+	})
+}
+
+// HandlerGetWebhook fetches a webhook of the user
+func HandlerGetWebhook(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	webhookID, err := strconv.Atoi(c.Param("wid"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	hook, err := repos.Webhooks.GetWebhook(c.Request.Context(), userUUID, webhookID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "Webhook fetched successfully.",
+		"data": hook,
+	})
+}
+
+// HandlerUpdateWebhook updates the url, the events and the state of a webhook. Enabling a webhook resets it's failures.
+func HandlerUpdateWebhook(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	webhookID, err := strconv.Atoi(c.Param("wid"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	var body WebhookUpdateInput
+
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		c.Error(problem.InvalidBody(err))
+		return
+	}
+
+	err = validate.Struct(body)
+	if err != nil {
+		c.Error(problem.Validation(err))
+		return
+	}
+
+	hook := model.Webhook{
+		WebhookID: webhookID,
+		UserUUID:  userUUID,
+		URL:       body.URL,
+		Events:    body.Events,
+		Active:    *body.Active,
+	}
+
+	err = repos.Webhooks.UpdateWebhook(c.Request.Context(), &hook)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// HandlerDeleteWebhook deletes a webhook along with it's delivery log
+func HandlerDeleteWebhook(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	webhookID, err := strconv.Atoi(c.Param("wid"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	err = repos.Webhooks.DeleteWebhook(c.Request.Context(), userUUID, webhookID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// HandlerGetWebhookDeliveries fetches the delivery log of a webhook with pagination
+func HandlerGetWebhookDeliveries(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	webhookID, err := strconv.Atoi(c.Param("wid"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	// Get the limit and offset query parameters from the URL
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.Error(problem.InvalidParameter("limit", err))
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.Error(problem.InvalidParameter("offset", err))
+		return
+	}
+
+	_, err = repos.Webhooks.GetWebhook(c.Request.Context(), userUUID, webhookID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	deliveries, err := repos.Webhooks.GetWebhookDeliveries(c.Request.Context(), userUUID, webhookID, limit, offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if len(deliveries) == 0 {
+		c.JSON(http.StatusNoContent, gin.H{
+			"msg": "No deliveries found.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "Deliveries fetched successfully.",
+		"data": deliveries,
+	})
+}
+
+// HandlerRedeliverWebhookDelivery queues the event of a delivery to be sent again
+func HandlerRedeliverWebhookDelivery(c *gin.Context) {
+	userUUID, err := middleware.GetUserUUID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	webhookID, err := strconv.Atoi(c.Param("wid"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	deliveryID, err := strconv.Atoi(c.Param("did"))
+	if err != nil {
+		c.Error(problem.NotFound())
+		return
+	}
+
+	delivery, err := repos.Webhooks.RedeliverWebhookDelivery(c.Request.Context(), userUUID, webhookID, deliveryID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"msg":  "Delivery queued successfully.",
+		"data": delivery,
+	})
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerCreateWebhook(t *testing.T) {
+	router := SetupDBTest(t)
+
+	route := "/webhook"
+	router.Use(middleware.VerifyAuthToken)
+	router.POST(route, HandlerCreateWebhook)
+	body := WebhookInput{
+		URL:    "https://hooks.example.com/catalog",
+		Events: []string{"service.created", "version.created"},
+	}
+	jsonValue, _ := json.Marshal(body)
+
+	req, _ := http.NewRequest(http.MethodPost, route, bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"secret":`)
+
+	// Case fail: Unknown event
+	body.Events = []string{"service.renamed"}
+	jsonValue, _ = json.Marshal(body)
+
+	req, _ = http.NewRequest(http.MethodPost, route, bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case fail: Not an http url
+	body.URL = "ftp://hooks.example.com/catalog"
+	body.Events = []string{"*"}
+	jsonValue, _ = json.Marshal(body)
+
+	req, _ = http.NewRequest(http.MethodPost, route, bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case fail: An address of the internal network
+	body.URL = "http://169.254.169.254/latest/meta-data"
+	jsonValue, _ = json.Marshal(body)
+
+	req, _ = http.NewRequest(http.MethodPost, route, bytes.NewBuffer(jsonValue))
+	AddAuthorizationHeader(req)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"url","rule":"public_url"`)
+}
+
+func TestHandlerRedeliverWebhookDelivery(t *testing.T) {
+	router := SetupDBTest(t)
+
+	route := "/webhook/:wid/delivery/:did/redeliver"
+	router.Use(middleware.VerifyAuthToken)
+	router.POST(route, HandlerRedeliverWebhookDelivery)
+
+	// Case fail: Delivery does not exist
+	req, _ := http.NewRequest(http.MethodPost, "/webhook/1000/delivery/1000/redeliver", nil)
+	AddAuthorizationHeader(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
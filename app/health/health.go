@@ -0,0 +1,167 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status of a check and of a report
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// defaultTimeout is how long a check may take before it is reported as down
+const defaultTimeout = 2 * time.Second
+
+// ErrShuttingDown is reported by the readiness once the server is shutting down
+var ErrShuttingDown = errors.New("the server is shutting down")
+
+var (
+	checker = NewChecker()
+)
+
+// Check is a named check of a dependency of the server, it returns an error when the dependency is not usable
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the result of a check
+type Result struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Report is the result of the checks, it is up when all of them are up
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Checker runs the checks of the liveness and of the readiness of the server
+type Checker struct {
+	// Timeout is how long each check may take
+	Timeout time.Duration
+
+	mu           sync.RWMutex
+	liveness     []Check
+	readiness    []Check
+	shuttingDown atomic.Bool
+}
+
+// NewChecker returns a checker without checks
+func NewChecker() *Checker {
+	return &Checker{Timeout: defaultTimeout}
+}
+
+// GetChecker gets the checker of the server
+func GetChecker() *Checker {
+	return checker
+}
+
+// AddLiveness adds a check of the liveness, the server is restarted when it fails.
+// It should only fail when restarting fixes it, not when a dependency is down.
+func (c *Checker) AddLiveness(name string, run func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.liveness = append(c.liveness, Check{Name: name, Run: run})
+}
+
+// AddReadiness adds a check of the readiness, the server does not receive traffic while it fails
+func (c *Checker) AddReadiness(name string, run func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.readiness = append(c.readiness, Check{Name: name, Run: run})
+}
+
+// ShutDown makes the server not ready, so that it stops receiving traffic before it's connections are drained
+func (c *Checker) ShutDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Live runs the checks of the liveness
+func (c *Checker) Live(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]Check(nil), c.liveness...)
+	c.mu.RUnlock()
+
+	return run(ctx, checks, c.Timeout)
+}
+
+// Ready runs the checks of the readiness, the server is not ready once it is shutting down
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]Check(nil), c.readiness...)
+	c.mu.RUnlock()
+
+	checks = append(checks, Check{Name: "shutdown", Run: func(ctx context.Context) error {
+		if c.shuttingDown.Load() {
+			return ErrShuttingDown
+		}
+		return nil
+	}})
+
+	return run(ctx, checks, c.Timeout)
+}
+
+// run runs the checks concurrently, a check which does not return within the timeout is down
+func run(ctx context.Context, checks []Check, timeout time.Duration) Report {
+	report := Report{Status: StatusUp, Checks: make([]Result, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			report.Checks[i] = runCheck(ctx, check, timeout)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+// runCheck runs a check with the timeout, the check is not waited for when it does not honor it's context
+func runCheck(ctx context.Context, check Check, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{
+		Name:       check.Name,
+		Status:     StatusUp,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,84 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker(t *testing.T) {
+	ctx := context.Background()
+	checker := NewChecker()
+
+	checker.AddLiveness("workers", func(ctx context.Context) error { return nil })
+	checker.AddReadiness("database", func(ctx context.Context) error { return nil })
+
+	report := checker.Live(ctx)
+	assert.Equal(t, StatusUp, report.Status)
+	if assert.Len(t, report.Checks, 1) {
+		assert.Equal(t, Result{Name: "workers", Status: StatusUp}, report.Checks[0])
+	}
+
+	report = checker.Ready(ctx)
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Len(t, report.Checks, 2)
+
+	// Case fail: A check is down
+	checker.AddReadiness("migrations", func(ctx context.Context) error { return errors.New("1 migrations are pending") })
+
+	report = checker.Ready(ctx)
+	assert.Equal(t, StatusDown, report.Status)
+	if assert.Len(t, report.Checks, 3) {
+		assert.Equal(t, StatusUp, report.Checks[0].Status)
+		assert.Equal(t, StatusDown, report.Checks[1].Status)
+		assert.Equal(t, "1 migrations are pending", report.Checks[1].Error)
+	}
+
+	// The liveness does not depend on the readiness
+	assert.Equal(t, StatusUp, checker.Live(ctx).Status)
+}
+
+func TestCheckerTimeout(t *testing.T) {
+	checker := NewChecker()
+	checker.Timeout = 10 * time.Millisecond
+
+	// Case fail: A check which honors it's context
+	checker.AddLiveness("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	// Case fail: A check which ignores it's context is not waited for
+	block := make(chan struct{})
+	defer close(block)
+	checker.AddLiveness("stuck", func(ctx context.Context) error {
+		<-block
+		return nil
+	})
+
+	report := checker.Live(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	for _, result := range report.Checks {
+		assert.Equal(t, StatusDown, result.Status)
+		assert.Equal(t, context.DeadlineExceeded.Error(), result.Error)
+	}
+}
+
+func TestCheckerShutDown(t *testing.T) {
+	ctx := context.Background()
+	checker := NewChecker()
+	checker.AddLiveness("workers", func(ctx context.Context) error { return nil })
+
+	checker.ShutDown()
+
+	// Case fail: The server is not ready once it is shutting down, it is still alive
+	report := checker.Ready(ctx)
+	assert.Equal(t, StatusDown, report.Status)
+	if assert.Len(t, report.Checks, 1) {
+		assert.Equal(t, "shutdown", report.Checks[0].Name)
+		assert.Equal(t, ErrShuttingDown.Error(), report.Checks[0].Error)
+	}
+	assert.Equal(t, StatusUp, checker.Live(ctx).Status)
+}
@@ -0,0 +1,69 @@
+// Package mergepatch applies JSON Merge Patch documents as defined by RFC 7386
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// MediaType is the media type of the merge patch documents
+const MediaType = "application/merge-patch+json"
+
+// ErrInvalidPatch is returned when the patch is not a JSON document
+var ErrInvalidPatch = errors.New("invalid merge patch")
+
+// Apply returns the document patched with the merge patch.
+// Members of the patch replace the members of the document, null members remove them and objects are merged recursively.
+// A patch which is not an object replaces the whole document.
+func Apply(document, patch []byte) ([]byte, error) {
+	var patchValue interface{}
+	if err := decode(patch, &patchValue); err != nil {
+		return nil, ErrInvalidPatch
+	}
+
+	var documentValue interface{}
+	if len(bytes.TrimSpace(document)) > 0 {
+		if err := decode(document, &documentValue); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(merge(documentValue, patchValue))
+}
+
+// merge implements the MergePatch function of RFC 7386
+func merge(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{}, len(patchObject))
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = merge(targetObject[name], value)
+	}
+
+	return targetObject
+}
+
+// decode decodes a single JSON value keeping the numbers as they are
+func decode(data []byte, value *interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(value); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package mergepatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	// Examples of RFC 7386 appendix A
+	tests := []struct {
+		document string
+		patch    string
+		result   string
+	}{
+		{document: `{"a":"b"}`, patch: `{"a":"c"}`, result: `{"a":"c"}`},
+		{document: `{"a":"b"}`, patch: `{"b":"c"}`, result: `{"a":"b","b":"c"}`},
+		{document: `{"a":"b"}`, patch: `{"a":null}`, result: `{}`},
+		{document: `{"a":"b","b":"c"}`, patch: `{"a":null}`, result: `{"b":"c"}`},
+		{document: `{"a":["b"]}`, patch: `{"a":"c"}`, result: `{"a":"c"}`},
+		{document: `{"a":"c"}`, patch: `{"a":["b"]}`, result: `{"a":["b"]}`},
+		{document: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, result: `{"a":{"b":"d"}}`},
+		{document: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, result: `{"a":[1]}`},
+		{document: `["a","b"]`, patch: `["c","d"]`, result: `["c","d"]`},
+		{document: `{"a":"b"}`, patch: `["c"]`, result: `["c"]`},
+		{document: `{"a":"foo"}`, patch: `null`, result: `null`},
+		{document: `{"a":"foo"}`, patch: `"bar"`, result: `"bar"`},
+		{document: `{"e":null}`, patch: `{"a":1}`, result: `{"a":1,"e":null}`},
+		{document: `[1,2]`, patch: `{"a":"b","c":null}`, result: `{"a":"b"}`},
+		{document: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, result: `{"a":{"bb":{}}}`},
+		{document: ``, patch: `{"a":1.50}`, result: `{"a":1.50}`},
+	}
+
+	for _, test := range tests {
+		result, err := Apply([]byte(test.document), []byte(test.patch))
+		assert.NoError(t, err)
+		assert.JSONEq(t, test.result, string(result), "%s patched with %s", test.document, test.patch)
+	}
+
+	// Case fail: The patch is not a JSON document
+	for _, patch := range []string{``, `{"a":`, `{"a":1} {"b":2}`} {
+		_, err := Apply([]byte(`{}`), []byte(patch))
+		assert.ErrorIs(t, err, ErrInvalidPatch, patch)
+	}
+}
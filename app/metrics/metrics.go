@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/db"
+	"github.com/ZiyanK/service-catalog-api/app/logger"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.uber.org/zap"
+)
+
+const (
+	// collectTimeout is how long the catalog is counted for when the metrics are scraped
+	collectTimeout = 5 * time.Second
+
+	// statsTTL is how long the catalog counted by a scrape is reported to the next ones, so that frequent scrapes or
+	// several Prometheus servers do not count the tables every time
+	statsTTL = 30 * time.Second
+)
+
+var (
+	log = logger.CreateLogger()
+)
+
+// Register registers the metrics of the connection pool of the database and of the catalog,
+// the metrics of the requests and of the statements are registered by their packages
+func Register() {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db.GetDBInstance().Sqlx.DB, "service_catalog"))
+	prometheus.MustRegister(NewCatalogCollector(model.GetCatalogStats, statsTTL))
+}
+
+// CatalogCollector exports the size of the catalog, it is counted when the metrics are scraped and kept for the ttl
+type CatalogCollector struct {
+	stats func(ctx context.Context) (*model.CatalogStats, error)
+	ttl   time.Duration
+
+	// mu serializes the scrapes so that the catalog is counted once when they are concurrent
+	mu        sync.Mutex
+	cached    *model.CatalogStats
+	countedAt time.Time
+
+	users             *prometheus.Desc
+	services          *prometheus.Desc
+	serviceVersions   *prometheus.Desc
+	artifacts         *prometheus.Desc
+	webhooks          *prometheus.Desc
+	unpublishedEvents *prometheus.Desc
+	deadEvents        *prometheus.Desc
+	pendingDeliveries *prometheus.Desc
+	up                *prometheus.Desc
+}
+
+// NewCatalogCollector returns a collector of the catalog counted by stats, the counts are reused for ttl
+func NewCatalogCollector(stats func(ctx context.Context) (*model.CatalogStats, error), ttl time.Duration) *CatalogCollector {
+	return &CatalogCollector{
+		stats: stats,
+		ttl:   ttl,
+
+		users:             prometheus.NewDesc("catalog_users", "Number of users.", nil, nil),
+		services:          prometheus.NewDesc("catalog_services", "Number of services.", nil, nil),
+		serviceVersions:   prometheus.NewDesc("catalog_service_versions", "Number of service versions.", nil, nil),
+		artifacts:         prometheus.NewDesc("catalog_artifacts", "Number of artifacts of the service versions.", nil, nil),
+		webhooks:          prometheus.NewDesc("catalog_webhooks", "Number of webhooks.", nil, nil),
+		unpublishedEvents: prometheus.NewDesc("catalog_outbox_unpublished_events", "Number of events of the outbox which are not published yet.", nil, nil),
+		deadEvents:        prometheus.NewDesc("catalog_outbox_dead_events", "Number of events of the outbox which failed to be published too many times.", nil, nil),
+		pendingDeliveries: prometheus.NewDesc("catalog_webhook_pending_deliveries", "Number of webhook deliveries which are not delivered yet.", nil, nil),
+		up:                prometheus.NewDesc("catalog_stats_up", "Whether the catalog could be counted.", nil, nil),
+	}
+}
+
+// Describe sends the descriptions of the metrics of the catalog
+func (c *CatalogCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.users
+	ch <- c.services
+	ch <- c.serviceVersions
+	ch <- c.artifacts
+	ch <- c.webhooks
+	ch <- c.unpublishedEvents
+	ch <- c.deadEvents
+	ch <- c.pendingDeliveries
+	ch <- c.up
+}
+
+// Collect counts the catalog when the counts are older than the ttl, only catalog_stats_up is reported when it fails
+func (c *CatalogCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.collectStats()
+	if err != nil {
+		log.Error("Failed to count the catalog", zap.Error(err))
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+	ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(stats.Users))
+	ch <- prometheus.MustNewConstMetric(c.services, prometheus.GaugeValue, float64(stats.Services))
+	ch <- prometheus.MustNewConstMetric(c.serviceVersions, prometheus.GaugeValue, float64(stats.ServiceVersions))
+	ch <- prometheus.MustNewConstMetric(c.artifacts, prometheus.GaugeValue, float64(stats.Artifacts))
+	ch <- prometheus.MustNewConstMetric(c.webhooks, prometheus.GaugeValue, float64(stats.Webhooks))
+	ch <- prometheus.MustNewConstMetric(c.unpublishedEvents, prometheus.GaugeValue, float64(stats.UnpublishedEvents))
+	ch <- prometheus.MustNewConstMetric(c.deadEvents, prometheus.GaugeValue, float64(stats.DeadEvents))
+	ch <- prometheus.MustNewConstMetric(c.pendingDeliveries, prometheus.GaugeValue, float64(stats.PendingDeliveries))
+}
+
+// collectStats returns the counts of the catalog, it is counted again once the counts are older than the ttl
+func (c *CatalogCollector) collectStats() (*model.CatalogStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached != nil && time.Since(c.countedAt) < c.ttl {
+		return c.cached, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	stats, err := c.stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cached = stats
+	c.countedAt = time.Now()
+	return stats, nil
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogCollector(t *testing.T) {
+	counted := 0
+	collector := NewCatalogCollector(func(ctx context.Context) (*model.CatalogStats, error) {
+		counted++
+		return &model.CatalogStats{Users: 2, Services: 3, ServiceVersions: 5, UnpublishedEvents: 1}, nil
+	}, time.Minute)
+
+	expected := `
+# HELP catalog_services Number of services.
+# TYPE catalog_services gauge
+catalog_services 3
+# HELP catalog_service_versions Number of service versions.
+# TYPE catalog_service_versions gauge
+catalog_service_versions 5
+# HELP catalog_stats_up Whether the catalog could be counted.
+# TYPE catalog_stats_up gauge
+catalog_stats_up 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "catalog_services", "catalog_service_versions", "catalog_stats_up"))
+	assert.Equal(t, 9, testutil.CollectAndCount(collector))
+
+	// Case: The catalog is counted once within the ttl
+	assert.Equal(t, 1, counted)
+
+	// Case: Only catalog_stats_up is reported when the catalog cannot be counted
+	collector = NewCatalogCollector(func(ctx context.Context) (*model.CatalogStats, error) {
+		return nil, errors.New("database is down")
+	}, time.Minute)
+
+	expected = `
+# HELP catalog_stats_up Whether the catalog could be counted.
+# TYPE catalog_stats_up gauge
+catalog_stats_up 0
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+}
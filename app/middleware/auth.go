@@ -1,13 +1,13 @@
 package middleware
 
 import (
-	"database/sql"
+	"context"
 	"errors"
-	"net/http"
 	"strings"
 
 	"github.com/ZiyanK/service-catalog-api/app/logger"
 	model "github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
@@ -18,20 +18,47 @@ import (
 
 var (
 	log = logger.CreateLogger()
+
+	// userRepo is the repository the users of the tokens are fetched from
+	userRepo model.UserRepository = model.SQLRepository{}
 )
 
+// UseUserRepository sets the repository the users of the tokens are fetched from
+func UseUserRepository(repo model.UserRepository) {
+	userRepo = repo
+}
+
+// ErrUnauthorized is returned when the auth token is missing, invalid or belongs to a user which does not exist
+var ErrUnauthorized = errors.New("unauthorized")
+
 // VerifyAuthToken is used to verify any given auth token with the secret provided in the env
 func VerifyAuthToken(c *gin.Context) {
-	authorizationToken := c.Request.Header.Get("Authorization")
-	if authorizationToken == "" {
-		c.AbortWithStatus(http.StatusUnauthorized)
+	userUUID, err := VerifyToken(c, c.Request.Header.Get("Authorization"))
+	if err == ErrUnauthorized {
+		c.Error(problem.Unauthorized())
+		c.Abort()
 		return
 	}
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	c.Set("user_uuid", userUUID)
+	c.Next()
+}
+
+// VerifyToken verifies an authorization value (`Bearer <token>`) and returns the uuid of the user it was issued to.
+// It is shared by the HTTP and the gRPC APIs.
+func VerifyToken(ctx context.Context, authorizationToken string) (uuid.UUID, error) {
+	if authorizationToken == "" {
+		return uuid.Nil, ErrUnauthorized
+	}
 
 	tokensArray := strings.Split(authorizationToken, " ")
 	if len(tokensArray) != 2 {
-		c.AbortWithStatus(http.StatusUnauthorized)
-		return
+		return uuid.Nil, ErrUnauthorized
 	}
 
 	token := tokensArray[1]
@@ -48,44 +75,36 @@ func VerifyAuthToken(c *gin.Context) {
 
 	})
 	if err != nil {
-		c.AbortWithStatus(http.StatusUnauthorized)
-		return
+		return uuid.Nil, ErrUnauthorized
 	}
 	if !parsedToken.Valid {
-		c.AbortWithStatus(http.StatusUnauthorized)
-		return
+		return uuid.Nil, ErrUnauthorized
 	}
 
 	claims, ok := parsedToken.Claims.(jwt.MapClaims)
 	if !ok {
-		c.AbortWithStatus(http.StatusInternalServerError)
-		return
+		return uuid.Nil, errors.New("invalid claims")
 	}
 
 	userUUIDstring, ok := claims["user_id"].(string)
 	if !ok {
-		c.AbortWithStatus(http.StatusInternalServerError)
-		return
+		return uuid.Nil, errors.New("invalid user_id claim")
 	}
 
 	userUUID, err := uuid.Parse(userUUIDstring)
 	if err != nil {
-		c.AbortWithStatus(http.StatusInternalServerError)
-		return
+		return uuid.Nil, err
 	}
 
-	user, err := model.GetUserByID(c, userUUID)
-	if err == sql.ErrNoRows {
-		c.AbortWithStatus(http.StatusUnauthorized)
-		return
+	user, err := userRepo.GetUserByID(ctx, userUUID)
+	if err == model.ErrUserNotFound {
+		return uuid.Nil, ErrUnauthorized
 	}
 	if err != nil {
-		c.AbortWithStatus(http.StatusInternalServerError)
-		return
+		return uuid.Nil, err
 	}
 
-	c.Set("user_uuid", user.UserUUID)
-	c.Next()
+	return user.UserUUID, nil
 }
 
 // HashValue returns the hashed string of the value given
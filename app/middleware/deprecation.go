@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+)
+
+// deprecatedRequests counts the requests of the deprecated routes by method and route template
+var deprecatedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_deprecated_requests_total",
+	Help: "Number of HTTP requests of the deprecated routes by method and route.",
+}, []string{"method", "route"})
+
+// Deprecation describes when a route was deprecated and when it will be removed
+type Deprecation struct {
+	// Since is the date the route was deprecated
+	Since time.Time
+	// Sunset is the date after which the route may be removed, it is not reported when zero
+	Sunset time.Time
+
+	// Prefix of the path which is replaced by Successor to link the route which replaces it, e.g. `/v1` by `/v2`.
+	// The successor is not linked when Successor is empty.
+	Prefix    string
+	Successor string
+}
+
+// Deprecated reports the deprecation of the routes with the Deprecation (RFC 9745), Sunset (RFC 8594) and Link headers.
+// The requests of the deprecated routes are counted and logged so their clients can be found before the sunset.
+func Deprecated(deprecation Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "@"+strconv.FormatInt(deprecation.Since.Unix(), 10))
+		if !deprecation.Sunset.IsZero() {
+			c.Header("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if deprecation.Successor != "" {
+			successor := deprecation.Successor + strings.TrimPrefix(c.Request.URL.Path, deprecation.Prefix)
+			c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		}
+
+		route := c.Request.Method + " " + c.FullPath()
+		deprecatedRequests.WithLabelValues(c.Request.Method, c.FullPath()).Inc()
+
+		c.Next()
+
+		userUUID, _ := GetUserUUID(c)
+		log.Info("Deprecated route requested",
+			zap.String("route", route),
+			zap.String("user_agent", c.Request.UserAgent()),
+			zap.Any("user_uuid", userUUID))
+	}
+}
+
+// DeprecatedRequests returns the number of requests of a deprecated route, e.g. `GET` and `/services`
+func DeprecatedRequests(method, route string) float64 {
+	var metric dto.Metric
+	err := deprecatedRequests.WithLabelValues(method, route).Write(&metric)
+	if err != nil {
+		return 0
+	}
+	return metric.GetCounter().GetValue()
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// HandleErrors writes the last error added to the context by the handlers as a problem+json response.
+// Responses already written by the handlers are left as they are.
+func HandleErrors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		p := problem.FromError(err)
+		p.Instance = c.Request.URL.Path
+
+		if p.Status >= http.StatusInternalServerError {
+			log.Error("Error while handling request", zap.String("path", p.Instance), zap.Error(err))
+		} else {
+			log.Info("Request failed", zap.String("path", p.Instance), zap.String("code", p.Code), zap.Error(err))
+		}
+
+		c.Header("Content-Type", problem.ContentType)
+		c.JSON(p.Status, p)
+	}
+}
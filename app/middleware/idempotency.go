@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	model "github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// IdempotencyKeyHeader is the header of the key a client sends to retry a request safely
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// maxIdempotencyKeyLength is the length of the `idempotency_key` column
+	maxIdempotencyKeyLength = 255
+
+	// defaultIdempotencyKeyTTL is how long the responses are replayed when `idempotency_key_ttl` is not set
+	defaultIdempotencyKeyTTL = 24 * time.Hour
+
+	// defaultIdempotencyKeyPurgeInterval is how often the expired keys are deleted when `idempotency_key_purge_interval` is not set
+	defaultIdempotencyKeyPurgeInterval = time.Hour
+
+	// idempotencyKeyAbandonAfter is how long a key stays claimed by a request which did not complete, e.g. on a crash
+	idempotencyKeyAbandonAfter = 5 * time.Minute
+)
+
+// IdempotencyKeyTTL returns how long the responses of the idempotent requests are stored
+func IdempotencyKeyTTL() time.Duration {
+	if ttl := viper.GetDuration("idempotency_key_ttl"); ttl > 0 {
+		return ttl
+	}
+	return defaultIdempotencyKeyTTL
+}
+
+// IdempotencyKeyPurgeInterval returns how often the expired idempotency keys are deleted
+func IdempotencyKeyPurgeInterval() time.Duration {
+	if interval := viper.GetDuration("idempotency_key_purge_interval"); interval > 0 {
+		return interval
+	}
+	return defaultIdempotencyKeyPurgeInterval
+}
+
+// responseRecorder keeps a copy of the response body written by the handlers
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes the requests with an Idempotency-Key header safe to retry.
+// The first request with a key is handled and it's response is stored, the retries with the same key and body replay it.
+// A key reused with another request is rejected, as is a retry while the first request is still in progress.
+// Requests which fail with an error are not stored so they can be retried.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keyValue := c.GetHeader(IdempotencyKeyHeader)
+		if keyValue == "" {
+			c.Next()
+			return
+		}
+
+		if len(keyValue) > maxIdempotencyKeyLength {
+			c.Error(problem.InvalidParameter(IdempotencyKeyHeader, errors.New("key is too long")))
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Error(problem.InvalidBody(err))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are scoped by user, the public routes share the nil user
+		userUUID, _ := GetUserUUID(c)
+
+		key := &model.IdempotencyKey{
+			UserUUID:    userUUID,
+			Key:         keyValue,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			RequestHash: requestHash(c.Request.Method, c.Request.URL.RequestURI(), body),
+		}
+
+		stored, claimed, err := model.ClaimIdempotencyKey(c.Request.Context(), key, IdempotencyKeyTTL(), idempotencyKeyAbandonAfter)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		if !claimed {
+			replay(c, key, stored)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// The response is stored even if the client went away, so a retry gets it
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		status := c.Writer.Status()
+		if !c.Writer.Written() || status >= http.StatusInternalServerError {
+			key.ReleaseIdempotencyKey(ctx)
+			return
+		}
+
+		key.StatusCode = status
+		key.ContentType = c.Writer.Header().Get("Content-Type")
+		key.ResponseBody = recorder.body.Bytes()
+		if err := key.SaveIdempotentResponse(ctx); err != nil {
+			log.Error("Error while storing idempotent response", zap.String("key", key.Key), zap.Error(err))
+		}
+	}
+}
+
+// replay responds to a request whose key is already used
+func replay(c *gin.Context, key, stored *model.IdempotencyKey) {
+	c.Abort()
+
+	if stored.RequestHash != key.RequestHash {
+		c.Error(problem.New(http.StatusUnprocessableEntity, "idempotency_key_reused", "The Idempotency-Key was used with another request."))
+		return
+	}
+
+	if stored.StatusCode == 0 {
+		c.Error(problem.New(http.StatusConflict, "idempotency_key_in_use", "A request with the same Idempotency-Key is in progress."))
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(stored.StatusCode, stored.ContentType, stored.ResponseBody)
+}
+
+// requestHash identifies a request by it's method, URI and body
+func requestHash(method, uri string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + uri + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// PurgeIdempotencyKeys deletes the expired idempotency keys at every interval until the context is done
+func PurgeIdempotencyKeys(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := model.DeleteExpiredIdempotencyKeys(ctx, time.Now().UTC().Add(-IdempotencyKeyTTL()))
+			if err != nil {
+				continue
+			}
+			if deleted > 0 {
+				log.Info("Deleted expired idempotency keys", zap.Int64("count", deleted))
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestHash(t *testing.T) {
+	hash := requestHash(http.MethodPost, "/v1/service/1/version", []byte(`{"version":"v1.0.0"}`))
+
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, requestHash(http.MethodPost, "/v1/service/1/version", []byte(`{"version":"v1.0.0"}`)))
+	assert.NotEqual(t, hash, requestHash(http.MethodPost, "/v1/service/1/version", []byte(`{"version":"v1.0.1"}`)))
+	assert.NotEqual(t, hash, requestHash(http.MethodPost, "/v1/service/2/version", []byte(`{"version":"v1.0.0"}`)))
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+
+	serve := func(key, stored *model.IdempotencyKey) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.Use(HandleErrors())
+		router.POST("/service", func(c *gin.Context) {
+			replay(c, key, stored)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/service", nil))
+		return w
+	}
+
+	key := &model.IdempotencyKey{Key: "retry-1", RequestHash: "a"}
+
+	// Case: The stored response is replayed
+	w := serve(key, &model.IdempotencyKey{RequestHash: "a", StatusCode: http.StatusCreated, ContentType: "application/json", ResponseBody: []byte(`{"msg":"created"}`)})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `{"msg":"created"}`, w.Body.String())
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+
+	// Case fail: The first request is in progress
+	w = serve(key, &model.IdempotencyKey{RequestHash: "a"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+	// Case fail: The key was used with another body
+	w = serve(key, &model.IdempotencyKey{RequestHash: "b", StatusCode: http.StatusCreated})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"idempotency_key_reused"`)
+}
+
+func TestIdempotencyKeyTooLong(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(HandleErrors())
+	router.POST("/service", Idempotency(), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/service", nil)
+	req.Header.Set(IdempotencyKeyHeader, strings.Repeat("k", maxIdempotencyKeyLength+1))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Case: Requests without a key are not affected
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/service", nil))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
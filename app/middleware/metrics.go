@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/spf13/viper"
+)
+
+const (
+	// unmatchedRoute is the route of the requests which do not match a route, so that their paths are not used as labels
+	unmatchedRoute = "unmatched"
+
+	// eventStreamContentType is the content type of the server-sent event streams
+	eventStreamContentType = "text/event-stream"
+)
+
+var (
+	// httpRequests counts the requests by method, route template and status
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	// httpRequestDuration is the latency of the requests by method, route template and status
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of the HTTP requests by method, route and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// Metrics counts the requests and measures their latency, they are labeled by the template of their route
+// (e.g. `/v1/service/:id`) so that the number of series does not grow with the ids.
+// The latency of the event streams is not measured, they last as long as their clients are connected.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		if strings.HasPrefix(c.Writer.Header().Get("Content-Type"), eventStreamContentType) {
+			return
+		}
+		httpRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// MetricsToken only lets the scrapes sending `Authorization: Bearer <metrics_token>` through.
+// Every scrape is rejected when `metrics_token` is not set.
+func MetricsToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := viper.GetString("metrics_token")
+		authorization := []byte(c.Request.Header.Get("Authorization"))
+		if token == "" || subtle.ConstantTimeCompare(authorization, []byte("Bearer "+token)) != 1 {
+			c.Error(problem.Unauthorized())
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+
+	router := gin.New()
+	router.Use(Metrics())
+	router.GET("/metrics-test/:id", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/metrics-test-stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/metrics-test/1", "/metrics-test/2", "/metrics-test-missing", "/metrics-test-stream"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Case: The requests are labeled by the template of their route, not by their path
+	assert.Equal(t, float64(2), testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/metrics-test/:id", "204")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/metrics-test/1", "204")))
+
+	// Case: The requests which do not match a route share a label
+	assert.Equal(t, float64(1), testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, unmatchedRoute, "404")))
+
+	// Case: The event streams are counted but their latency is not measured
+	assert.Equal(t, float64(1), testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/metrics-test-stream", "200")))
+	assert.Equal(t, 2, testutil.CollectAndCount(httpRequestDuration))
+}
+
+func TestMetricsToken(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+
+	router := gin.New()
+	router.Use(HandleErrors())
+	router.GET("/metrics", MetricsToken(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	scrape := func(authorization string) int {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Case fail: The scrapes are rejected when no token is configured
+	assert.Equal(t, http.StatusUnauthorized, scrape(""))
+	assert.Equal(t, http.StatusUnauthorized, scrape("Bearer "))
+
+	viper.Set("metrics_token", "scrape-token")
+	defer viper.Set("metrics_token", "")
+
+	// Case fail: Missing or wrong token
+	assert.Equal(t, http.StatusUnauthorized, scrape(""))
+	assert.Equal(t, http.StatusUnauthorized, scrape("Bearer wrong-token"))
+
+	// Case: The configured token is accepted
+	assert.Equal(t, http.StatusOK, scrape("Bearer scrape-token"))
+}
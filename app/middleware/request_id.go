@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// RequestIDHeader is the header of the id identifying a request, it is generated when the client does not send it
+	RequestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLength is the length of the `request_id` column of the audit log
+	maxRequestIDLength = 255
+)
+
+// RequestID identifies every request with an id, it is returned in the X-Request-ID header.
+// The id and the client IP are stored in the context of the request so that the changes it makes are audited with them.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(model.WithRequestInfo(c.Request.Context(), model.RequestInfo{
+			RequestID: requestID,
+			ClientIP:  c.ClientIP(),
+		}))
+
+		c.Next()
+	}
+}
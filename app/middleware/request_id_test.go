@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+
+	var requestID string
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/ping", func(c *gin.Context) {
+		requestID = c.GetString("request_id")
+		c.Status(http.StatusOK)
+	})
+
+	// Case: The id sent by the client is kept
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "req-1", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "req-1", requestID)
+
+	// Case: An id is generated when the client does not send one or it is too long
+	for _, header := range []string{"", strings.Repeat("a", maxRequestIDLength+1)} {
+		req = httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set(RequestIDHeader, header)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Len(t, w.Header().Get(RequestIDHeader), 36)
+		assert.Equal(t, w.Header().Get(RequestIDHeader), requestID)
+	}
+}
@@ -0,0 +1,220 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	database "github.com/ZiyanK/service-catalog-api/app/db"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	queryInsertArtifact = `
+	INSERT INTO service_version_artifacts(sv_id, user_uuid, type, uri, digest, size_bytes)
+	VALUES (:sv_id, :user_uuid, :type, :uri, :digest, :size_bytes)`
+
+	queryCheckArtifactByDigest = `SELECT count(1) FROM service_version_artifacts WHERE user_uuid = :user_uuid AND digest = :digest`
+
+	queryGetServiceVersionArtifacts = `
+	SELECT a.artifact_id, a.sv_id, a.type, a.uri, a.digest, a.size_bytes, a.created_at, a.updated_at
+	FROM service_version_artifacts a
+	JOIN service_versions sv ON sv.sv_id = a.sv_id
+	JOIN services s ON s.service_id = sv.service_id
+	WHERE
+		a.sv_id = :sv_id
+		AND s.service_id = :service_id
+		AND s.user_uuid = :user_uuid
+	ORDER BY a.artifact_id`
+
+	queryGetArtifactByDigest = `
+	SELECT s.service_id, s.name, sv.sv_id, sv.version,
+		a.artifact_id, a.type, a.uri, a.digest, a.size_bytes, a.created_at
+	FROM service_version_artifacts a
+	JOIN service_versions sv ON sv.sv_id = a.sv_id
+	JOIN services s ON s.service_id = sv.service_id
+	WHERE
+		a.user_uuid = :user_uuid
+		AND a.digest = :digest`
+)
+
+// Supported artifact types
+const (
+	ArtifactTypeImage   = "image"
+	ArtifactTypeTarball = "tarball"
+	ArtifactTypeSBOM    = "sbom"
+)
+
+// Artifact is a struct used to represent the `service_version_artifacts` table in the database
+type Artifact struct {
+	ArtifactID int       `db:"artifact_id" json:"artifact_id"`
+	SvID       int       `db:"sv_id" json:"sv_id"`
+	Type       string    `db:"type" json:"type"`
+	URI        string    `db:"uri" json:"uri"`
+	Digest     string    `db:"digest" json:"digest"`
+	SizeBytes  int64     `db:"size_bytes" json:"size_bytes"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ArtifactLookup is a struct used to get the service version an artifact digest belongs to
+type ArtifactLookup struct {
+	ServiceID  int       `db:"service_id" json:"service_id"`
+	Name       string    `db:"name" json:"name"`
+	SvID       int       `db:"sv_id" json:"sv_id"`
+	Version    string    `db:"version" json:"version"`
+	ArtifactID int       `db:"artifact_id" json:"artifact_id"`
+	Type       string    `db:"type" json:"type"`
+	URI        string    `db:"uri" json:"uri"`
+	Digest     string    `db:"digest" json:"digest"`
+	SizeBytes  int64     `db:"size_bytes" json:"size_bytes"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// CreateArtifact is used to attach a new artifact to a given service version
+func (artifact *Artifact) CreateArtifact(ctx context.Context, userUUID uuid.UUID, serviceID int) error {
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		// Check if service version belongs to user
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceVersionUsingSVID, map[string]interface{}{
+			"sv_id":      artifact.SvID,
+			"service_id": serviceID,
+			"user_uuid":  userUUID,
+		})
+		if err != nil {
+			log.Error("error building service version check query", zap.Error(err))
+			return err
+		}
+
+		var svCount int
+
+		err = tx.GetContext(ctx, &svCount, q, args...)
+		if err != nil && err != sql.ErrNoRows {
+			log.Error("error querying service version", zap.Error(err))
+			return err
+		}
+
+		if svCount == 0 {
+			log.Info("service version does not exist")
+			return ErrServiceVersionNotFound
+		}
+
+		// Digests are unique for each user so that a digest maps to a single service version of the user,
+		// the digests of the other users are not visible
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckArtifactByDigest, map[string]interface{}{
+			"user_uuid": userUUID,
+			"digest":    artifact.Digest,
+		})
+		if err != nil {
+			log.Error("error building artifact check query", zap.Error(err))
+			return err
+		}
+
+		var artifactCount int
+
+		err = tx.GetContext(ctx, &artifactCount, q, args...)
+		if err != nil && err != sql.ErrNoRows {
+			log.Error("error querying artifact", zap.Error(err))
+			return err
+		}
+
+		if artifactCount > 0 {
+			log.Info("artifact with same digest exists")
+			return ErrArtifactExists
+		}
+
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertArtifact, map[string]interface{}{
+			"sv_id":      artifact.SvID,
+			"user_uuid":  userUUID,
+			"type":       artifact.Type,
+			"uri":        artifact.URI,
+			"digest":     artifact.Digest,
+			"size_bytes": artifact.SizeBytes,
+		})
+		if err != nil {
+			log.Error("error building artifact insert query", zap.Error(err))
+			return err
+		}
+
+		// An artifact created concurrently with the same digest violates the unique constraint
+		result, err := tx.ExecContext(ctx, q, args...)
+		if database.IsUniqueViolation(err) {
+			log.Info("artifact with same digest exists")
+			return ErrArtifactExists
+		}
+		if err != nil {
+			log.Error("error inserting artifact", zap.Error(err))
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.Error("Error while getting no. of rows affected", zap.Error(err))
+			return err
+		}
+
+		if rowsAffected == 0 {
+			log.Info("no row were added")
+			return errors.New("error while creating artifact")
+		}
+
+		data := map[string]interface{}{
+			"service_id": serviceID,
+			"sv_id":      artifact.SvID,
+			"type":       artifact.Type,
+			"uri":        artifact.URI,
+			"digest":     artifact.Digest,
+			"size_bytes": artifact.SizeBytes,
+		}
+
+		err = recordEvent(ctx, tx, userUUID, serviceID, EventArtifactCreated, data)
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, tx, userUUID, EventArtifactCreated, AuditTargetArtifact, artifact.Digest, nil, data)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// GetServiceVersionArtifacts is used to fetch all the artifacts attached to a given service version
+func GetServiceVersionArtifacts(ctx context.Context, userUUID uuid.UUID, serviceID, svID int) ([]Artifact, error) {
+	var artifacts []Artifact
+
+	err := db.NamedSelectContext(ctx, &artifacts, queryGetServiceVersionArtifacts, map[string]interface{}{
+		"sv_id":      svID,
+		"service_id": serviceID,
+		"user_uuid":  userUUID,
+	})
+	if err != nil {
+		log.Error("Error while fetching artifacts", zap.Error(err))
+		return nil, err
+	}
+
+	return artifacts, nil
+}
+
+// GetArtifactByDigest is used to find the service version an artifact digest belongs to
+func GetArtifactByDigest(ctx context.Context, userUUID uuid.UUID, digest string) (*ArtifactLookup, error) {
+	var lookup ArtifactLookup
+
+	err := db.NamedGetContext(ctx, &lookup, queryGetArtifactByDigest, map[string]interface{}{
+		"digest":    digest,
+		"user_uuid": userUUID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrArtifactNotFound
+		}
+		log.Error("Error while fetching artifact by digest", zap.Error(err))
+		return nil, err
+	}
+
+	return &lookup, nil
+}
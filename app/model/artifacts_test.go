@@ -0,0 +1,83 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestQueryCheckArtifactByDigest is used to test whether the index is used to query the artifact by it's digest
+func TestQueryCheckArtifactByDigest(t *testing.T) {
+	setupTest()
+
+	plan := queryPlan(t, queryCheckArtifactByDigest, map[string]interface{}{
+		"user_uuid": userUUID,
+		"digest":    "sha256:" + strings.Repeat("a", 64),
+	})
+
+	if !usesIndex(plan, "Index Only") {
+		t.Error("Expected index scan but index is not being used")
+	}
+}
+
+// TestQueryGetServiceVersionArtifacts is used to test whether the index is used to query the artifacts by their service version id
+func TestQueryGetServiceVersionArtifacts(t *testing.T) {
+	setupTest()
+
+	plan := queryPlan(t, queryGetServiceVersionArtifacts, map[string]interface{}{
+		"sv_id":      1,
+		"service_id": 1,
+		"user_uuid":  userUUID,
+	})
+
+	if !usesIndex(plan, "Index Scan") {
+		t.Error("Expected index scan but index is not being used")
+	}
+}
+
+// createArtifactOf creates a service version of the user with an artifact of the digest
+func createArtifactOf(t *testing.T, owner uuid.UUID, digest string) error {
+	ctx := context.Background()
+
+	service := &Service{Name: "artifact-" + uuid.NewString(), UserUUID: owner}
+	if err := service.CreateService(ctx); err != nil {
+		t.Fatal("Failed to create service:", err)
+	}
+
+	sv := &ServiceVersion{ServiceID: service.ServiceID, Version: "1.0.0", Changes: []ServiceVersionChange{{Category: "Added", Description: "artifact"}}}
+	if err := sv.CreateServiceVersion(ctx, owner); err != nil {
+		t.Fatal("Failed to create service version:", err)
+	}
+
+	artifact := &Artifact{SvID: sv.SvID, Type: ArtifactTypeTarball, URI: "https://example.com/artifact.tgz", Digest: digest}
+	return artifact.CreateArtifact(ctx, owner, service.ServiceID)
+}
+
+func TestCreateArtifactDigestIsScopedToUser(t *testing.T) {
+	setupTest()
+	ctx := context.Background()
+
+	other := User{UserUUID: uuid.New(), Email: uuid.NewString() + "@example.com", Password: "secret"}
+	assert.NoError(t, other.CreateUser(ctx))
+
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(uuid.NewString())))
+	assert.NoError(t, createArtifactOf(t, userUUID, digest))
+
+	// Case: Another user can attach the same digest and does not see the artifact of the user
+	_, err := GetArtifactByDigest(ctx, other.UserUUID, digest)
+	assert.ErrorIs(t, err, ErrArtifactNotFound)
+	assert.NoError(t, createArtifactOf(t, other.UserUUID, digest))
+
+	lookup, err := GetArtifactByDigest(ctx, other.UserUUID, digest)
+	if assert.NoError(t, err) {
+		assert.Equal(t, digest, lookup.Digest)
+	}
+
+	// Case fail: The digest is unique for a user
+	assert.ErrorIs(t, createArtifactOf(t, userUUID, digest), ErrArtifactExists)
+}
@@ -0,0 +1,376 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	// The entries are chained in the order they are committed, the lock is held until the end of the transaction
+	queryLockAudit = `SELECT pg_advisory_xact_lock(:lock_id)`
+
+	querySelectLastAuditHash = `SELECT a.hash FROM audit_events a ORDER BY a.audit_id DESC LIMIT 1`
+
+	queryInsertAuditEvent = `
+	INSERT INTO audit_events(actor_uuid, action, target_type, target_id, before, after, request_id, client_ip, prev_hash, hash, created_at)
+	VALUES(:actor_uuid, :action, :target_type, :target_id, :before, :after, :request_id, :client_ip, :prev_hash, :hash, :created_at)`
+
+	querySelectAuditEvents = `
+	SELECT a.audit_id, a.actor_uuid, a.action, a.target_type, a.target_id, COALESCE(a.before, '') as before,
+		COALESCE(a.after, '') as after, a.request_id, a.client_ip, a.prev_hash, a.hash, a.created_at
+	FROM audit_events a
+	WHERE a.actor_uuid = :actor_uuid
+		AND (:action = '' OR a.action = :action)
+		AND (:target_type = '' OR a.target_type = :target_type)
+		AND (:target_id = '' OR a.target_id = :target_id)
+		AND (:request_id = '' OR a.request_id = :request_id)
+		AND a.created_at >= :from AND a.created_at < :to
+	ORDER BY a.audit_id DESC
+	LIMIT :limit OFFSET :offset`
+
+	querySelectAuditChain = `
+	SELECT a.audit_id, a.actor_uuid, a.action, a.target_type, a.target_id, COALESCE(a.before, '') as before,
+		COALESCE(a.after, '') as after, a.request_id, a.client_ip, a.prev_hash, a.hash, a.created_at
+	FROM audit_events a
+	WHERE a.audit_id > :after
+	ORDER BY a.audit_id
+	LIMIT :limit`
+)
+
+// auditLockID is the key of the advisory lock held while appending to the audit log
+const auditLockID = 7312
+
+// Targets of the audit events
+const (
+	AuditTargetUser            = "user"
+	AuditTargetService         = "service"
+	AuditTargetVersion         = "version"
+	AuditTargetArtifact        = "artifact"
+	AuditTargetTeam            = "team"
+	AuditTargetWebhook         = "webhook"
+	AuditTargetWebhookDelivery = "webhook_delivery"
+)
+
+// Actions of the audit events which are not catalog events
+const (
+	AuditUserCreated         = "user.created"
+	AuditUserUpdated         = "user.updated"
+	AuditTeamCreated         = "team.created"
+	AuditTeamMemberAdded     = "team.member_added"
+	AuditTeamMemberRemoved   = "team.member_removed"
+	AuditTeamMemberInvited   = "team.member_invited"
+	AuditTeamServiceAdded    = "team.service_added"
+	AuditTeamServiceRemoved  = "team.service_removed"
+	AuditWebhookCreated      = "webhook.created"
+	AuditWebhookUpdated      = "webhook.updated"
+	AuditWebhookDeleted      = "webhook.deleted"
+	AuditWebhookRedelivered  = "webhook.redelivered"
+	AuditServiceEntityStored = "service.entity_stored"
+	AuditServiceReverted     = "service.reverted"
+)
+
+// JSONText is a JSON document stored as text, it is marshalled as it is
+type JSONText string
+
+// MarshalJSON returns the document, an empty document is null
+func (text JSONText) MarshalJSON() ([]byte, error) {
+	if text == "" {
+		return []byte("null"), nil
+	}
+	return []byte(text), nil
+}
+
+// AuditEvent is a struct used to represent the `audit_events` table in the database.
+// Every entry holds the hash of the previous one, so that a modified or deleted entry breaks the chain.
+type AuditEvent struct {
+	AuditID    int64     `db:"audit_id" json:"audit_id"`
+	ActorUUID  uuid.UUID `db:"actor_uuid" json:"actor_uuid"`
+	Action     string    `db:"action" json:"action"`
+	TargetType string    `db:"target_type" json:"target_type"`
+	TargetID   string    `db:"target_id" json:"target_id"`
+	Before     JSONText  `db:"before" json:"before"`
+	After      JSONText  `db:"after" json:"after"`
+	RequestID  string    `db:"request_id" json:"request_id"`
+	ClientIP   string    `db:"client_ip" json:"client_ip"`
+	PrevHash   string    `db:"prev_hash" json:"prev_hash"`
+	Hash       string    `db:"hash" json:"hash"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+
+	// Diff are the fields which changed between before and after
+	Diff map[string]AuditChange `db:"-" json:"diff"`
+}
+
+// AuditChange is the value of a field before and after a change
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditFilter is used to filter the audit events of an actor, empty fields are not filtered.
+// The events are paginated by 50 when Limit is not set.
+type AuditFilter struct {
+	ActorUUID  uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	From       time.Time
+	To         time.Time
+	Limit      int
+	Offset     int
+}
+
+// AuditVerification is the result of the verification of the hash chain of the audit log
+type AuditVerification struct {
+	Valid   bool  `json:"valid"`
+	Checked int64 `json:"checked"`
+	// BrokenAt is the first entry which does not match the chain
+	BrokenAt int64 `json:"broken_at,omitempty"`
+}
+
+// RequestInfo identifies the request a change was made by, it is recorded in the audit log
+type RequestInfo struct {
+	RequestID string
+	ClientIP  string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a context carrying the request info
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// requestInfoFromContext returns the request info of the context, it is empty for changes made outside of a request
+func requestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
+
+// recordAudit appends an entry to the audit log within the transaction of the change it describes.
+// before and after are the state of the target, they are nil when it is created or deleted.
+func recordAudit(ctx context.Context, tx *sqlx.Tx, actorUUID uuid.UUID, action, targetType, targetID string, before, after interface{}) error {
+	info := requestInfoFromContext(ctx)
+
+	event := AuditEvent{
+		ActorUUID:  actorUUID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		RequestID:  info.RequestID,
+		ClientIP:   info.ClientIP,
+		// Timestamps are stored with a microsecond precision
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	var err error
+	if event.Before, err = toJSONText(before); err != nil {
+		log.Error("error encoding audit event", zap.Error(err))
+		return err
+	}
+	if event.After, err = toJSONText(after); err != nil {
+		log.Error("error encoding audit event", zap.Error(err))
+		return err
+	}
+
+	// SQLite has a single writer, the transaction already holds the write lock
+	if !db.IsSQLite() {
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryLockAudit, map[string]interface{}{
+			"lock_id": auditLockID,
+		})
+		if err != nil {
+			log.Error("error building audit lock query", zap.Error(err))
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("error locking audit log", zap.Error(err))
+			return err
+		}
+	}
+
+	err = tx.GetContext(ctx, &event.PrevHash, querySelectLastAuditHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error("error querying last audit event", zap.Error(err))
+		return err
+	}
+
+	event.Hash = event.computeHash()
+
+	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertAuditEvent, map[string]interface{}{
+		"actor_uuid":  event.ActorUUID,
+		"action":      event.Action,
+		"target_type": event.TargetType,
+		"target_id":   event.TargetID,
+		"before":      nullJSONText(event.Before),
+		"after":       nullJSONText(event.After),
+		"request_id":  event.RequestID,
+		"client_ip":   event.ClientIP,
+		"prev_hash":   event.PrevHash,
+		"hash":        event.Hash,
+		"created_at":  event.CreatedAt,
+	})
+	if err != nil {
+		log.Error("error building audit event insert query", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		log.Error("error inserting audit event", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// computeHash returns the hash of the entry chained to the hash of the previous entry
+func (event *AuditEvent) computeHash() string {
+	hash := sha256.New()
+	hash.Write([]byte(strings.Join([]string{
+		event.PrevHash,
+		event.ActorUUID.String(),
+		event.Action,
+		event.TargetType,
+		event.TargetID,
+		string(event.Before),
+		string(event.After),
+		event.RequestID,
+		event.ClientIP,
+		event.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}, "\n")))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// GetAuditEvents is used to fetch the audit events of an actor, the latest events come first
+func GetAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	var events []AuditEvent
+
+	params := map[string]interface{}{
+		"actor_uuid":  filter.ActorUUID,
+		"action":      filter.Action,
+		"target_type": filter.TargetType,
+		"target_id":   filter.TargetID,
+		"request_id":  filter.RequestID,
+		"from":        filter.From,
+		"to":          filter.To,
+		"limit":       50,
+		"offset":      0,
+	}
+
+	if filter.To.IsZero() {
+		params["to"] = time.Now().UTC().Add(time.Minute)
+	}
+	if filter.Limit > 0 {
+		params["limit"] = filter.Limit
+	}
+	if filter.Offset > 0 {
+		params["offset"] = filter.Offset
+	}
+
+	err := db.NamedSelectContext(ctx, &events, querySelectAuditEvents, params)
+	if err != nil {
+		log.Error("Error while fetching audit events", zap.Error(err))
+		return nil, err
+	}
+
+	for i := range events {
+		events[i].Diff = DiffAudit(events[i].Before, events[i].After)
+	}
+
+	return events, nil
+}
+
+// VerifyAuditChain recomputes the hash chain of the whole audit log in batches
+func VerifyAuditChain(ctx context.Context, batchSize int) (AuditVerification, error) {
+	verification := AuditVerification{Valid: true}
+
+	var after int64
+	var prevHash string
+	for {
+		var events []AuditEvent
+		err := db.NamedSelectContext(ctx, &events, querySelectAuditChain, map[string]interface{}{
+			"after": after,
+			"limit": batchSize,
+		})
+		if err != nil {
+			log.Error("Error while fetching audit chain", zap.Error(err))
+			return verification, err
+		}
+
+		for _, event := range events {
+			verification.Checked++
+			if event.PrevHash != prevHash || event.computeHash() != event.Hash {
+				verification.Valid = false
+				verification.BrokenAt = event.AuditID
+				return verification, nil
+			}
+			prevHash = event.Hash
+			after = event.AuditID
+		}
+
+		if len(events) < batchSize {
+			return verification, nil
+		}
+	}
+}
+
+// DiffAudit returns the top-level fields which differ between the before and after documents of an audit event
+func DiffAudit(before, after JSONText) map[string]AuditChange {
+	beforeFields := map[string]interface{}{}
+	afterFields := map[string]interface{}{}
+	if before != "" {
+		json.Unmarshal([]byte(before), &beforeFields)
+	}
+	if after != "" {
+		json.Unmarshal([]byte(after), &afterFields)
+	}
+
+	diff := map[string]AuditChange{}
+	for name, value := range beforeFields {
+		if afterValue, ok := afterFields[name]; !ok || !reflect.DeepEqual(value, afterValue) {
+			diff[name] = AuditChange{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			diff[name] = AuditChange{After: value}
+		}
+	}
+
+	return diff
+}
+
+// toJSONText encodes the state of an audit target, a nil state is empty
+func toJSONText(value interface{}) (JSONText, error) {
+	if value == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return JSONText(data), nil
+}
+
+// nullJSONText stores an empty document as NULL
+func nullJSONText(text JSONText) *string {
+	if text == "" {
+		return nil
+	}
+	value := string(text)
+	return &value
+}
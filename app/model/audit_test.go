@@ -0,0 +1,94 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditEventHash(t *testing.T) {
+	first := AuditEvent{
+		ActorUUID:  uuid.New(),
+		Action:     EventServiceUpdated,
+		TargetType: AuditTargetService,
+		TargetID:   "1",
+		Before:     `{"name":"billing"}`,
+		After:      `{"name":"payments"}`,
+		RequestID:  "req-1",
+		ClientIP:   "10.0.0.1",
+		CreatedAt:  time.Date(2024, time.May, 9, 9, 0, 0, 123456000, time.UTC),
+	}
+	first.Hash = first.computeHash()
+
+	second := first
+	second.AuditID = 2
+	second.PrevHash = first.Hash
+	second.Hash = second.computeHash()
+
+	assert.Len(t, first.Hash, 64)
+	assert.NotEqual(t, first.Hash, second.Hash)
+
+	// Case: The hash does not depend on the time zone the entry is read in
+	read := first
+	read.CreatedAt = first.CreatedAt.In(time.FixedZone("IST", 19800))
+	assert.Equal(t, first.Hash, read.computeHash())
+
+	// Case fail: A modified entry does not match it's hash
+	tampered := first
+	tampered.After = `{"name":"ledger"}`
+	assert.NotEqual(t, first.Hash, tampered.computeHash())
+
+	// Case fail: An entry chained to another entry does not match it's hash
+	reordered := second
+	reordered.PrevHash = tampered.computeHash()
+	assert.NotEqual(t, second.Hash, reordered.computeHash())
+}
+
+func TestDiffAudit(t *testing.T) {
+	diff := DiffAudit(`{"name":"billing","description":"Bills","service_id":1}`, `{"name":"payments","description":"Bills","service_id":1}`)
+	assert.Equal(t, map[string]AuditChange{
+		"name": {Before: "billing", After: "payments"},
+	}, diff)
+
+	// Case: Every field changes when the target is created or deleted
+	diff = DiffAudit("", `{"email":"john@example.com"}`)
+	assert.Equal(t, map[string]AuditChange{
+		"email": {After: "john@example.com"},
+	}, diff)
+
+	diff = DiffAudit(`{"email":"john@example.com"}`, "")
+	assert.Equal(t, map[string]AuditChange{
+		"email": {Before: "john@example.com"},
+	}, diff)
+}
+
+func TestRequestInfoFromContext(t *testing.T) {
+	assert.Equal(t, RequestInfo{}, requestInfoFromContext(context.Background()))
+
+	info := RequestInfo{RequestID: "req-1", ClientIP: "10.0.0.1"}
+	assert.Equal(t, info, requestInfoFromContext(WithRequestInfo(context.Background(), info)))
+}
+
+// TestQuerySelectAuditEvents is used to test whether the index is used to query the audit events of an actor
+func TestQuerySelectAuditEvents(t *testing.T) {
+	setupTest()
+
+	plan := queryPlan(t, querySelectAuditEvents, map[string]interface{}{
+		"actor_uuid":  uuid.New(),
+		"action":      "",
+		"target_type": "",
+		"target_id":   "",
+		"request_id":  "",
+		"from":        time.Time{},
+		"to":          time.Now(),
+		"limit":       50,
+		"offset":      0,
+	})
+
+	if !usesIndex(plan, "Index Scan") {
+		t.Error("Expected index scan but index is not being used")
+	}
+}
@@ -0,0 +1,284 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	database "github.com/ZiyanK/service-catalog-api/app/db"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	queryGetServiceIDByNameAndUserUUID = `
+	SELECT s.service_id FROM services s
+	WHERE s.name = :name AND s.user_uuid = :user_uuid`
+
+	queryInsertServiceReturnID = `
+	INSERT INTO services(name, description, user_uuid) VALUES(:name, :description, :user_uuid)
+	RETURNING service_id`
+
+	queryExportServices = `
+	SELECT s.service_id, s.name, COALESCE(s.description, '') as description, s.created_at, s.updated_at
+	FROM services s
+	WHERE s.user_uuid = :user_uuid
+	ORDER BY s.name`
+
+	queryExportServiceVersions = `
+	SELECT sv.sv_id, sv.version, COALESCE(sv.changelog, '') as changelog, sv.service_id, sv.created_at, sv.updated_at
+	FROM service_versions sv
+	JOIN services s ON s.service_id = sv.service_id
+	WHERE s.user_uuid = :user_uuid
+	ORDER BY sv.service_id, sv.created_at, sv.sv_id`
+
+	queryExportServiceVersionChanges = `
+	SELECT c.change_id, c.sv_id, c.category, c.description, c.position
+	FROM service_version_changes c
+	JOIN service_versions sv ON sv.sv_id = c.sv_id
+	JOIN services s ON s.service_id = sv.service_id
+	WHERE s.user_uuid = :user_uuid
+	ORDER BY c.sv_id, c.position`
+)
+
+// errDryRun rolls back the transaction of a dry run import
+var errDryRun = errors.New("dry run")
+
+// CatalogService is a struct used to represent a service along with all it's versions when importing or exporting the catalog
+type CatalogService struct {
+	Service
+	Versions []ServiceVersion `json:"versions"`
+
+	// Entity is the Backstage descriptor of the service, nil when the service was not imported from Backstage
+	Entity *ServiceEntity `json:"entity,omitempty"`
+}
+
+// ImportedService is a struct used to report what was imported for a service of the catalog
+type ImportedService struct {
+	Name            string   `json:"name"`
+	ServiceID       int      `json:"service_id,omitempty"`
+	Created         bool     `json:"created"`
+	VersionsCreated []string `json:"versions_created"`
+	VersionsSkipped []string `json:"versions_skipped"`
+}
+
+// ImportCatalog is used to create the services of a user along with their versions in a single transaction.
+// Services with the same name are reused and versions which already exist are skipped.
+// When dryRun is true every statement is executed and the transaction is rolled back.
+func ImportCatalog(ctx context.Context, userUUID uuid.UUID, services []CatalogService, dryRun bool) ([]ImportedService, error) {
+	var imported []ImportedService
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		imported = make([]ImportedService, 0, len(services))
+		for _, service := range services {
+			result := ImportedService{
+				Name:            service.Name,
+				VersionsCreated: []string{},
+				VersionsSkipped: []string{},
+			}
+
+			q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryGetServiceIDByNameAndUserUUID, map[string]interface{}{
+				"name":      service.Name,
+				"user_uuid": userUUID,
+			})
+			if err != nil {
+				log.Error("error building service fetch query", zap.Error(err))
+				return err
+			}
+
+			err = tx.GetContext(ctx, &result.ServiceID, q, args...)
+			if err != nil && err != sql.ErrNoRows {
+				log.Error("error querying service", zap.Error(err))
+				return err
+			}
+
+			// If service doesn't exist
+			if err == sql.ErrNoRows {
+				q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertServiceReturnID, map[string]interface{}{
+					"name":        service.Name,
+					"description": service.Description,
+					"user_uuid":   userUUID,
+				})
+				if err != nil {
+					log.Error("error building service insert query", zap.Error(err))
+					return err
+				}
+
+				err = tx.QueryRowxContext(ctx, q, args...).Scan(&result.ServiceID)
+				if database.IsUniqueViolation(err) {
+					log.Info("service with same name exists")
+					return ErrServiceExists
+				}
+				if err != nil {
+					log.Error("error inserting service", zap.Error(err))
+					return err
+				}
+
+				result.Created = true
+
+				service.ServiceID = result.ServiceID
+
+				err = recordServiceRevision(ctx, tx, result.ServiceID, nil, nil)
+				if err != nil {
+					return err
+				}
+
+				err = recordEvent(ctx, tx, userUUID, result.ServiceID, EventServiceCreated, serviceEventData(&service.Service))
+				if err != nil {
+					return err
+				}
+
+				err = recordAudit(ctx, tx, userUUID, EventServiceCreated, AuditTargetService, strconv.Itoa(result.ServiceID), nil, serviceEventData(&service.Service))
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, sv := range service.Versions {
+				sv.ServiceID = result.ServiceID
+
+				q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceVersionUsingVersion, map[string]interface{}{
+					"version":    sv.Version,
+					"service_id": result.ServiceID,
+					"user_uuid":  userUUID,
+				})
+				if err != nil {
+					log.Error("error building service version check query", zap.Error(err))
+					return err
+				}
+
+				var svCount int
+
+				err = tx.GetContext(ctx, &svCount, q, args...)
+				if err != nil && err != sql.ErrNoRows {
+					log.Error("error querying service version", zap.Error(err))
+					return err
+				}
+
+				if svCount > 0 {
+					result.VersionsSkipped = append(result.VersionsSkipped, sv.Version)
+					continue
+				}
+
+				err = insertServiceVersion(ctx, tx, &sv)
+				if err != nil {
+					return err
+				}
+
+				err = recordEvent(ctx, tx, userUUID, sv.ServiceID, EventVersionCreated, versionEventData(&sv))
+				if err != nil {
+					return err
+				}
+
+				err = recordAudit(ctx, tx, userUUID, EventVersionCreated, AuditTargetVersion, strconv.Itoa(sv.SvID), nil, versionEventData(&sv))
+				if err != nil {
+					return err
+				}
+
+				result.VersionsCreated = append(result.VersionsCreated, sv.Version)
+			}
+
+			if service.Entity != nil {
+				entity := *service.Entity
+				entity.ServiceID = result.ServiceID
+
+				err = upsertServiceEntity(ctx, tx, &entity)
+				if err != nil {
+					return err
+				}
+
+				err = recordAudit(ctx, tx, userUUID, AuditServiceEntityStored, AuditTargetService, strconv.Itoa(result.ServiceID), nil, entity)
+				if err != nil {
+					return err
+				}
+			}
+
+			imported = append(imported, result)
+		}
+
+		// A dry run executes every statement and is rolled back
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	if err == errDryRun {
+		// Nothing was persisted so there are no ids to return
+		for i := range imported {
+			if imported[i].Created {
+				imported[i].ServiceID = 0
+			}
+		}
+		return imported, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return imported, nil
+}
+
+// ExportCatalog is used to fetch all the services of a user along with their versions, changes and Backstage entities
+func ExportCatalog(ctx context.Context, userUUID uuid.UUID) ([]CatalogService, error) {
+	params := map[string]interface{}{
+		"user_uuid": userUUID,
+	}
+
+	var services []Service
+
+	err := db.NamedSelectContext(ctx, &services, queryExportServices, params)
+	if err != nil {
+		log.Error("Error while exporting services", zap.Error(err))
+		return nil, err
+	}
+
+	var versions []ServiceVersion
+
+	err = db.NamedSelectContext(ctx, &versions, queryExportServiceVersions, params)
+	if err != nil {
+		log.Error("Error while exporting service versions", zap.Error(err))
+		return nil, err
+	}
+
+	var changes []ServiceVersionChange
+
+	err = db.NamedSelectContext(ctx, &changes, queryExportServiceVersionChanges, params)
+	if err != nil {
+		log.Error("Error while exporting service version changes", zap.Error(err))
+		return nil, err
+	}
+
+	entities, err := getServiceEntities(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	changesBySvID := make(map[int][]ServiceVersionChange)
+	for _, change := range changes {
+		changesBySvID[change.SvID] = append(changesBySvID[change.SvID], change)
+	}
+
+	versionsByServiceID := make(map[int][]ServiceVersion)
+	for _, sv := range versions {
+		sv.Changes = changesBySvID[sv.SvID]
+		versionsByServiceID[sv.ServiceID] = append(versionsByServiceID[sv.ServiceID], sv)
+	}
+
+	catalog := make([]CatalogService, 0, len(services))
+	for _, service := range services {
+		exported := CatalogService{
+			Service:  service,
+			Versions: versionsByServiceID[service.ServiceID],
+		}
+		if entity, ok := entities[service.ServiceID]; ok {
+			exported.Entity = &entity
+		}
+
+		catalog = append(catalog, exported)
+	}
+
+	return catalog, nil
+}
@@ -0,0 +1,17 @@
+package model
+
+import "testing"
+
+// TestQueryGetServiceIDByNameAndUserUUID is used to test whether the index is used to query the service id by it's name and user uuid
+func TestQueryGetServiceIDByNameAndUserUUID(t *testing.T) {
+	setupTest()
+
+	plan := queryPlan(t, queryGetServiceIDByNameAndUserUUID, map[string]interface{}{
+		"name":      "backend",
+		"user_uuid": userUUID,
+	})
+
+	if !usesIndex(plan, "Index Scan") {
+		t.Error("Expected index scan but index is not being used")
+	}
+}
@@ -0,0 +1,44 @@
+package model
+
+// Kind classifies the errors returned by the model, the APIs map it to their status codes
+type Kind int
+
+const (
+	// KindNotFound is returned when a resource of the user does not exist
+	KindNotFound Kind = iota + 1
+	// KindConflict is returned when a resource conflicts with an existing one
+	KindConflict
+	// KindPrecondition is returned when a resource changed since the version a write is conditional on
+	KindPrecondition
+)
+
+// Error is an error returned by the model with a machine-readable code.
+// The errors are sentinels, they are compared with errors.Is.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Errors returned by the model
+var (
+	ErrServiceNotFound         = &Error{Kind: KindNotFound, Code: "service_not_found", Message: "service does not exist"}
+	ErrServiceExists           = &Error{Kind: KindConflict, Code: "service_exists", Message: "service with same name exists"}
+	ErrServiceModified         = &Error{Kind: KindPrecondition, Code: "service_modified", Message: "service was modified since it was fetched"}
+	ErrServiceRevisionNotFound = &Error{Kind: KindNotFound, Code: "service_revision_not_found", Message: "service revision does not exist"}
+	ErrServiceVersionNotFound  = &Error{Kind: KindNotFound, Code: "service_version_not_found", Message: "service version does not exist"}
+	ErrServiceVersionExists    = &Error{Kind: KindConflict, Code: "service_version_exists", Message: "service with same version exists"}
+	ErrArtifactNotFound        = &Error{Kind: KindNotFound, Code: "artifact_not_found", Message: "artifact does not exist"}
+	ErrArtifactExists          = &Error{Kind: KindConflict, Code: "artifact_exists", Message: "artifact with same digest exists"}
+	ErrEmailExists             = &Error{Kind: KindConflict, Code: "email_exists", Message: "user with this email exists"}
+	ErrUserNotFound            = &Error{Kind: KindNotFound, Code: "user_not_found", Message: "user does not exist"}
+	ErrTeamNotFound            = &Error{Kind: KindNotFound, Code: "team_not_found", Message: "team does not exist"}
+	ErrTeamMemberExists        = &Error{Kind: KindConflict, Code: "team_member_exists", Message: "user is already a member of the team"}
+	ErrTeamMemberNotFound      = &Error{Kind: KindNotFound, Code: "team_member_not_found", Message: "team member does not exist"}
+	ErrWebhookNotFound         = &Error{Kind: KindNotFound, Code: "webhook_not_found", Message: "webhook does not exist"}
+	ErrWebhookDeliveryNotFound = &Error{Kind: KindNotFound, Code: "webhook_delivery_not_found", Message: "webhook delivery does not exist"}
+)
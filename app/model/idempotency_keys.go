@@ -0,0 +1,185 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	// An expired key or a key whose request never completed is claimed again
+	queryDeleteExpiredIdempotencyKey = `
+	DELETE FROM idempotency_keys
+	WHERE user_uuid = :user_uuid AND idempotency_key = :idempotency_key
+		AND (created_at < :expired_before OR (status_code IS NULL AND created_at < :abandoned_before))`
+
+	queryInsertIdempotencyKey = `
+	INSERT INTO idempotency_keys(user_uuid, idempotency_key, method, path, request_hash, created_at)
+	VALUES(:user_uuid, :idempotency_key, :method, :path, :request_hash, :created_at)
+	ON CONFLICT (user_uuid, idempotency_key) DO NOTHING`
+
+	queryGetIdempotencyKey = `
+	SELECT k.user_uuid, k.idempotency_key, k.method, k.path, k.request_hash, COALESCE(k.status_code, 0) as status_code,
+		COALESCE(k.content_type, '') as content_type, COALESCE(k.response_body, '') as response_body, k.created_at
+	FROM idempotency_keys k
+	WHERE k.user_uuid = :user_uuid AND k.idempotency_key = :idempotency_key`
+
+	queryUpdateIdempotencyKeyResponse = `
+	UPDATE idempotency_keys SET status_code = :status_code, content_type = :content_type, response_body = :response_body
+	WHERE user_uuid = :user_uuid AND idempotency_key = :idempotency_key`
+
+	queryDeletePendingIdempotencyKey = `
+	DELETE FROM idempotency_keys
+	WHERE user_uuid = :user_uuid AND idempotency_key = :idempotency_key AND status_code IS NULL`
+
+	queryDeleteExpiredIdempotencyKeys = `DELETE FROM idempotency_keys WHERE created_at < :expired_before`
+)
+
+// IdempotencyKey is a struct used to represent the `idempotency_keys` table in the database.
+// The key is claimed by the first request using it and stores it's response once it completes.
+type IdempotencyKey struct {
+	UserUUID    uuid.UUID `db:"user_uuid" json:"-"`
+	Key         string    `db:"idempotency_key" json:"idempotency_key"`
+	Method      string    `db:"method" json:"method"`
+	Path        string    `db:"path" json:"path"`
+	RequestHash string    `db:"request_hash" json:"request_hash"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+
+	// StatusCode is 0 while the request is in progress
+	StatusCode   int    `db:"status_code" json:"status_code"`
+	ContentType  string `db:"content_type" json:"content_type"`
+	ResponseBody []byte `db:"response_body" json:"-"`
+}
+
+// ClaimIdempotencyKey claims the key for a request. When the key is already used the stored key is returned instead.
+// Keys older than ttl are expired and keys whose request did not complete within abandonAfter are claimed again.
+func ClaimIdempotencyKey(ctx context.Context, key *IdempotencyKey, ttl, abandonAfter time.Duration) (*IdempotencyKey, bool, error) {
+	var (
+		claimed *IdempotencyKey
+		created bool
+	)
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		now := time.Now().UTC()
+		key.CreatedAt = now
+
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryDeleteExpiredIdempotencyKey, map[string]interface{}{
+			"user_uuid":        key.UserUUID,
+			"idempotency_key":  key.Key,
+			"expired_before":   now.Add(-ttl),
+			"abandoned_before": now.Add(-abandonAfter),
+		})
+		if err != nil {
+			log.Error("error building idempotency key delete query", zap.Error(err))
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("error deleting expired idempotency key", zap.Error(err))
+			return err
+		}
+
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertIdempotencyKey, key)
+		if err != nil {
+			log.Error("error building idempotency key insert query", zap.Error(err))
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("error inserting idempotency key", zap.Error(err))
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.Error("Error while getting no. of rows affected", zap.Error(err))
+			return err
+		}
+
+		if rowsAffected == 1 {
+			claimed, created = key, true
+			return nil
+		}
+
+		// The key is used by another request
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryGetIdempotencyKey, map[string]interface{}{
+			"user_uuid":       key.UserUUID,
+			"idempotency_key": key.Key,
+		})
+		if err != nil {
+			log.Error("error building idempotency key fetch query", zap.Error(err))
+			return err
+		}
+
+		var stored IdempotencyKey
+		err = tx.GetContext(ctx, &stored, q, args...)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				// The request using the key was released in the meantime, it is reported as in progress so the client retries
+				pending := *key
+				claimed = &pending
+				return nil
+			}
+			log.Error("error fetching idempotency key", zap.Error(err))
+			return err
+		}
+
+		claimed = &stored
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return claimed, created, nil
+}
+
+// SaveIdempotentResponse stores the response of the request which claimed the key
+func (key *IdempotencyKey) SaveIdempotentResponse(ctx context.Context) error {
+	_, err := db.NamedExecContext(ctx, queryUpdateIdempotencyKeyResponse, map[string]interface{}{
+		"user_uuid":       key.UserUUID,
+		"idempotency_key": key.Key,
+		"status_code":     key.StatusCode,
+		"content_type":    key.ContentType,
+		"response_body":   key.ResponseBody,
+	})
+	if err != nil {
+		log.Error("Error while saving idempotent response", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes a key whose request did not complete so that it can be retried
+func (key *IdempotencyKey) ReleaseIdempotencyKey(ctx context.Context) error {
+	_, err := db.NamedExecContext(ctx, queryDeletePendingIdempotencyKey, map[string]interface{}{
+		"user_uuid":       key.UserUUID,
+		"idempotency_key": key.Key,
+	})
+	if err != nil {
+		log.Error("Error while releasing idempotency key", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes the keys created before the given time, it returns the number of keys deleted
+func DeleteExpiredIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	result, err := db.NamedExecContext(ctx, queryDeleteExpiredIdempotencyKeys, map[string]interface{}{
+		"expired_before": expiredBefore.UTC(),
+	})
+	if err != nil {
+		log.Error("Error while deleting expired idempotency keys", zap.Error(err))
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
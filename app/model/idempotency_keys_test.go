@@ -0,0 +1,35 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestQueryGetIdempotencyKey is used to test whether the primary key is used to fetch an idempotency key
+func TestQueryGetIdempotencyKey(t *testing.T) {
+	setupTest()
+
+	plan := queryPlan(t, queryGetIdempotencyKey, map[string]interface{}{
+		"user_uuid":       uuid.New(),
+		"idempotency_key": "retry-1",
+	})
+
+	if !usesIndex(plan, "Index Scan") {
+		t.Error("Expected index scan but index is not being used")
+	}
+}
+
+// TestQueryDeleteExpiredIdempotencyKeys is used to test whether the index is used to find the expired idempotency keys
+func TestQueryDeleteExpiredIdempotencyKeys(t *testing.T) {
+	setupTest()
+
+	plan := queryPlan(t, queryDeleteExpiredIdempotencyKeys, map[string]interface{}{
+		"expired_before": time.Now().Add(-24 * time.Hour),
+	})
+
+	if !usesIndex(plan, "Index Scan", "Bitmap Index Scan") {
+		t.Error("Expected index scan but index is not being used")
+	}
+}
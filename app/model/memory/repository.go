@@ -0,0 +1,707 @@
+// Package memory is an in-memory implementation of the repositories of the model.
+// It behaves as the database does, so the handlers can be tested without one.
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/google/uuid"
+)
+
+// Repository stores the users, the services and their versions in memory.
+// The events and the audit log of the changes are not recorded.
+type Repository struct {
+	mu sync.RWMutex
+
+	users    map[uuid.UUID]model.User
+	services map[int]model.Service
+	versions map[int]model.ServiceVersion
+	history  map[int]*serviceHistory
+
+	lastServiceID int
+	lastSvID      int
+	lastChangeID  int
+
+	// now returns the time of the changes
+	now func() time.Time
+}
+
+// serviceHistory is the history of a service, it is kept after the service is deleted
+type serviceHistory struct {
+	userUUID  uuid.UUID
+	revisions []model.ServiceRevision
+	versions  []model.ServiceVersionHistory
+}
+
+var (
+	_ model.UserRepository    = (*Repository)(nil)
+	_ model.ServiceRepository = (*Repository)(nil)
+	_ model.VersionRepository = (*Repository)(nil)
+)
+
+// NewRepository returns an empty repository
+func NewRepository() *Repository {
+	return &Repository{
+		users:    make(map[uuid.UUID]model.User),
+		services: make(map[int]model.Service),
+		versions: make(map[int]model.ServiceVersion),
+		history:  make(map[int]*serviceHistory),
+		now: func() time.Time {
+			// Timestamps are stored with a microsecond precision as in the database
+			return time.Now().UTC().Truncate(time.Microsecond)
+		},
+	}
+}
+
+// CreateUser is used to create a new user
+func (r *Repository) CreateUser(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.userByEmail(user.Email); ok {
+		return model.ErrEmailExists
+	}
+
+	now := r.now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	r.users[user.UserUUID] = *user
+
+	return nil
+}
+
+// GetUserByEmail is used to fetch a user using the email
+func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.userByEmail(email)
+	if !ok {
+		return nil, model.ErrUserNotFound
+	}
+
+	return &user, nil
+}
+
+// GetUserByID is used to fetch a user using the userUUID, the password is not returned
+func (r *Repository) GetUserByID(ctx context.Context, userUUID uuid.UUID) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[userUUID]
+	if !ok {
+		return nil, model.ErrUserNotFound
+	}
+
+	user.Password = ""
+	return &user, nil
+}
+
+// UpdateUser is used to update the user email
+func (r *Repository) UpdateUser(ctx context.Context, updatedEmail string, userUUID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.userByEmail(updatedEmail); ok {
+		return model.ErrEmailExists
+	}
+
+	user, ok := r.users[userUUID]
+	if !ok {
+		return model.ErrUserNotFound
+	}
+
+	user.Email = updatedEmail
+	user.UpdatedAt = r.now()
+	r.users[userUUID] = user
+
+	return nil
+}
+
+func (r *Repository) userByEmail(email string) (model.User, bool) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, true
+		}
+	}
+	return model.User{}, false
+}
+
+// CreateService is used to create a new service for a user
+func (r *Repository) CreateService(ctx context.Context, service *model.Service) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(service.UserUUID, service.Name, 0) {
+		return model.ErrServiceExists
+	}
+
+	r.lastServiceID++
+	now := r.now()
+
+	stored := model.Service{
+		ServiceID:   r.lastServiceID,
+		Name:        service.Name,
+		Description: service.Description,
+		UserUUID:    service.UserUUID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		RowVersion:  1,
+	}
+	r.services[stored.ServiceID] = stored
+	r.history[stored.ServiceID] = &serviceHistory{userUUID: stored.UserUUID}
+	r.recordRevision(stored.ServiceID, nil, nil)
+
+	service.ServiceID = stored.ServiceID
+	service.RowVersion = stored.RowVersion
+	return nil
+}
+
+// GetServices is used to fetch the services of a user whose name contains serviceName, by creation time
+func (r *Repository) GetServices(ctx context.Context, userUUID uuid.UUID, limit, offset int, serviceName, orderBy string) ([]model.Service, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var matching []model.Service
+	for _, service := range r.services {
+		if service.UserUUID == userUUID && strings.Contains(service.Name, serviceName) {
+			service.VersionsCount = r.countVersions(service.ServiceID)
+			matching = append(matching, service)
+		}
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		a, b := matching[i], matching[j]
+		if orderBy == "DESC" {
+			a, b = b, a
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ServiceID < b.ServiceID
+	})
+
+	if offset >= len(matching) {
+		return nil, nil
+	}
+	matching = matching[offset:]
+	if len(matching) > limit {
+		matching = matching[:limit]
+	}
+
+	return matching, nil
+}
+
+// GetService is used to get a particular service with all it's versions, one row per version
+func (r *Repository) GetService(ctx context.Context, serviceID int, userUUID uuid.UUID) ([]model.ServiceWithVersions, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	service, ok := r.services[serviceID]
+	if !ok || service.UserUUID != userUUID {
+		return nil, nil
+	}
+
+	row := model.ServiceWithVersions{
+		ServiceID:   service.ServiceID,
+		Name:        service.Name,
+		Description: service.Description,
+		RowVersion:  service.RowVersion,
+	}
+
+	versions := r.versionsOf(serviceID)
+	if len(versions) == 0 {
+		return []model.ServiceWithVersions{row}, nil
+	}
+
+	rows := make([]model.ServiceWithVersions, 0, len(versions))
+	for _, sv := range versions {
+		row.SvID = sv.SvID
+		row.Version = sv.Version
+		row.Changelog = sv.Changelog
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// GetServiceByID is used to fetch a service of a user along with it's number of versions
+func (r *Repository) GetServiceByID(ctx context.Context, serviceID int, userUUID uuid.UUID) (*model.Service, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	service, ok := r.services[serviceID]
+	if !ok || service.UserUUID != userUUID {
+		return nil, model.ErrServiceNotFound
+	}
+
+	service.VersionsCount = r.countVersions(serviceID)
+	return &service, nil
+}
+
+// ServiceExists is used to check if a service exists for a user
+func (r *Repository) ServiceExists(ctx context.Context, serviceID int, userUUID uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	service, ok := r.services[serviceID]
+	return ok && service.UserUUID == userUUID, nil
+}
+
+// UpdateService is used to update the name and description of a service, it only applies to the given row version unless it is 0
+func (r *Repository) UpdateService(ctx context.Context, service *model.Service) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.serviceOf(service.ServiceID, service.UserUUID, service.RowVersion)
+	if err != nil {
+		return err
+	}
+
+	if r.nameTaken(service.UserUUID, service.Name, service.ServiceID) {
+		return model.ErrServiceExists
+	}
+
+	stored.Name = service.Name
+	stored.Description = service.Description
+	stored.RowVersion++
+	stored.UpdatedAt = r.now()
+	r.services[stored.ServiceID] = stored
+	r.recordRevision(stored.ServiceID, nil, nil)
+
+	service.RowVersion = stored.RowVersion
+	return nil
+}
+
+// DeleteService is used to delete a service and all it's versions, it only applies to the given row version unless it is 0
+func (r *Repository) DeleteService(ctx context.Context, service *model.Service) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.serviceOf(service.ServiceID, service.UserUUID, service.RowVersion)
+	if err != nil {
+		return err
+	}
+
+	for svID, sv := range r.versions {
+		if sv.ServiceID == service.ServiceID {
+			delete(r.versions, svID)
+		}
+	}
+	delete(r.services, service.ServiceID)
+
+	// The history of the versions removed along with their service is closed without removing them in a revision
+	at := r.now()
+	history := r.history[service.ServiceID]
+	for i := range history.revisions {
+		if history.revisions[i].ValidTo == nil {
+			history.revisions[i].ValidTo = &at
+		}
+	}
+	for i := range history.versions {
+		if history.versions[i].ValidTo == nil {
+			history.versions[i].ValidTo = &at
+		}
+	}
+
+	return nil
+}
+
+// GetServiceAsOf is used to get a service with all it's versions as they were at the given time.
+// It is empty when the service did not exist at that time.
+func (r *Repository) GetServiceAsOf(ctx context.Context, serviceID int, userUUID uuid.UUID, asOf time.Time) ([]model.ServiceWithVersions, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history, ok := r.history[serviceID]
+	if !ok || history.userUUID != userUUID {
+		return nil, nil
+	}
+
+	validAt := func(from time.Time, to *time.Time) bool {
+		return !from.After(asOf) && (to == nil || to.After(asOf))
+	}
+
+	for _, revision := range history.revisions {
+		if !validAt(revision.ValidFrom, revision.ValidTo) {
+			continue
+		}
+
+		row := model.ServiceWithVersions{
+			ServiceID:   serviceID,
+			Name:        revision.Name,
+			Description: revision.Description,
+			RowVersion:  revision.Revision,
+		}
+
+		var rows []model.ServiceWithVersions
+		for _, version := range history.versions {
+			if validAt(version.ValidFrom, version.ValidTo) {
+				row.SvID = version.SvID
+				row.Version = version.Version
+				row.Changelog = version.Changelog
+				rows = append(rows, row)
+			}
+		}
+		if len(rows) == 0 {
+			rows = append(rows, row)
+		}
+
+		return rows, nil
+	}
+
+	return nil, nil
+}
+
+// GetServiceHistory is used to fetch the revisions of a service, each with the changes since the previous revision
+func (r *Repository) GetServiceHistory(ctx context.Context, serviceID int, userUUID uuid.UUID) ([]model.ServiceRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history, ok := r.history[serviceID]
+	if !ok || history.userUUID != userUUID || len(history.revisions) == 0 {
+		return nil, model.ErrServiceNotFound
+	}
+
+	revisions := append([]model.ServiceRevision(nil), history.revisions...)
+	model.DiffServiceRevisions(revisions, history.versions)
+
+	return revisions, nil
+}
+
+// RevertService is used to restore the name, the description and the versions a service had in a previous revision.
+// The revert is a new revision, it only applies to the given row version of the service unless it is 0.
+func (r *Repository) RevertService(ctx context.Context, service *model.Service, revision int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.serviceOf(service.ServiceID, service.UserUUID, service.RowVersion)
+	if err != nil {
+		return err
+	}
+
+	history := r.history[service.ServiceID]
+
+	var target *model.ServiceRevision
+	for i := range history.revisions {
+		if history.revisions[i].Revision == revision {
+			target = &history.revisions[i]
+		}
+	}
+	if target == nil {
+		return model.ErrServiceRevisionNotFound
+	}
+
+	if r.nameTaken(service.UserUUID, target.Name, service.ServiceID) {
+		return model.ErrServiceExists
+	}
+
+	keep := make(map[string]model.ServiceVersionHistory)
+	for _, version := range history.versions {
+		if version.AddedIn <= revision && (version.RemovedIn == 0 || version.RemovedIn > revision) {
+			keep[version.Version] = version
+		}
+	}
+
+	// Deleting the versions added since the revision
+	var removed []int
+	for _, sv := range r.versionsOf(service.ServiceID) {
+		if _, ok := keep[sv.Version]; ok {
+			delete(keep, sv.Version)
+			continue
+		}
+
+		delete(r.versions, sv.SvID)
+		removed = append(removed, sv.SvID)
+	}
+
+	// Creating the versions removed since the revision, the versions left in keep
+	var added []*model.ServiceVersion
+	for _, version := range history.versions {
+		if kept, ok := keep[version.Version]; !ok || kept.SvID != version.SvID {
+			continue
+		}
+
+		sv := &model.ServiceVersion{
+			Version:   version.Version,
+			Changelog: version.Changelog,
+			ServiceID: service.ServiceID,
+			CreatedAt: version.ValidFrom,
+		}
+		r.insertVersion(sv)
+		added = append(added, sv)
+	}
+
+	stored.Name = target.Name
+	stored.Description = target.Description
+	stored.RowVersion++
+	stored.UpdatedAt = r.now()
+	r.services[stored.ServiceID] = stored
+	r.recordRevision(stored.ServiceID, added, removed)
+
+	service.Name = stored.Name
+	service.Description = stored.Description
+	service.RowVersion = stored.RowVersion
+	return nil
+}
+
+// CreateServiceVersion is used to create a new service version for a given service
+func (r *Repository) CreateServiceVersion(ctx context.Context, userUUID uuid.UUID, sv *model.ServiceVersion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.serviceOf(sv.ServiceID, userUUID, 0); err != nil {
+		return err
+	}
+
+	if r.versionExists(sv.ServiceID, sv.Version) {
+		return model.ErrServiceVersionExists
+	}
+
+	r.addVersion(sv)
+	return nil
+}
+
+// DeleteServiceVersion is used to delete a particular service version for a given service
+func (r *Repository) DeleteServiceVersion(ctx context.Context, userUUID uuid.UUID, serviceID, svID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sv, ok := r.versions[svID]
+	if !ok || sv.ServiceID != serviceID {
+		return model.ErrServiceVersionNotFound
+	}
+	if _, err := r.serviceOf(serviceID, userUUID, 0); err != nil {
+		return model.ErrServiceVersionNotFound
+	}
+
+	delete(r.versions, svID)
+	r.bumpRowVersion(serviceID)
+	r.recordRevision(serviceID, nil, []int{svID})
+
+	return nil
+}
+
+// GetServiceVersions is used to fetch all the versions of a given service along with their changes, the latest first
+func (r *Repository) GetServiceVersions(ctx context.Context, serviceID int, userUUID uuid.UUID) ([]model.ServiceVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, err := r.serviceOf(serviceID, userUUID, 0); err != nil {
+		return nil, nil
+	}
+
+	return r.latestVersionsOf(serviceID), nil
+}
+
+// GetVersionsOfServices is used to fetch the versions of several services of a user at once along with their changes.
+// The versions are grouped by service id, services without versions are not present in the map.
+func (r *Repository) GetVersionsOfServices(ctx context.Context, serviceIDs []int, userUUID uuid.UUID) (map[int][]model.ServiceVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versionsByServiceID := make(map[int][]model.ServiceVersion)
+	for _, serviceID := range serviceIDs {
+		if _, err := r.serviceOf(serviceID, userUUID, 0); err != nil {
+			continue
+		}
+		if versions := r.latestVersionsOf(serviceID); len(versions) > 0 {
+			versionsByServiceID[serviceID] = versions
+		}
+	}
+
+	return versionsByServiceID, nil
+}
+
+// ImportServiceVersions is used to create multiple versions for a given service at once.
+// It returns the versions that were created and the versions that were skipped as they already exist.
+func (r *Repository) ImportServiceVersions(ctx context.Context, userUUID uuid.UUID, serviceID int, versions []model.ServiceVersion) ([]string, []string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.serviceOf(serviceID, userUUID, 0); err != nil {
+		return nil, nil, err
+	}
+
+	var created, skipped []string
+
+	for _, sv := range versions {
+		sv.ServiceID = serviceID
+
+		if r.versionExists(serviceID, sv.Version) {
+			skipped = append(skipped, sv.Version)
+			continue
+		}
+
+		r.addVersion(&sv)
+		created = append(created, sv.Version)
+	}
+
+	return created, skipped, nil
+}
+
+// serviceOf returns the service of a user, the row version is checked unless it is 0
+func (r *Repository) serviceOf(serviceID int, userUUID uuid.UUID, rowVersion int64) (model.Service, error) {
+	service, ok := r.services[serviceID]
+	if !ok || service.UserUUID != userUUID {
+		return model.Service{}, model.ErrServiceNotFound
+	}
+	if rowVersion != 0 && service.RowVersion != rowVersion {
+		return model.Service{}, model.ErrServiceModified
+	}
+	return service, nil
+}
+
+// nameTaken reports whether another service of the user has the name, the names of the services of a user are unique
+func (r *Repository) nameTaken(userUUID uuid.UUID, name string, serviceID int) bool {
+	for _, existing := range r.services {
+		if existing.UserUUID == userUUID && existing.Name == name && existing.ServiceID != serviceID {
+			return true
+		}
+	}
+	return false
+}
+
+// versionsOf returns the versions of a service by id
+func (r *Repository) versionsOf(serviceID int) []model.ServiceVersion {
+	var versions []model.ServiceVersion
+	for _, sv := range r.versions {
+		if sv.ServiceID == serviceID {
+			versions = append(versions, sv)
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].SvID < versions[j].SvID
+	})
+	return versions
+}
+
+// latestVersionsOf returns copies of the versions of a service, the latest first
+func (r *Repository) latestVersionsOf(serviceID int) []model.ServiceVersion {
+	versions := r.versionsOf(serviceID)
+	for i := range versions {
+		versions[i].Changes = append([]model.ServiceVersionChange(nil), versions[i].Changes...)
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		if !versions[i].CreatedAt.Equal(versions[j].CreatedAt) {
+			return versions[i].CreatedAt.After(versions[j].CreatedAt)
+		}
+		return versions[i].SvID > versions[j].SvID
+	})
+	return versions
+}
+
+func (r *Repository) countVersions(serviceID int) int {
+	count := 0
+	for _, sv := range r.versions {
+		if sv.ServiceID == serviceID {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *Repository) versionExists(serviceID int, version string) bool {
+	for _, sv := range r.versions {
+		if sv.ServiceID == serviceID && sv.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// addVersion inserts the version, the service gets a new revision with it
+func (r *Repository) addVersion(sv *model.ServiceVersion) {
+	r.insertVersion(sv)
+	r.bumpRowVersion(sv.ServiceID)
+	r.recordRevision(sv.ServiceID, []*model.ServiceVersion{sv}, nil)
+}
+
+// insertVersion inserts the version along with it's changes without changing the revision of the service
+func (r *Repository) insertVersion(sv *model.ServiceVersion) {
+	r.lastSvID++
+	sv.SvID = r.lastSvID
+
+	now := r.now()
+	// The creation time is only set when importing versions released in the past
+	if sv.CreatedAt.IsZero() {
+		sv.CreatedAt = now
+	}
+	sv.UpdatedAt = now
+
+	changes := make([]model.ServiceVersionChange, len(sv.Changes))
+	for i := range sv.Changes {
+		r.lastChangeID++
+		sv.Changes[i].ChangeID = r.lastChangeID
+		sv.Changes[i].SvID = sv.SvID
+		sv.Changes[i].Position = i
+		changes[i] = sv.Changes[i]
+	}
+
+	stored := *sv
+	stored.Changes = changes
+	r.versions[sv.SvID] = stored
+}
+
+// bumpRowVersion increments the row version of a service when it's versions change
+func (r *Repository) bumpRowVersion(serviceID int) {
+	service := r.services[serviceID]
+	service.RowVersion++
+	r.services[serviceID] = service
+}
+
+// recordRevision records the current state of the service as a new revision.
+// The service versions added and removed by the change are recorded in the history of the versions.
+func (r *Repository) recordRevision(serviceID int, added []*model.ServiceVersion, removed []int) {
+	at := r.now()
+	service := r.services[serviceID]
+	history := r.history[serviceID]
+
+	for i := range history.revisions {
+		if history.revisions[i].ValidTo == nil {
+			history.revisions[i].ValidTo = &at
+		}
+	}
+
+	history.revisions = append(history.revisions, model.ServiceRevision{
+		ServiceID:   serviceID,
+		Revision:    service.RowVersion,
+		Name:        service.Name,
+		Description: service.Description,
+		ValidFrom:   at,
+	})
+
+	for _, sv := range added {
+		history.versions = append(history.versions, model.ServiceVersionHistory{
+			SvID:      sv.SvID,
+			ServiceID: serviceID,
+			Version:   sv.Version,
+			Changelog: sv.Changelog,
+			AddedIn:   service.RowVersion,
+			ValidFrom: at,
+		})
+	}
+
+	for _, svID := range removed {
+		for i := range history.versions {
+			if history.versions[i].SvID == svID && history.versions[i].ValidTo == nil {
+				history.versions[i].RemovedIn = service.RowVersion
+				history.versions[i].ValidTo = &at
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var userUUID = uuid.MustParse("d90f9b49-dcd9-4feb-8250-d013098e45ee")
+
+func TestUsers(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository()
+
+	err := repo.CreateUser(ctx, &model.User{UserUUID: userUUID, Email: "test@gmail.com", Password: "hash"})
+	assert.NoError(t, err)
+
+	// Case fail: Email already used
+	err = repo.CreateUser(ctx, &model.User{UserUUID: uuid.New(), Email: "test@gmail.com"})
+	assert.Equal(t, model.ErrEmailExists, err)
+
+	user, err := repo.GetUserByEmail(ctx, "test@gmail.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", user.Password)
+
+	err = repo.UpdateUser(ctx, "updated@gmail.com", userUUID)
+	assert.NoError(t, err)
+
+	user, err = repo.GetUserByID(ctx, userUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, "updated@gmail.com", user.Email)
+	assert.Empty(t, user.Password)
+
+	// Case fail: User does not exist
+	_, err = repo.GetUserByEmail(ctx, "test@gmail.com")
+	assert.Equal(t, model.ErrUserNotFound, err)
+}
+
+func TestServices(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository()
+
+	backend := &model.Service{Name: "backend", Description: "this service has the backend", UserUUID: userUUID}
+	assert.NoError(t, repo.CreateService(ctx, backend))
+	frontend := &model.Service{Name: "frontend", UserUUID: userUUID}
+	assert.NoError(t, repo.CreateService(ctx, frontend))
+	assert.NoError(t, repo.CreateService(ctx, &model.Service{Name: "backend", UserUUID: uuid.New()}))
+
+	// Case fail: Service with same name
+	assert.Equal(t, model.ErrServiceExists, repo.CreateService(ctx, &model.Service{Name: "backend", UserUUID: userUUID}))
+
+	services, err := repo.GetServices(ctx, userUUID, 10, 0, "", "DESC")
+	assert.NoError(t, err)
+	if assert.Len(t, services, 2) {
+		assert.Equal(t, "frontend", services[0].Name)
+		assert.Equal(t, "backend", services[1].Name)
+	}
+
+	services, err = repo.GetServices(ctx, userUUID, 1, 1, "end", "ASC")
+	assert.NoError(t, err)
+	if assert.Len(t, services, 1) {
+		assert.Equal(t, "frontend", services[0].Name)
+	}
+
+	// Case: The update applies to the given row version
+	backend.Name = "api"
+	assert.NoError(t, repo.UpdateService(ctx, backend))
+	assert.Equal(t, int64(2), backend.RowVersion)
+
+	backend.RowVersion = 1
+	assert.Equal(t, model.ErrServiceModified, repo.UpdateService(ctx, backend))
+
+	// Case fail: Renamed to the name of another service
+	assert.Equal(t, model.ErrServiceExists, repo.UpdateService(ctx, &model.Service{ServiceID: backend.ServiceID, Name: "frontend", UserUUID: userUUID}))
+
+	// Case fail: Service of another user
+	_, err = repo.GetServiceByID(ctx, backend.ServiceID, uuid.New())
+	assert.Equal(t, model.ErrServiceNotFound, err)
+
+	assert.NoError(t, repo.DeleteService(ctx, &model.Service{ServiceID: backend.ServiceID, UserUUID: userUUID}))
+
+	exists, err := repo.ServiceExists(ctx, backend.ServiceID, userUUID)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestVersions(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository()
+
+	service := &model.Service{Name: "backend", UserUUID: userUUID}
+	assert.NoError(t, repo.CreateService(ctx, service))
+
+	sv := &model.ServiceVersion{
+		ServiceID: service.ServiceID,
+		Version:   "1.0.0",
+		Changes:   []model.ServiceVersionChange{{Category: "added", Description: "First release"}},
+	}
+	assert.NoError(t, repo.CreateServiceVersion(ctx, userUUID, sv))
+
+	// Case fail: Version already exists
+	assert.Equal(t, model.ErrServiceVersionExists, repo.CreateServiceVersion(ctx, userUUID, &model.ServiceVersion{ServiceID: service.ServiceID, Version: "1.0.0"}))
+
+	created, skipped, err := repo.ImportServiceVersions(ctx, userUUID, service.ServiceID, []model.ServiceVersion{
+		{Version: "1.0.0"},
+		{Version: "0.9.0", CreatedAt: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0.9.0"}, created)
+	assert.Equal(t, []string{"1.0.0"}, skipped)
+
+	// Case: The latest versions come first
+	versions, err := repo.GetServiceVersions(ctx, service.ServiceID, userUUID)
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 2) {
+		assert.Equal(t, "1.0.0", versions[0].Version)
+		assert.Equal(t, "First release", versions[0].Changes[0].Description)
+		assert.Equal(t, "0.9.0", versions[1].Version)
+	}
+
+	// Case: The versions are part of the service's row version
+	current, err := repo.GetServiceByID(ctx, service.ServiceID, userUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, current.VersionsCount)
+	assert.Equal(t, int64(3), current.RowVersion)
+
+	// Case fail: Version of another service
+	assert.Equal(t, model.ErrServiceVersionNotFound, repo.DeleteServiceVersion(ctx, userUUID, service.ServiceID+1, sv.SvID))
+
+	assert.NoError(t, repo.DeleteServiceVersion(ctx, userUUID, service.ServiceID, sv.SvID))
+
+	byService, err := repo.GetVersionsOfServices(ctx, []int{service.ServiceID}, userUUID)
+	assert.NoError(t, err)
+	assert.Len(t, byService[service.ServiceID], 1)
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository()
+
+	// The clock advances by a second at every change
+	clock := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	service := &model.Service{Name: "backend", UserUUID: userUUID}
+	assert.NoError(t, repo.CreateService(ctx, service))
+	assert.NoError(t, repo.CreateServiceVersion(ctx, userUUID, &model.ServiceVersion{ServiceID: service.ServiceID, Version: "1.0.0"}))
+	created := clock
+
+	// The service is updated whatever it's row version
+	service.Name = "api"
+	service.RowVersion = 0
+	assert.NoError(t, repo.UpdateService(ctx, service))
+	assert.NoError(t, repo.CreateServiceVersion(ctx, userUUID, &model.ServiceVersion{ServiceID: service.ServiceID, Version: "2.0.0"}))
+
+	revisions, err := repo.GetServiceHistory(ctx, service.ServiceID, userUUID)
+	assert.NoError(t, err)
+	if assert.Len(t, revisions, 4) {
+		assert.Equal(t, []string{"1.0.0"}, revisions[1].VersionsAdded)
+		assert.Equal(t, model.AuditChange{Before: "backend", After: "api"}, revisions[2].Diff["name"])
+	}
+
+	// Case: The service as it was when the first version was created
+	rows, err := repo.GetServiceAsOf(ctx, service.ServiceID, userUUID, created)
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, "backend", rows[0].Name)
+		assert.Equal(t, "1.0.0", rows[0].Version)
+	}
+
+	// Case: The revert restores the name and the versions of the revision
+	service.RowVersion = 0
+	assert.NoError(t, repo.RevertService(ctx, service, 2))
+	assert.Equal(t, "backend", service.Name)
+
+	versions, err := repo.GetServiceVersions(ctx, service.ServiceID, userUUID)
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 1) {
+		assert.Equal(t, "1.0.0", versions[0].Version)
+	}
+
+	// Case fail: The revision does not exist
+	assert.Equal(t, model.ErrServiceRevisionNotFound, repo.RevertService(ctx, service, 100))
+
+	// Case: The history is kept after the service is deleted
+	assert.NoError(t, repo.DeleteService(ctx, &model.Service{ServiceID: service.ServiceID, UserUUID: userUUID}))
+
+	rows, err = repo.GetServiceAsOf(ctx, service.ServiceID, userUUID, created)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+
+	rows, err = repo.GetServiceAsOf(ctx, service.ServiceID, userUUID, clock.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Empty(t, rows)
+}
@@ -0,0 +1,351 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	// The team of the service is recorded with the event, so that it's webhooks get the event once the service is deleted
+	queryInsertOutboxEvent = `
+	INSERT INTO outbox_events(event_id, event, user_uuid, service_id, team_id, payload)
+	VALUES(:event_id, :event, :user_uuid, :service_id, (SELECT s.team_id FROM services s WHERE s.service_id = :service_id), :payload)`
+
+	// Only one relay claims events at a time, so that the events of a service are claimed in order
+	queryLockOutbox = `SELECT pg_try_advisory_xact_lock(:lock_id)`
+
+	// An event is claimed once the previous pending events of it's service are claimed along with it or published,
+	// the events of a service which are claimed by another relay or wait for a retry are held back
+	queryClaimOutboxEvents = `
+	UPDATE outbox_events SET claimed_until = :lease_until
+	WHERE outbox_id IN (
+		SELECT o.outbox_id
+		FROM outbox_events o
+		WHERE o.published_at IS NULL AND o.dead_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox_events p
+				WHERE p.service_id = o.service_id AND p.outbox_id <= o.outbox_id
+					AND p.published_at IS NULL AND p.dead_at IS NULL
+					AND (p.claimed_until > :now OR p.next_attempt_at > :now)
+			)
+		ORDER BY o.outbox_id
+		LIMIT :limit
+	)
+	RETURNING outbox_id, event_id, event, user_uuid, service_id, team_id, payload, attempts, published_sinks, created_at`
+
+	queryGetPublishedEvent = `
+	SELECT o.outbox_id, o.event_id, o.event, o.user_uuid, o.service_id, o.team_id, o.payload, o.attempts, o.published_sinks,
+		o.published_seq, o.created_at
+	FROM outbox_events o
+	WHERE o.published_seq = :published_seq`
+
+	// Only one event is marked published at a time, so that the events are committed in the order of their position
+	queryLockOutboxPublish = `SELECT pg_advisory_xact_lock(:lock_id)`
+
+	// The position of an event in the event stream is assigned once it is published
+	queryMarkOutboxEventPublished = `
+	UPDATE outbox_events SET published_at = CURRENT_TIMESTAMP, published_sinks = :published_sinks, attempts = attempts + 1,
+		last_error = NULL, claimed_until = NULL,
+		published_seq = COALESCE(published_seq, (SELECT COALESCE(MAX(p.published_seq), 0) + 1 FROM outbox_events p))
+	WHERE outbox_id = :outbox_id
+	RETURNING published_seq`
+
+	// The event is dead once it reaches the maximum number of attempts
+	queryMarkOutboxEventFailed = `
+	UPDATE outbox_events SET published_sinks = :published_sinks, attempts = attempts + 1, last_error = :last_error,
+		claimed_until = NULL, next_attempt_at = :next_attempt_at,
+		dead_at = CASE WHEN attempts + 1 >= :max_attempts THEN CURRENT_TIMESTAMP ELSE NULL END
+	WHERE outbox_id = :outbox_id`
+
+	queryReleaseOutboxEvent = `UPDATE outbox_events SET claimed_until = NULL WHERE outbox_id = :outbox_id`
+
+	// An event published after a retry comes after the events published before it, whatever the order they were recorded in
+	querySelectPublishedEventsOfUser = `
+	SELECT o.outbox_id, o.event_id, o.event, o.user_uuid, o.service_id, o.team_id, o.payload, o.attempts, o.published_seq, o.created_at
+	FROM outbox_events o
+	WHERE o.user_uuid = :user_uuid AND o.published_seq > :after
+	ORDER BY o.published_seq
+	LIMIT :limit`
+)
+
+// outboxLockID is the key of the advisory lock held by the relay claiming events of the outbox
+const outboxLockID = 7311
+
+// outboxPublishLockID is the key of the advisory lock held while an event is marked published
+const outboxPublishLockID = 7313
+
+// Catalog events, they are recorded in the outbox and webhooks can subscribe to them
+const (
+	EventAll             = "*"
+	EventServiceCreated  = "service.created"
+	EventServiceUpdated  = "service.updated"
+	EventServiceDeleted  = "service.deleted"
+	EventVersionCreated  = "version.created"
+	EventVersionDeleted  = "version.deleted"
+	EventArtifactCreated = "artifact.created"
+)
+
+// OutboxEvent is a struct used to represent the `outbox_events` table in the database
+type OutboxEvent struct {
+	OutboxID  int64     `db:"outbox_id" json:"outbox_id"`
+	EventID   uuid.UUID `db:"event_id" json:"event_id"`
+	Event     string    `db:"event" json:"event"`
+	UserUUID  uuid.UUID `db:"user_uuid" json:"-"`
+	ServiceID int       `db:"service_id" json:"service_id"`
+	TeamID    *int      `db:"team_id" json:"-"`
+	Payload   string    `db:"payload" json:"payload"`
+	Attempts  int       `db:"attempts" json:"attempts"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+
+	// PublishedSeq is the position of the event in the event stream, it is assigned once the event is published
+	PublishedSeq int64 `db:"published_seq" json:"-"`
+
+	// PublishedSinks are the sinks the event was published to
+	PublishedSinks OutboxSinks `db:"published_sinks" json:"-"`
+}
+
+// OutboxSinks is the list of sinks an event was published to, it is stored as a comma separated list
+type OutboxSinks []string
+
+// Value implements driver.Valuer
+func (sinks OutboxSinks) Value() (driver.Value, error) {
+	return strings.Join(sinks, ","), nil
+}
+
+// Scan implements sql.Scanner
+func (sinks *OutboxSinks) Scan(src interface{}) error {
+	return (*WebhookEvents)(sinks).Scan(src)
+}
+
+// Contains checks if the event was published to the given sink
+func (sinks OutboxSinks) Contains(sink string) bool {
+	for _, s := range sinks {
+		if s == sink {
+			return true
+		}
+	}
+	return false
+}
+
+// OutboxAttempt is a struct used to record the result of publishing an event to the sinks
+type OutboxAttempt struct {
+	// PublishedSinks are the sinks the event was published to, including in the previous attempts
+	PublishedSinks OutboxSinks
+	// Error is empty when the event was published to every sink
+	Error         string
+	NextAttemptAt time.Time
+	MaxAttempts   int
+}
+
+// recordEvent writes an event to the outbox within the transaction of the change it describes,
+// so that an event is recorded if and only if the change is committed
+func recordEvent(ctx context.Context, tx *sqlx.Tx, userUUID uuid.UUID, serviceID int, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Error("error encoding event", zap.Error(err))
+		return err
+	}
+
+	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertOutboxEvent, map[string]interface{}{
+		"event_id":   uuid.New(),
+		"event":      event,
+		"user_uuid":  userUUID,
+		"service_id": serviceID,
+		"payload":    string(payload),
+	})
+	if err != nil {
+		log.Error("error building outbox event insert query", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		log.Error("error inserting outbox event", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// ClaimOutboxEvents is used to fetch the events to publish in the order they were recorded, they are not returned again until leaseUntil.
+// The following events of a service are held back while one of it's events waits to be retried, so that the events of a
+// service are published in order. No events are returned while another relay is claiming events.
+func ClaimOutboxEvents(ctx context.Context, now, leaseUntil time.Time, limit int) ([]OutboxEvent, error) {
+	var events []OutboxEvent
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		events = nil
+
+		// SQLite has a single writer, the transaction already holds the write lock
+		locked := true
+		if !db.IsSQLite() {
+			q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryLockOutbox, map[string]interface{}{
+				"lock_id": outboxLockID,
+			})
+			if err != nil {
+				log.Error("error building outbox lock query", zap.Error(err))
+				return err
+			}
+
+			err = tx.GetContext(ctx, &locked, q, args...)
+			if err != nil {
+				log.Error("error locking outbox", zap.Error(err))
+				return err
+			}
+		}
+
+		// Another relay is claiming events
+		if !locked {
+			return nil
+		}
+
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryClaimOutboxEvents, map[string]interface{}{
+			"now":         now.UTC(),
+			"lease_until": leaseUntil.UTC(),
+			"limit":       limit,
+		})
+		if err != nil {
+			log.Error("error building outbox claim query", zap.Error(err))
+			return err
+		}
+
+		err = tx.SelectContext(ctx, &events, q, args...)
+		if err != nil {
+			log.Error("error claiming outbox events", zap.Error(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The rows returned by an update are not ordered
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].OutboxID < events[j].OutboxID
+	})
+
+	return events, nil
+}
+
+// RecordOutboxAttempt is used to save the result of publishing a claimed event.
+// A failed event is retried at attempt.NextAttemptAt, it is dead once it failed attempt.MaxAttempts times.
+// The position of an event published to every sink is returned, it is 0 for a failed event.
+func RecordOutboxAttempt(ctx context.Context, event OutboxEvent, attempt OutboxAttempt) (int64, error) {
+	if attempt.Error != "" {
+		_, err := db.NamedExecContext(ctx, queryMarkOutboxEventFailed, map[string]interface{}{
+			"outbox_id":       event.OutboxID,
+			"published_sinks": attempt.PublishedSinks,
+			"last_error":      attempt.Error,
+			"next_attempt_at": attempt.NextAttemptAt.UTC(),
+			"max_attempts":    attempt.MaxAttempts,
+		})
+		if err != nil {
+			log.Error("error updating outbox event", zap.Error(err))
+			return 0, err
+		}
+
+		return 0, nil
+	}
+
+	var publishedSeq int64
+
+	// The position is the next one after the events committed before, which the statements of a read committed
+	// transaction see once the lock is held
+	err := db.WithTxOptions(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted}, func(tx *sqlx.Tx) error {
+		// SQLite has a single writer, the update holds the write lock
+		if !db.IsSQLite() {
+			q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryLockOutboxPublish, map[string]interface{}{
+				"lock_id": outboxPublishLockID,
+			})
+			if err != nil {
+				log.Error("error building outbox publish lock query", zap.Error(err))
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, q, args...)
+			if err != nil {
+				log.Error("error locking outbox publish", zap.Error(err))
+				return err
+			}
+		}
+
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryMarkOutboxEventPublished, map[string]interface{}{
+			"outbox_id":       event.OutboxID,
+			"published_sinks": attempt.PublishedSinks,
+		})
+		if err != nil {
+			log.Error("error building outbox event publish query", zap.Error(err))
+			return err
+		}
+
+		err = tx.GetContext(ctx, &publishedSeq, q, args...)
+		if err != nil {
+			log.Error("error updating outbox event", zap.Error(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return publishedSeq, nil
+}
+
+// ReleaseOutboxEvent is used to give up a claimed event which was not published, so that it can be claimed again
+func ReleaseOutboxEvent(ctx context.Context, event OutboxEvent) error {
+	_, err := db.NamedExecContext(ctx, queryReleaseOutboxEvent, map[string]interface{}{
+		"outbox_id": event.OutboxID,
+	})
+	if err != nil {
+		log.Error("error releasing outbox event", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// GetPublishedEvent is used to fetch the event at the given position of the event stream
+func GetPublishedEvent(ctx context.Context, publishedSeq int64) (*OutboxEvent, error) {
+	var event OutboxEvent
+
+	err := db.NamedGetContext(ctx, &event, queryGetPublishedEvent, map[string]interface{}{
+		"published_seq": publishedSeq,
+	})
+	if err != nil {
+		log.Error("Error while fetching published event", zap.Error(err))
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// GetPublishedEvents is used to fetch the published events of a user after the given position of the event stream,
+// in the order they were published
+func GetPublishedEvents(ctx context.Context, userUUID uuid.UUID, after int64, limit int) ([]OutboxEvent, error) {
+	var events []OutboxEvent
+
+	err := db.NamedSelectContext(ctx, &events, querySelectPublishedEventsOfUser, map[string]interface{}{
+		"user_uuid": userUUID,
+		"after":     after,
+		"limit":     limit,
+	})
+	if err != nil {
+		log.Error("Error while fetching published events", zap.Error(err))
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,154 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestQueryClaimOutboxEvents is used to test whether the index is used to query the pending events
+func TestQueryClaimOutboxEvents(t *testing.T) {
+	setupTest()
+
+	now := time.Now()
+	plan := queryPlan(t, queryClaimOutboxEvents, map[string]interface{}{
+		"now":         now,
+		"lease_until": now.Add(time.Minute),
+		"limit":       100,
+	})
+
+	if !usesIndex(plan, "Index Scan") {
+		t.Error("Expected index scan but index is not being used")
+	}
+}
+
+// TestQuerySelectPublishedEventsOfUser is used to test whether the index is used to query the events of a user
+func TestQuerySelectPublishedEventsOfUser(t *testing.T) {
+	setupTest()
+
+	plan := queryPlan(t, querySelectPublishedEventsOfUser, map[string]interface{}{
+		"user_uuid": uuid.New(),
+		"after":     0,
+		"limit":     500,
+	})
+
+	if !usesIndex(plan, "Index Scan") {
+		t.Error("Expected index scan but index is not being used")
+	}
+}
+
+// claimServiceEvents claims the pending events and returns the ones of the given service
+func claimServiceEvents(t *testing.T, now time.Time, serviceID int) []OutboxEvent {
+	events, err := ClaimOutboxEvents(context.Background(), now, now.Add(time.Minute), 100000)
+	if err != nil {
+		t.Fatal("Failed to claim outbox events:", err)
+	}
+
+	var claimed []OutboxEvent
+	for _, event := range events {
+		if event.ServiceID == serviceID {
+			claimed = append(claimed, event)
+		}
+	}
+	return claimed
+}
+
+func TestClaimOutboxEvents(t *testing.T) {
+	setupTest()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	service := &Service{Name: "outbox-" + uuid.NewString(), UserUUID: userUUID}
+	assert.NoError(t, service.CreateService(ctx))
+	sv := &ServiceVersion{ServiceID: service.ServiceID, Version: "1.0.0", Changes: []ServiceVersionChange{{Category: "Added", Description: "outbox"}}}
+	assert.NoError(t, sv.CreateServiceVersion(ctx, userUUID))
+
+	events := claimServiceEvents(t, now, service.ServiceID)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, EventServiceCreated, events[0].Event)
+		assert.Equal(t, EventVersionCreated, events[1].Event)
+	}
+
+	// Case: The claimed events are hidden from the other relays
+	assert.Empty(t, claimServiceEvents(t, now, service.ServiceID))
+
+	// Case: The following events of the service are held back while the first one waits to be retried
+	failed := OutboxAttempt{PublishedSinks: OutboxSinks{"file"}, Error: "unavailable", NextAttemptAt: now.Add(time.Hour), MaxAttempts: 2}
+	_, err := RecordOutboxAttempt(ctx, events[0], failed)
+	assert.NoError(t, err)
+	assert.NoError(t, ReleaseOutboxEvent(ctx, events[1]))
+	assert.Empty(t, claimServiceEvents(t, now, service.ServiceID))
+
+	events = claimServiceEvents(t, now.Add(2*time.Hour), service.ServiceID)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, OutboxSinks{"file"}, events[0].PublishedSinks)
+	}
+
+	// Case: An event is dead once it failed MaxAttempts times, the following events are not held back anymore
+	failed.NextAttemptAt = now.Add(3 * time.Hour)
+	_, err = RecordOutboxAttempt(ctx, events[0], failed)
+	assert.NoError(t, err)
+	assert.NoError(t, ReleaseOutboxEvent(ctx, events[1]))
+
+	events = claimServiceEvents(t, now, service.ServiceID)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, EventVersionCreated, events[0].Event)
+	}
+	assert.Equal(t, 1, countRows(t, "outbox_events", "service_id = ? AND dead_at IS NOT NULL", service.ServiceID))
+
+	publishedSeq, err := RecordOutboxAttempt(ctx, events[0], OutboxAttempt{PublishedSinks: OutboxSinks{"file"}})
+	assert.NoError(t, err)
+	assert.NotZero(t, publishedSeq)
+	assert.Equal(t, 1, countRows(t, "outbox_events", "service_id = ? AND published_at IS NOT NULL", service.ServiceID))
+}
+
+func TestGetPublishedEvents(t *testing.T) {
+	setupTest()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	owner := User{UserUUID: uuid.New(), Email: uuid.NewString() + "@example.com", Password: "secret"}
+	assert.NoError(t, owner.CreateUser(ctx))
+	first := &Service{Name: "published-" + uuid.NewString(), UserUUID: owner.UserUUID}
+	assert.NoError(t, first.CreateService(ctx))
+	second := &Service{Name: "published-" + uuid.NewString(), UserUUID: owner.UserUUID}
+	assert.NoError(t, second.CreateService(ctx))
+
+	claimed, err := ClaimOutboxEvents(ctx, now, now.Add(time.Minute), 100000)
+	assert.NoError(t, err)
+
+	var late, early []OutboxEvent
+	for _, event := range claimed {
+		switch event.ServiceID {
+		case first.ServiceID:
+			late = append(late, event)
+		case second.ServiceID:
+			early = append(early, event)
+		}
+	}
+	if !assert.Len(t, late, 1) || !assert.Len(t, early, 1) {
+		return
+	}
+
+	// Case: An event recorded before but published after another one is streamed after it, so that a client which
+	// received the other one gets it on reconnection
+	earlySeq, err := RecordOutboxAttempt(ctx, early[0], OutboxAttempt{})
+	assert.NoError(t, err)
+	lateSeq, err := RecordOutboxAttempt(ctx, late[0], OutboxAttempt{})
+	assert.NoError(t, err)
+	assert.Greater(t, lateSeq, earlySeq)
+
+	events, err := GetPublishedEvents(ctx, owner.UserUUID, earlySeq, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, late[0].OutboxID, events[0].OutboxID)
+		assert.Equal(t, lateSeq, events[0].PublishedSeq)
+	}
+
+	event, err := GetPublishedEvent(ctx, earlySeq)
+	assert.NoError(t, err)
+	assert.Equal(t, early[0].EventID, event.EventID)
+}
@@ -0,0 +1,304 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserRepository stores the users
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *User) error
+	// GetUserByEmail and GetUserByID return ErrUserNotFound when the user does not exist
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	GetUserByID(ctx context.Context, userUUID uuid.UUID) (*User, error)
+	UpdateUser(ctx context.Context, updatedEmail string, userUUID uuid.UUID) error
+}
+
+// ServiceRepository stores the services of the users along with their history
+type ServiceRepository interface {
+	CreateService(ctx context.Context, service *Service) error
+	GetServices(ctx context.Context, userUUID uuid.UUID, limit, offset int, serviceName, orderBy string) ([]Service, error)
+	GetService(ctx context.Context, serviceID int, userUUID uuid.UUID) ([]ServiceWithVersions, error)
+	GetServiceByID(ctx context.Context, serviceID int, userUUID uuid.UUID) (*Service, error)
+	ServiceExists(ctx context.Context, serviceID int, userUUID uuid.UUID) (bool, error)
+	UpdateService(ctx context.Context, service *Service) error
+	DeleteService(ctx context.Context, service *Service) error
+	GetServiceAsOf(ctx context.Context, serviceID int, userUUID uuid.UUID, asOf time.Time) ([]ServiceWithVersions, error)
+	GetServiceHistory(ctx context.Context, serviceID int, userUUID uuid.UUID) ([]ServiceRevision, error)
+	RevertService(ctx context.Context, service *Service, revision int64) error
+}
+
+// VersionRepository stores the versions of the services along with their changes
+type VersionRepository interface {
+	CreateServiceVersion(ctx context.Context, userUUID uuid.UUID, sv *ServiceVersion) error
+	DeleteServiceVersion(ctx context.Context, userUUID uuid.UUID, serviceID, svID int) error
+	GetServiceVersions(ctx context.Context, serviceID int, userUUID uuid.UUID) ([]ServiceVersion, error)
+	GetVersionsOfServices(ctx context.Context, serviceIDs []int, userUUID uuid.UUID) (map[int][]ServiceVersion, error)
+	ImportServiceVersions(ctx context.Context, userUUID uuid.UUID, serviceID int, versions []ServiceVersion) ([]string, []string, error)
+}
+
+// ArtifactRepository stores the artifacts of the versions
+type ArtifactRepository interface {
+	CreateArtifact(ctx context.Context, userUUID uuid.UUID, serviceID int, artifact *Artifact) error
+	GetServiceVersionArtifacts(ctx context.Context, userUUID uuid.UUID, serviceID, svID int) ([]Artifact, error)
+	GetArtifactByDigest(ctx context.Context, userUUID uuid.UUID, digest string) (*ArtifactLookup, error)
+}
+
+// CatalogRepository imports and exports the catalog of a user, along with the Backstage entities of it's services
+type CatalogRepository interface {
+	ImportCatalog(ctx context.Context, userUUID uuid.UUID, services []CatalogService, dryRun bool) ([]ImportedService, error)
+	ExportCatalog(ctx context.Context, userUUID uuid.UUID) ([]CatalogService, error)
+	GetServiceEntities(ctx context.Context, serviceIDs []int, userUUID uuid.UUID) (map[int]ServiceEntity, error)
+}
+
+// TeamRepository stores the teams, their members and their services
+type TeamRepository interface {
+	CreateTeam(ctx context.Context, userUUID uuid.UUID, team *Team) error
+	GetTeams(ctx context.Context, userUUID uuid.UUID) ([]Team, error)
+	GetTeam(ctx context.Context, userUUID uuid.UUID, teamID int) (*Team, error)
+	AddTeamMember(ctx context.Context, userUUID uuid.UUID, teamID int, email string) error
+	AcceptTeamInvitation(ctx context.Context, userUUID uuid.UUID, teamID int) error
+	RemoveTeamMember(ctx context.Context, userUUID uuid.UUID, teamID int, email string) error
+	AddTeamService(ctx context.Context, userUUID uuid.UUID, teamID, serviceID int) error
+	RemoveTeamService(ctx context.Context, userUUID uuid.UUID, teamID, serviceID int) error
+}
+
+// WebhookRepository stores the webhooks along with their deliveries
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *Webhook) error
+	GetWebhooks(ctx context.Context, userUUID uuid.UUID) ([]Webhook, error)
+	GetWebhook(ctx context.Context, userUUID uuid.UUID, webhookID int) (*Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *Webhook) error
+	DeleteWebhook(ctx context.Context, userUUID uuid.UUID, webhookID int) error
+	GetWebhookDeliveries(ctx context.Context, userUUID uuid.UUID, webhookID, limit, offset int) ([]WebhookDelivery, error)
+	RedeliverWebhookDelivery(ctx context.Context, userUUID uuid.UUID, webhookID, deliveryID int) (*WebhookDelivery, error)
+}
+
+// AuditRepository reads the audit log
+type AuditRepository interface {
+	GetAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, error)
+	VerifyAuditChain(ctx context.Context, batchSize int) (AuditVerification, error)
+}
+
+// EventRepository reads the published events of the outbox
+type EventRepository interface {
+	GetPublishedEvents(ctx context.Context, userUUID uuid.UUID, after int64, limit int) ([]OutboxEvent, error)
+}
+
+// Repositories are the repositories the API reads and writes through
+type Repositories struct {
+	Users     UserRepository
+	Services  ServiceRepository
+	Versions  VersionRepository
+	Artifacts ArtifactRepository
+	Catalog   CatalogRepository
+	Teams     TeamRepository
+	Webhooks  WebhookRepository
+	Audit     AuditRepository
+	Events    EventRepository
+}
+
+// NewSQLRepositories returns the repositories of the database
+func NewSQLRepositories() Repositories {
+	repo := SQLRepository{}
+	return Repositories{
+		Users:     repo,
+		Services:  repo,
+		Versions:  repo,
+		Artifacts: repo,
+		Catalog:   repo,
+		Teams:     repo,
+		Webhooks:  repo,
+		Audit:     repo,
+		Events:    repo,
+	}
+}
+
+// SQLRepository is the repository of the database, it records the events and the audit log of the changes
+type SQLRepository struct{}
+
+var (
+	_ UserRepository     = SQLRepository{}
+	_ ServiceRepository  = SQLRepository{}
+	_ VersionRepository  = SQLRepository{}
+	_ ArtifactRepository = SQLRepository{}
+	_ CatalogRepository  = SQLRepository{}
+	_ TeamRepository     = SQLRepository{}
+	_ WebhookRepository  = SQLRepository{}
+	_ AuditRepository    = SQLRepository{}
+	_ EventRepository    = SQLRepository{}
+)
+
+func (SQLRepository) CreateUser(ctx context.Context, user *User) error {
+	return user.CreateUser(ctx)
+}
+
+func (SQLRepository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	return GetUserByEmail(ctx, email)
+}
+
+func (SQLRepository) GetUserByID(ctx context.Context, userUUID uuid.UUID) (*User, error) {
+	return GetUserByID(ctx, userUUID)
+}
+
+func (SQLRepository) UpdateUser(ctx context.Context, updatedEmail string, userUUID uuid.UUID) error {
+	return UpdateUser(ctx, updatedEmail, userUUID)
+}
+
+func (SQLRepository) CreateService(ctx context.Context, service *Service) error {
+	return service.CreateService(ctx)
+}
+
+func (SQLRepository) GetServices(ctx context.Context, userUUID uuid.UUID, limit, offset int, serviceName, orderBy string) ([]Service, error) {
+	return GetServices(ctx, userUUID, limit, offset, serviceName, orderBy)
+}
+
+func (SQLRepository) GetService(ctx context.Context, serviceID int, userUUID uuid.UUID) ([]ServiceWithVersions, error) {
+	return GetService(ctx, serviceID, userUUID)
+}
+
+func (SQLRepository) GetServiceByID(ctx context.Context, serviceID int, userUUID uuid.UUID) (*Service, error) {
+	return GetServiceByID(ctx, serviceID, userUUID)
+}
+
+func (SQLRepository) ServiceExists(ctx context.Context, serviceID int, userUUID uuid.UUID) (bool, error) {
+	return ServiceExists(ctx, serviceID, userUUID)
+}
+
+func (SQLRepository) UpdateService(ctx context.Context, service *Service) error {
+	return service.UpdateService(ctx)
+}
+
+func (SQLRepository) DeleteService(ctx context.Context, service *Service) error {
+	return service.DeleteService(ctx)
+}
+
+func (SQLRepository) GetServiceAsOf(ctx context.Context, serviceID int, userUUID uuid.UUID, asOf time.Time) ([]ServiceWithVersions, error) {
+	return GetServiceAsOf(ctx, serviceID, userUUID, asOf)
+}
+
+func (SQLRepository) GetServiceHistory(ctx context.Context, serviceID int, userUUID uuid.UUID) ([]ServiceRevision, error) {
+	return GetServiceHistory(ctx, serviceID, userUUID)
+}
+
+func (SQLRepository) RevertService(ctx context.Context, service *Service, revision int64) error {
+	return service.RevertService(ctx, revision)
+}
+
+func (SQLRepository) CreateServiceVersion(ctx context.Context, userUUID uuid.UUID, sv *ServiceVersion) error {
+	return sv.CreateServiceVersion(ctx, userUUID)
+}
+
+func (SQLRepository) DeleteServiceVersion(ctx context.Context, userUUID uuid.UUID, serviceID, svID int) error {
+	return DeleteServiceVersion(ctx, userUUID, serviceID, svID)
+}
+
+func (SQLRepository) GetServiceVersions(ctx context.Context, serviceID int, userUUID uuid.UUID) ([]ServiceVersion, error) {
+	return GetServiceVersions(ctx, serviceID, userUUID)
+}
+
+func (SQLRepository) GetVersionsOfServices(ctx context.Context, serviceIDs []int, userUUID uuid.UUID) (map[int][]ServiceVersion, error) {
+	return GetVersionsOfServices(ctx, serviceIDs, userUUID)
+}
+
+func (SQLRepository) ImportServiceVersions(ctx context.Context, userUUID uuid.UUID, serviceID int, versions []ServiceVersion) ([]string, []string, error) {
+	return ImportServiceVersions(ctx, userUUID, serviceID, versions)
+}
+
+func (SQLRepository) CreateArtifact(ctx context.Context, userUUID uuid.UUID, serviceID int, artifact *Artifact) error {
+	return artifact.CreateArtifact(ctx, userUUID, serviceID)
+}
+
+func (SQLRepository) GetServiceVersionArtifacts(ctx context.Context, userUUID uuid.UUID, serviceID, svID int) ([]Artifact, error) {
+	return GetServiceVersionArtifacts(ctx, userUUID, serviceID, svID)
+}
+
+func (SQLRepository) GetArtifactByDigest(ctx context.Context, userUUID uuid.UUID, digest string) (*ArtifactLookup, error) {
+	return GetArtifactByDigest(ctx, userUUID, digest)
+}
+
+func (SQLRepository) ImportCatalog(ctx context.Context, userUUID uuid.UUID, services []CatalogService, dryRun bool) ([]ImportedService, error) {
+	return ImportCatalog(ctx, userUUID, services, dryRun)
+}
+
+func (SQLRepository) ExportCatalog(ctx context.Context, userUUID uuid.UUID) ([]CatalogService, error) {
+	return ExportCatalog(ctx, userUUID)
+}
+
+func (SQLRepository) GetServiceEntities(ctx context.Context, serviceIDs []int, userUUID uuid.UUID) (map[int]ServiceEntity, error) {
+	return GetServiceEntities(ctx, serviceIDs, userUUID)
+}
+
+func (SQLRepository) CreateTeam(ctx context.Context, userUUID uuid.UUID, team *Team) error {
+	return team.CreateTeam(ctx, userUUID)
+}
+
+func (SQLRepository) GetTeams(ctx context.Context, userUUID uuid.UUID) ([]Team, error) {
+	return GetTeams(ctx, userUUID)
+}
+
+func (SQLRepository) GetTeam(ctx context.Context, userUUID uuid.UUID, teamID int) (*Team, error) {
+	return GetTeam(ctx, userUUID, teamID)
+}
+
+func (SQLRepository) AddTeamMember(ctx context.Context, userUUID uuid.UUID, teamID int, email string) error {
+	return AddTeamMember(ctx, userUUID, teamID, email)
+}
+
+func (SQLRepository) AcceptTeamInvitation(ctx context.Context, userUUID uuid.UUID, teamID int) error {
+	return AcceptTeamInvitation(ctx, userUUID, teamID)
+}
+
+func (SQLRepository) RemoveTeamMember(ctx context.Context, userUUID uuid.UUID, teamID int, email string) error {
+	return RemoveTeamMember(ctx, userUUID, teamID, email)
+}
+
+func (SQLRepository) AddTeamService(ctx context.Context, userUUID uuid.UUID, teamID, serviceID int) error {
+	return AddTeamService(ctx, userUUID, teamID, serviceID)
+}
+
+func (SQLRepository) RemoveTeamService(ctx context.Context, userUUID uuid.UUID, teamID, serviceID int) error {
+	return RemoveTeamService(ctx, userUUID, teamID, serviceID)
+}
+
+func (SQLRepository) CreateWebhook(ctx context.Context, webhook *Webhook) error {
+	return webhook.CreateWebhook(ctx)
+}
+
+func (SQLRepository) GetWebhooks(ctx context.Context, userUUID uuid.UUID) ([]Webhook, error) {
+	return GetWebhooks(ctx, userUUID)
+}
+
+func (SQLRepository) GetWebhook(ctx context.Context, userUUID uuid.UUID, webhookID int) (*Webhook, error) {
+	return GetWebhook(ctx, userUUID, webhookID)
+}
+
+func (SQLRepository) UpdateWebhook(ctx context.Context, webhook *Webhook) error {
+	return webhook.UpdateWebhook(ctx)
+}
+
+func (SQLRepository) DeleteWebhook(ctx context.Context, userUUID uuid.UUID, webhookID int) error {
+	return DeleteWebhook(ctx, userUUID, webhookID)
+}
+
+func (SQLRepository) GetWebhookDeliveries(ctx context.Context, userUUID uuid.UUID, webhookID, limit, offset int) ([]WebhookDelivery, error) {
+	return GetWebhookDeliveries(ctx, userUUID, webhookID, limit, offset)
+}
+
+func (SQLRepository) RedeliverWebhookDelivery(ctx context.Context, userUUID uuid.UUID, webhookID, deliveryID int) (*WebhookDelivery, error) {
+	return RedeliverWebhookDelivery(ctx, userUUID, webhookID, deliveryID)
+}
+
+func (SQLRepository) GetAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	return GetAuditEvents(ctx, filter)
+}
+
+func (SQLRepository) VerifyAuditChain(ctx context.Context, batchSize int) (AuditVerification, error) {
+	return VerifyAuditChain(ctx, batchSize)
+}
+
+func (SQLRepository) GetPublishedEvents(ctx context.Context, userUUID uuid.UUID, after int64, limit int) ([]OutboxEvent, error) {
+	return GetPublishedEvents(ctx, userUUID, after, limit)
+}
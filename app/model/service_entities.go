@@ -0,0 +1,102 @@
+package model
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	queryUpsertServiceEntity = `
+	INSERT INTO service_entities(service_id, api_version, kind, metadata, spec)
+	VALUES(:service_id, :api_version, :kind, :metadata, :spec)
+	ON CONFLICT (service_id) DO UPDATE SET api_version = EXCLUDED.api_version, kind = EXCLUDED.kind,
+	metadata = EXCLUDED.metadata, spec = EXCLUDED.spec, updated_at = CURRENT_TIMESTAMP`
+
+	queryExportServiceEntities = `
+	SELECT e.service_id, e.api_version, e.kind, e.metadata, e.spec
+	FROM service_entities e
+	JOIN services s ON s.service_id = e.service_id
+	WHERE s.user_uuid = :user_uuid`
+
+	querySelectServiceEntitiesByIDs = `
+	SELECT e.service_id, e.api_version, e.kind, e.metadata, e.spec
+	FROM service_entities e
+	JOIN services s ON s.service_id = e.service_id
+	WHERE s.user_uuid = :user_uuid AND e.service_id IN (:service_ids)`
+)
+
+// ServiceEntity is a struct used to represent the `service_entities` table in the database.
+// It keeps the Backstage descriptor a service was imported from, metadata and spec are stored as JSON.
+type ServiceEntity struct {
+	ServiceID  int    `db:"service_id" json:"service_id"`
+	APIVersion string `db:"api_version" json:"api_version"`
+	Kind       string `db:"kind" json:"kind"`
+	Metadata   string `db:"metadata" json:"metadata"`
+	Spec       string `db:"spec" json:"spec"`
+}
+
+// upsertServiceEntity creates or replaces the Backstage entity of a service within the given transaction
+func upsertServiceEntity(ctx context.Context, tx *sqlx.Tx, entity *ServiceEntity) error {
+	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryUpsertServiceEntity, entity)
+	if err != nil {
+		log.Error("error building service entity upsert query", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		log.Error("error upserting service entity", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// getServiceEntities is used to fetch the Backstage entities of all the services of a user by service id
+func getServiceEntities(ctx context.Context, userUUID uuid.UUID) (map[int]ServiceEntity, error) {
+	var entities []ServiceEntity
+
+	err := db.NamedSelectContext(ctx, &entities, queryExportServiceEntities, map[string]interface{}{
+		"user_uuid": userUUID,
+	})
+	if err != nil {
+		log.Error("Error while exporting service entities", zap.Error(err))
+		return nil, err
+	}
+
+	entitiesByServiceID := make(map[int]ServiceEntity, len(entities))
+	for _, entity := range entities {
+		entitiesByServiceID[entity.ServiceID] = entity
+	}
+
+	return entitiesByServiceID, nil
+}
+
+// GetServiceEntities is used to fetch the Backstage entities of several services of a user by service id,
+// services which were not imported from Backstage are not present in the map
+func GetServiceEntities(ctx context.Context, serviceIDs []int, userUUID uuid.UUID) (map[int]ServiceEntity, error) {
+	entitiesByServiceID := make(map[int]ServiceEntity)
+	if len(serviceIDs) == 0 {
+		return entitiesByServiceID, nil
+	}
+
+	var entities []ServiceEntity
+
+	err := db.NamedSelectInContext(ctx, &entities, querySelectServiceEntitiesByIDs, map[string]interface{}{
+		"service_ids": serviceIDs,
+		"user_uuid":   userUUID,
+	})
+	if err != nil {
+		log.Error("Error while fetching service entities", zap.Error(err))
+		return nil, err
+	}
+
+	for _, entity := range entities {
+		entitiesByServiceID[entity.ServiceID] = entity
+	}
+
+	return entitiesByServiceID, nil
+}
@@ -3,18 +3,17 @@ package model
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
+	database "github.com/ZiyanK/service-catalog-api/app/db"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
 const (
-	queryInsertService = `INSERT INTO services(name, description, user_uuid) VALUES(:name, :description, :user_uuid)`
-
 	queryCheckServiceByNameAndUserUUID = `
 	SELECT COUNT(1) FROM services s
 	WHERE s.name = :name AND s.user_uuid = :user_uuid`
@@ -24,18 +23,32 @@ const (
 	WHERE s.service_id = :service_id AND s.user_uuid = :user_uuid`
 
 	queryGetService = `
-	SELECT s.service_id, s.name, s.description, COALESCE(sv.sv_id, 0) as sv_id, COALESCE(sv.version,'') as version, COALESCE(sv.changelog,'') as changelog
+	SELECT s.service_id, s.name, s.description, s.row_version, COALESCE(sv.sv_id, 0) as sv_id, COALESCE(sv.version,'') as version, COALESCE(sv.changelog,'') as changelog
 	FROM services s
 	LEFT JOIN service_versions sv ON sv.service_id = s.service_id
 	WHERE s.user_uuid = :user_uuid AND s.service_id = :service_id`
 
+	querySelectServiceByID = `
+	SELECT s.service_id, s.name, s.description, s.row_version, s.created_at, s.updated_at, COUNT(sv.service_id) AS versions_count
+	FROM services s
+	LEFT JOIN service_versions sv ON s.service_id = sv.service_id
+	WHERE s.user_uuid = :user_uuid AND s.service_id = :service_id
+	GROUP BY s.service_id`
+
 	queryUpdateService = `
-	UPDATE services s SET name = :name, description = :description, updated_at = NOW()
-	WHERE s.service_id = :service_id
-	RETURNING *`
+	UPDATE services SET name = :name, description = :description, row_version = row_version + 1, updated_at = CURRENT_TIMESTAMP
+	WHERE service_id = :service_id AND (:row_version = 0 OR row_version = :row_version)
+	RETURNING row_version`
+
+	querySelectServiceState = `
+	SELECT s.service_id, s.name, COALESCE(s.description, '') as description, s.user_uuid, s.row_version
+	FROM services s
+	WHERE s.service_id = :service_id`
 
-	queryDeleteService         = `DELETE FROM services WHERE service_id = :service_id`
-	queryDeleteServiceVersions = `DELETE FROM service_versions WHERE service_id = :service_id`
+	// queryBumpServiceRowVersion is run when the versions of a service change as they are part of it's representation
+	queryBumpServiceRowVersion = `UPDATE services SET row_version = row_version + 1 WHERE service_id = :service_id`
+
+	queryDeleteService = `DELETE FROM services WHERE service_id = :service_id AND (:row_version = 0 OR row_version = :row_version)`
 )
 
 // Service is a struct used to represent the `services` table in the database
@@ -47,6 +60,10 @@ type Service struct {
 	CreatedAt     time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
 	VersionsCount int       `db:"versions_count" json:"versions_count"`
+
+	// RowVersion is incremented by every change of the service, it is reported in the ETag header.
+	// Updates and deletes with a RowVersion only apply to that version of the service.
+	RowVersion int64 `db:"row_version" json:"-"`
 }
 
 // ServiceWithVersions is a struct used to get the given service and all of it's version
@@ -57,73 +74,73 @@ type ServiceWithVersions struct {
 	SvID        int    `db:"sv_id" json:"sv_id"`
 	Version     string `db:"version" json:"version"`
 	Changelog   string `db:"changelog" json:"changelog"`
+	RowVersion  int64  `db:"row_version" json:"-"`
 }
 
 // CreateService is used to create a new service for a user
 func (service *Service) CreateService(ctx context.Context) error {
-	tx, err := db.Sqlx.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceByNameAndUserUUID, map[string]interface{}{
+			"name":      service.Name,
+			"user_uuid": service.UserUUID,
+		})
+		if err != nil {
+			log.Error("error building service fetch query", zap.Error(err))
+			return err
+		}
+
+		var count int
+
+		err = tx.GetContext(ctx, &count, q, args...)
+		if err != nil && err != sql.ErrNoRows {
+			log.Error("error querying user", zap.Error(err))
+			return err
+		}
+
+		if count > 0 {
+			log.Info("service with same name exists")
+			return ErrServiceExists
+		}
+
+		// If service doesn't exist
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertServiceReturnID, map[string]interface{}{
+			"name":        service.Name,
+			"description": service.Description,
+			"user_uuid":   service.UserUUID,
+		})
+		if err != nil {
+			log.Error("error building service insert query", zap.Error(err))
+			return err
+		}
+
+		// A service created concurrently with the same name violates the unique constraint
+		err = tx.QueryRowxContext(ctx, q, args...).Scan(&service.ServiceID)
+		if database.IsUniqueViolation(err) {
+			log.Info("service with same name exists")
+			return ErrServiceExists
+		}
+		if err != nil {
+			log.Error("error inserting service", zap.Error(err))
+			return err
+		}
+
+		err = recordServiceRevision(ctx, tx, service.ServiceID, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		err = recordEvent(ctx, tx, service.UserUUID, service.ServiceID, EventServiceCreated, serviceEventData(service))
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, tx, service.UserUUID, EventServiceCreated, AuditTargetService, strconv.Itoa(service.ServiceID), nil, serviceEventData(service))
+		if err != nil {
+			return err
+		}
 
-	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceByNameAndUserUUID, map[string]interface{}{
-		"name":      service.Name,
-		"user_uuid": service.UserUUID,
-	})
-	if err != nil {
-		log.Error("error building service fetch query", zap.Error(err))
-		tx.Rollback()
-		return err
-	}
-
-	var count int
-
-	err = tx.GetContext(ctx, &count, q, args...)
-	if err != nil && err != sql.ErrNoRows {
-		log.Error("error querying user", zap.Error(err))
-		return err
-	}
-
-	if count > 0 {
-		tx.Rollback()
-		log.Info("service with same name exists")
-		return errors.New("service exists")
-	}
-
-	// If service doesn't exist
-	q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertService, map[string]interface{}{
-		"name":        service.Name,
-		"description": service.Description,
-		"user_uuid":   service.UserUUID,
-	})
-	if err != nil {
-		log.Error("error building service insert query", zap.Error(err))
-		tx.Rollback()
-		return err
-	}
-
-	result, err := tx.ExecContext(ctx, q, args...)
-	if err != nil {
-		log.Error("error inserting service", zap.Error(err))
-		tx.Rollback()
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		tx.Rollback()
-		log.Error("Error while getting no. of rows affected", zap.Error(err))
-		return err
-	}
-
-	if rowsAffected == 1 {
-		tx.Commit()
 		return nil
-	}
-
-	tx.Rollback()
-	log.Info("no row was updated")
-	return err
+	})
 }
 
 // GetServices is used to fetch all the services present ofr a given user
@@ -133,7 +150,7 @@ func GetServices(ctx context.Context, userUUID uuid.UUID, limit, offset int, ser
 	// This query was required to be initialized here to add the order by (ASC,DESC) clause
 	// Reason: sqlx does not permit to pass keywords as args
 	querySelectServices := `
-	SELECT s.service_id, s.name, s.description, s.created_at, s.updated_at, COUNT(sv.service_id) AS versions_count
+	SELECT s.service_id, s.name, s.description, s.row_version, s.created_at, s.updated_at, COUNT(sv.service_id) AS versions_count
 	FROM services s
 	LEFT JOIN service_versions sv ON s.service_id = sv.service_id
 	WHERE
@@ -191,134 +208,224 @@ func GetService(ctx context.Context, serviceID int, userUUID uuid.UUID) ([]Servi
 	return service, nil
 }
 
-// UpdateService is used to update the service name and description of a given service
-func (service *Service) UpdateService(ctx context.Context) error {
-	tx, err := db.Sqlx.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
+// GetServiceByID is used to fetch a service of a user along with it's number of versions
+func GetServiceByID(ctx context.Context, serviceID int, userUUID uuid.UUID) (*Service, error) {
+	var service Service
 
-	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceByIDAndUserUUID, map[string]interface{}{
-		"service_id": service.ServiceID,
-		"user_uuid":  service.UserUUID,
+	err := db.NamedGetContext(ctx, &service, querySelectServiceByID, map[string]interface{}{
+		"user_uuid":  userUUID,
+		"service_id": serviceID,
 	})
 	if err != nil {
-		log.Error("error building service fetch query", zap.Error(err))
-		tx.Rollback()
-		return err
+		if err == sql.ErrNoRows {
+			return nil, ErrServiceNotFound
+		}
+		log.Error("Error while fetching service by id", zap.Error(err))
+		return nil, err
 	}
 
-	var count int
-
-	err = tx.GetContext(ctx, &count, q, args...)
-	if err != nil && err != sql.ErrNoRows {
-		log.Error("error querying user", zap.Error(err))
-		return err
-	}
+	return &service, nil
+}
 
-	// If service was not found
-	if count == 0 {
-		tx.Rollback()
-		log.Info("service does not exist")
-		return errors.New("service does not exist")
-	}
+// UpdateService is used to update the service name and description of a given service
+func (service *Service) UpdateService(ctx context.Context) error {
+	// The row version of the service is replaced by the update, the transaction may be run again
+	rowVersion := service.RowVersion
+
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceByIDAndUserUUID, map[string]interface{}{
+			"service_id": service.ServiceID,
+			"user_uuid":  service.UserUUID,
+		})
+		if err != nil {
+			log.Error("error building service fetch query", zap.Error(err))
+			return err
+		}
+
+		var count int
+
+		err = tx.GetContext(ctx, &count, q, args...)
+		if err != nil && err != sql.ErrNoRows {
+			log.Error("error querying user", zap.Error(err))
+			return err
+		}
+
+		// If service was not found
+		if count == 0 {
+			log.Info("service does not exist")
+			return ErrServiceNotFound
+		}
+
+		before, err := getServiceState(ctx, tx, service.ServiceID)
+		if err != nil {
+			return err
+		}
+
+		// If service is found, it is only updated if it's row version did not change
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryUpdateService, map[string]interface{}{
+			"service_id":  service.ServiceID,
+			"name":        service.Name,
+			"description": service.Description,
+			"row_version": rowVersion,
+		})
+		if err != nil {
+			log.Error("error building service update query", zap.Error(err))
+			return err
+		}
+
+		err = tx.QueryRowxContext(ctx, q, args...).Scan(&service.RowVersion)
+		if err != nil {
+			if err == sql.ErrNoRows && rowVersion != 0 {
+				log.Info("service was modified")
+				return ErrServiceModified
+			}
+			if err == sql.ErrNoRows {
+				return ErrServiceNotFound
+			}
+			if database.IsUniqueViolation(err) {
+				log.Info("service with same name exists")
+				return ErrServiceExists
+			}
+			log.Error("Error while updating service", zap.Error(err))
+			return err
+		}
+
+		err = recordServiceRevision(ctx, tx, service.ServiceID, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		err = recordEvent(ctx, tx, service.UserUUID, service.ServiceID, EventServiceUpdated, serviceEventData(service))
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, tx, service.UserUUID, EventServiceUpdated, AuditTargetService, strconv.Itoa(service.ServiceID), serviceEventData(before), serviceEventData(service))
+		if err != nil {
+			return err
+		}
 
-	// If service is found
-	result, err := db.NamedExecContext(ctx, queryUpdateService, map[string]interface{}{
-		"service_id":  service.ServiceID,
-		"name":        service.Name,
-		"description": service.Description,
+		return nil
 	})
-	if err != nil {
-		tx.Rollback()
-		log.Error("Error while updating service", zap.Error(err))
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		tx.Rollback()
-		log.Error("Error while getting no. of rows affected", zap.Error(err))
-		return err
-	}
-
-	if rowsAffected == 0 {
-		tx.Rollback()
-		return errors.New("no rows updated")
-	}
-
-	tx.Commit()
-	return nil
 }
 
 // DeleteService is used to delete a given service and all it's version
 func (service *Service) DeleteService(ctx context.Context) error {
-	tx, err := db.Sqlx.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceByIDAndUserUUID, map[string]interface{}{
+			"service_id": service.ServiceID,
+			"user_uuid":  service.UserUUID,
+		})
+		if err != nil {
+			log.Error("error building service fetch query", zap.Error(err))
+			return err
+		}
+
+		var count int
+
+		err = tx.GetContext(ctx, &count, q, args...)
+		if err != nil && err != sql.ErrNoRows {
+			log.Error("error querying user", zap.Error(err))
+			return err
+		}
+
+		// If service was not found
+		if count == 0 {
+			log.Info("service does not exist")
+			return ErrServiceNotFound
+		}
+
+		before, err := getServiceState(ctx, tx, service.ServiceID)
+		if err != nil {
+			return err
+		}
+
+		// If service is found, it is only deleted if it's row version did not change
+		if service.RowVersion != 0 && before.RowVersion != service.RowVersion {
+			log.Info("service was modified")
+			return ErrServiceModified
+		}
+
+		// The event is recorded before the service is deleted so that it is recorded with the team of the service,
+		// it is rolled back along with the delete if the delete fails
+		err = recordEvent(ctx, tx, service.UserUUID, service.ServiceID, EventServiceDeleted, map[string]interface{}{
+			"service_id": service.ServiceID,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Deleting service, it's entity and versions are deleted along with it
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryDeleteService, map[string]interface{}{
+			"service_id":  service.ServiceID,
+			"row_version": service.RowVersion,
+		})
+		if err != nil {
+			log.Error("error building service delete query", zap.Error(err))
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("Error while deleting service", zap.Error(err))
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.Error("Error while getting no. of rows affected", zap.Error(err))
+			return err
+		}
+
+		if rowsAffected == 0 && service.RowVersion != 0 {
+			return ErrServiceModified
+		}
+		if rowsAffected == 0 {
+			return ErrServiceNotFound
+		}
+
+		err = closeServiceHistory(ctx, tx, service.ServiceID)
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, tx, service.UserUUID, EventServiceDeleted, AuditTargetService, strconv.Itoa(service.ServiceID), serviceEventData(before), nil)
+		if err != nil {
+			return err
+		}
 
-	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceByIDAndUserUUID, map[string]interface{}{
-		"service_id": service.ServiceID,
-		"user_uuid":  service.UserUUID,
+		return nil
 	})
-	if err != nil {
-		tx.Rollback()
-		log.Error("error building service fetch query", zap.Error(err))
-		return err
-	}
-
-	var count int
-
-	err = tx.GetContext(ctx, &count, q, args...)
-	if err != nil && err != sql.ErrNoRows {
-		tx.Rollback()
-		log.Error("error querying user", zap.Error(err))
-		return err
-	}
-
-	// If service was not found
-	if count == 0 {
-		tx.Rollback()
-		log.Info("service does not exist")
-		return errors.New("service does not exist")
-	}
-
-	// If service is found
+}
 
-	// Deleting service versions
-	// Deleting versions first due to foreign key constraint
-	_, err = db.NamedExecContext(ctx, queryDeleteServiceVersions, map[string]interface{}{
-		"service_id": service.ServiceID,
+// getServiceState fetches the current state of a service using the given transaction
+func getServiceState(ctx context.Context, tx *sqlx.Tx, serviceID int) (*Service, error) {
+	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), querySelectServiceState, map[string]interface{}{
+		"service_id": serviceID,
 	})
 	if err != nil {
-		tx.Rollback()
-		log.Error("Error while deleting service", zap.Error(err))
-		return err
+		log.Error("error building service state query", zap.Error(err))
+		return nil, err
 	}
 
-	// Deleting service
-	result, err := db.NamedExecContext(ctx, queryDeleteService, map[string]interface{}{
-		"service_id": service.ServiceID,
-	})
+	var service Service
+	err = tx.GetContext(ctx, &service, q, args...)
 	if err != nil {
-		tx.Rollback()
-		log.Error("Error while deleting service", zap.Error(err))
-		return err
+		if err == sql.ErrNoRows {
+			return nil, ErrServiceNotFound
+		}
+		log.Error("error querying service state", zap.Error(err))
+		return nil, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		tx.Rollback()
-		log.Error("Error while getting no. of rows affected", zap.Error(err))
-		return err
-	}
+	return &service, nil
+}
 
-	if rowsAffected == 0 {
-		tx.Rollback()
-		return errors.New("no rows deleted")
+// serviceEventData is the data of the events recorded when a service is created or updated
+func serviceEventData(service *Service) map[string]interface{} {
+	return map[string]interface{}{
+		"service_id":  service.ServiceID,
+		"name":        service.Name,
+		"description": service.Description,
 	}
-
-	tx.Commit()
-	return nil
 }
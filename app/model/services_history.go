@@ -0,0 +1,477 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	database "github.com/ZiyanK/service-catalog-api/app/db"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	queryCloseServiceRevision = `
+	UPDATE services_history SET valid_to = :at
+	WHERE service_id = :service_id AND valid_to IS NULL`
+
+	queryInsertServiceRevision = `
+	INSERT INTO services_history(service_id, revision, user_uuid, name, description, valid_from)
+	SELECT s.service_id, s.row_version, s.user_uuid, s.name, s.description, :at
+	FROM services s
+	WHERE s.service_id = :service_id
+	RETURNING revision`
+
+	queryInsertServiceVersionHistory = `
+	INSERT INTO service_versions_history(sv_id, service_id, version, changelog, added_in, valid_from)
+	VALUES(:sv_id, :service_id, :version, :changelog, :revision, :at)`
+
+	queryRemoveServiceVersionHistory = `
+	UPDATE service_versions_history SET removed_in = :revision, valid_to = :at
+	WHERE sv_id = :sv_id AND valid_to IS NULL`
+
+	// The versions removed along with their service are not removed in a revision
+	queryCloseServiceVersionsHistory = `
+	UPDATE service_versions_history SET valid_to = :at
+	WHERE service_id = :service_id AND valid_to IS NULL`
+
+	queryGetServiceAsOf = `
+	SELECT h.service_id, h.name, COALESCE(h.description, '') as description, h.revision as row_version,
+		COALESCE(vh.sv_id, 0) as sv_id, COALESCE(vh.version, '') as version, COALESCE(vh.changelog, '') as changelog
+	FROM services_history h
+	LEFT JOIN service_versions_history vh ON vh.service_id = h.service_id
+		AND vh.valid_from <= :as_of AND (vh.valid_to IS NULL OR vh.valid_to > :as_of)
+	WHERE h.user_uuid = :user_uuid AND h.service_id = :service_id
+		AND h.valid_from <= :as_of AND (h.valid_to IS NULL OR h.valid_to > :as_of)
+	ORDER BY vh.sv_id`
+
+	querySelectServiceRevisions = `
+	SELECT h.service_id, h.revision, h.name, COALESCE(h.description, '') as description, h.valid_from, h.valid_to
+	FROM services_history h
+	WHERE h.user_uuid = :user_uuid AND h.service_id = :service_id
+	ORDER BY h.revision`
+
+	querySelectServiceVersionsHistory = `
+	SELECT vh.sv_id, vh.service_id, vh.version, COALESCE(vh.changelog, '') as changelog, vh.added_in,
+		COALESCE(vh.removed_in, 0) as removed_in, vh.valid_from, vh.valid_to
+	FROM service_versions_history vh
+	WHERE vh.service_id = :service_id
+	ORDER BY vh.sv_id`
+
+	queryGetServiceRevision = `
+	SELECT h.service_id, h.revision, h.name, COALESCE(h.description, '') as description, h.valid_from, h.valid_to
+	FROM services_history h
+	WHERE h.user_uuid = :user_uuid AND h.service_id = :service_id AND h.revision = :revision`
+
+	querySelectServiceVersionsOfRevision = `
+	SELECT vh.sv_id, vh.service_id, vh.version, COALESCE(vh.changelog, '') as changelog, vh.added_in,
+		COALESCE(vh.removed_in, 0) as removed_in, vh.valid_from, vh.valid_to
+	FROM service_versions_history vh
+	WHERE vh.service_id = :service_id AND vh.added_in <= :revision AND (vh.removed_in IS NULL OR vh.removed_in > :revision)
+	ORDER BY vh.sv_id`
+)
+
+// ServiceRevision is a struct used to represent the `services_history` table in the database.
+// A revision is the state of a service for one of it's row versions, it is current until ValidTo.
+type ServiceRevision struct {
+	ServiceID   int        `db:"service_id" json:"service_id"`
+	Revision    int64      `db:"revision" json:"revision"`
+	Name        string     `db:"name" json:"name"`
+	Description string     `db:"description" json:"description"`
+	ValidFrom   time.Time  `db:"valid_from" json:"valid_from"`
+	ValidTo     *time.Time `db:"valid_to" json:"valid_to,omitempty"`
+
+	// Diff are the fields which changed since the previous revision
+	Diff            map[string]AuditChange `db:"-" json:"diff"`
+	VersionsAdded   []string               `db:"-" json:"versions_added"`
+	VersionsRemoved []string               `db:"-" json:"versions_removed"`
+}
+
+// ServiceVersionHistory is a struct used to represent the `service_versions_history` table in the database.
+// RemovedIn is 0 while the version exists or when it was deleted along with it's service.
+type ServiceVersionHistory struct {
+	SvID      int        `db:"sv_id" json:"sv_id"`
+	ServiceID int        `db:"service_id" json:"service_id"`
+	Version   string     `db:"version" json:"version"`
+	Changelog string     `db:"changelog" json:"changelog"`
+	AddedIn   int64      `db:"added_in" json:"added_in"`
+	RemovedIn int64      `db:"removed_in" json:"removed_in,omitempty"`
+	ValidFrom time.Time  `db:"valid_from" json:"valid_from"`
+	ValidTo   *time.Time `db:"valid_to" json:"valid_to,omitempty"`
+}
+
+// recordServiceRevision records the current state of the service as a new revision within the transaction of the change.
+// The service versions added and removed by the change are recorded in the history of the versions.
+func recordServiceRevision(ctx context.Context, tx *sqlx.Tx, serviceID int, added []*ServiceVersion, removed []int) error {
+	// Timestamps are stored with a microsecond precision
+	at := time.Now().UTC().Truncate(time.Microsecond)
+
+	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCloseServiceRevision, map[string]interface{}{
+		"service_id": serviceID,
+		"at":         at,
+	})
+	if err != nil {
+		log.Error("error building service revision update query", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		log.Error("error closing service revision", zap.Error(err))
+		return err
+	}
+
+	q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertServiceRevision, map[string]interface{}{
+		"service_id": serviceID,
+		"at":         at,
+	})
+	if err != nil {
+		log.Error("error building service revision insert query", zap.Error(err))
+		return err
+	}
+
+	var revision int64
+	err = tx.QueryRowxContext(ctx, q, args...).Scan(&revision)
+	if err != nil {
+		log.Error("error inserting service revision", zap.Error(err))
+		return err
+	}
+
+	for _, sv := range added {
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertServiceVersionHistory, map[string]interface{}{
+			"sv_id":      sv.SvID,
+			"service_id": serviceID,
+			"version":    sv.Version,
+			"changelog":  sv.Changelog,
+			"revision":   revision,
+			"at":         at,
+		})
+		if err != nil {
+			log.Error("error building service version history insert query", zap.Error(err))
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("error inserting service version history", zap.Error(err))
+			return err
+		}
+	}
+
+	for _, svID := range removed {
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryRemoveServiceVersionHistory, map[string]interface{}{
+			"sv_id":    svID,
+			"revision": revision,
+			"at":       at,
+		})
+		if err != nil {
+			log.Error("error building service version history update query", zap.Error(err))
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("error removing service version history", zap.Error(err))
+			return err
+		}
+	}
+
+	return nil
+}
+
+// closeServiceHistory ends the current revision of a deleted service along with it's versions
+func closeServiceHistory(ctx context.Context, tx *sqlx.Tx, serviceID int) error {
+	at := time.Now().UTC().Truncate(time.Microsecond)
+
+	for _, query := range []string{queryCloseServiceRevision, queryCloseServiceVersionsHistory} {
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), query, map[string]interface{}{
+			"service_id": serviceID,
+			"at":         at,
+		})
+		if err != nil {
+			log.Error("error building service history update query", zap.Error(err))
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("error closing service history", zap.Error(err))
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetServiceAsOf is used to get a service with all it's versions as they were at the given time.
+// It is empty when the service did not exist at that time.
+func GetServiceAsOf(ctx context.Context, serviceID int, userUUID uuid.UUID, asOf time.Time) ([]ServiceWithVersions, error) {
+	var service []ServiceWithVersions
+
+	err := db.NamedSelectContext(ctx, &service, queryGetServiceAsOf, map[string]interface{}{
+		"user_uuid":  userUUID,
+		"service_id": serviceID,
+		"as_of":      asOf.UTC(),
+	})
+	if err != nil {
+		log.Error("Error while fetching service as of", zap.Error(err))
+		return nil, err
+	}
+
+	return service, nil
+}
+
+// GetServiceHistory is used to fetch the revisions of a service, each with the changes since the previous revision
+func GetServiceHistory(ctx context.Context, serviceID int, userUUID uuid.UUID) ([]ServiceRevision, error) {
+	var revisions []ServiceRevision
+
+	err := db.NamedSelectContext(ctx, &revisions, querySelectServiceRevisions, map[string]interface{}{
+		"user_uuid":  userUUID,
+		"service_id": serviceID,
+	})
+	if err != nil {
+		log.Error("Error while fetching service revisions", zap.Error(err))
+		return nil, err
+	}
+
+	if len(revisions) == 0 {
+		return nil, ErrServiceNotFound
+	}
+
+	var versions []ServiceVersionHistory
+
+	err = db.NamedSelectContext(ctx, &versions, querySelectServiceVersionsHistory, map[string]interface{}{
+		"service_id": serviceID,
+	})
+	if err != nil {
+		log.Error("Error while fetching service versions history", zap.Error(err))
+		return nil, err
+	}
+
+	DiffServiceRevisions(revisions, versions)
+
+	return revisions, nil
+}
+
+// DiffServiceRevisions fills the changes of each revision since the previous one, the revisions are sorted
+func DiffServiceRevisions(revisions []ServiceRevision, versions []ServiceVersionHistory) {
+	for i := range revisions {
+		revision := &revisions[i]
+		revision.Diff = map[string]AuditChange{}
+		revision.VersionsAdded = []string{}
+		revision.VersionsRemoved = []string{}
+
+		if i > 0 {
+			previous := revisions[i-1]
+			if previous.Name != revision.Name {
+				revision.Diff["name"] = AuditChange{Before: previous.Name, After: revision.Name}
+			}
+			if previous.Description != revision.Description {
+				revision.Diff["description"] = AuditChange{Before: previous.Description, After: revision.Description}
+			}
+		}
+
+		for _, version := range versions {
+			if version.AddedIn == revision.Revision {
+				revision.VersionsAdded = append(revision.VersionsAdded, version.Version)
+			}
+			if version.RemovedIn == revision.Revision {
+				revision.VersionsRemoved = append(revision.VersionsRemoved, version.Version)
+			}
+		}
+	}
+}
+
+// RevertService is used to restore the name, the description and the versions a service had in a previous revision.
+// The versions which were added since are deleted along with their artifacts and the removed versions are created again.
+// The revert is a new revision, it only applies to the given row version of the service unless it is 0.
+func (service *Service) RevertService(ctx context.Context, revision int64) error {
+	// The row version of the service is replaced by the revert, the transaction may be run again
+	rowVersion := service.RowVersion
+
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		before, err := getServiceState(ctx, tx, service.ServiceID)
+		if err != nil || before.UserUUID != service.UserUUID {
+			if err == nil || err == ErrServiceNotFound {
+				log.Info("service does not exist")
+				return ErrServiceNotFound
+			}
+			return err
+		}
+
+		if rowVersion != 0 && before.RowVersion != rowVersion {
+			log.Info("service was modified")
+			return ErrServiceModified
+		}
+
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryGetServiceRevision, map[string]interface{}{
+			"service_id": service.ServiceID,
+			"user_uuid":  service.UserUUID,
+			"revision":   revision,
+		})
+		if err != nil {
+			log.Error("error building service revision fetch query", zap.Error(err))
+			return err
+		}
+
+		var target ServiceRevision
+		err = tx.GetContext(ctx, &target, q, args...)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				log.Info("service revision does not exist")
+				return ErrServiceRevisionNotFound
+			}
+			log.Error("error querying service revision", zap.Error(err))
+			return err
+		}
+
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), querySelectServiceVersionsOfRevision, map[string]interface{}{
+			"service_id": service.ServiceID,
+			"revision":   revision,
+		})
+		if err != nil {
+			log.Error("error building service versions history query", zap.Error(err))
+			return err
+		}
+
+		var targetVersions []ServiceVersionHistory
+		err = tx.SelectContext(ctx, &targetVersions, q, args...)
+		if err != nil {
+			log.Error("error querying service versions history", zap.Error(err))
+			return err
+		}
+
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), querySelectServiceVersionsState, map[string]interface{}{
+			"service_id": service.ServiceID,
+		})
+		if err != nil {
+			log.Error("error building service versions fetch query", zap.Error(err))
+			return err
+		}
+
+		var currentVersions []ServiceVersion
+		err = tx.SelectContext(ctx, &currentVersions, q, args...)
+		if err != nil {
+			log.Error("error querying service versions", zap.Error(err))
+			return err
+		}
+
+		keep := make(map[string]bool, len(targetVersions))
+		for _, version := range targetVersions {
+			keep[version.Version] = true
+		}
+
+		// Deleting the versions added since the revision
+		var removed []int
+		for _, sv := range currentVersions {
+			if keep[sv.Version] {
+				delete(keep, sv.Version)
+				continue
+			}
+
+			err = deleteServiceVersion(ctx, tx, sv.SvID)
+			if err != nil {
+				return err
+			}
+			removed = append(removed, sv.SvID)
+
+			err = recordEvent(ctx, tx, service.UserUUID, service.ServiceID, EventVersionDeleted, map[string]interface{}{
+				"service_id": service.ServiceID,
+				"sv_id":      sv.SvID,
+			})
+			if err != nil {
+				return err
+			}
+
+			err = recordAudit(ctx, tx, service.UserUUID, EventVersionDeleted, AuditTargetVersion, strconv.Itoa(sv.SvID), versionEventData(&sv), nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Creating the versions removed since the revision, the versions left in keep
+		var added []*ServiceVersion
+		for _, version := range targetVersions {
+			if !keep[version.Version] {
+				continue
+			}
+
+			sv := &ServiceVersion{
+				Version:   version.Version,
+				Changelog: version.Changelog,
+				ServiceID: service.ServiceID,
+				CreatedAt: version.ValidFrom,
+			}
+
+			err = insertServiceVersionRow(ctx, tx, sv)
+			if err != nil {
+				return err
+			}
+			added = append(added, sv)
+
+			err = recordEvent(ctx, tx, service.UserUUID, service.ServiceID, EventVersionCreated, versionEventData(sv))
+			if err != nil {
+				return err
+			}
+
+			err = recordAudit(ctx, tx, service.UserUUID, EventVersionCreated, AuditTargetVersion, strconv.Itoa(sv.SvID), nil, versionEventData(sv))
+			if err != nil {
+				return err
+			}
+		}
+
+		// Updating the service is the new revision
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryUpdateService, map[string]interface{}{
+			"service_id":  service.ServiceID,
+			"name":        target.Name,
+			"description": target.Description,
+			"row_version": before.RowVersion,
+		})
+		if err != nil {
+			log.Error("error building service update query", zap.Error(err))
+			return err
+		}
+
+		err = tx.QueryRowxContext(ctx, q, args...).Scan(&service.RowVersion)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				log.Info("service was modified")
+				return ErrServiceModified
+			}
+			// The name of the revision is used by another service of the user
+			if database.IsUniqueViolation(err) {
+				log.Info("service with same name exists")
+				return ErrServiceExists
+			}
+			log.Error("error reverting service", zap.Error(err))
+			return err
+		}
+
+		err = recordServiceRevision(ctx, tx, service.ServiceID, added, removed)
+		if err != nil {
+			return err
+		}
+
+		service.Name = target.Name
+		service.Description = target.Description
+
+		err = recordEvent(ctx, tx, service.UserUUID, service.ServiceID, EventServiceUpdated, serviceEventData(service))
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, tx, service.UserUUID, AuditServiceReverted, AuditTargetService, strconv.Itoa(service.ServiceID), serviceEventData(before), map[string]interface{}{
+			"service_id":  service.ServiceID,
+			"name":        service.Name,
+			"description": service.Description,
+			"revision":    revision,
+		})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
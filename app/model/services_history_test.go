@@ -0,0 +1,46 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffServiceRevisions(t *testing.T) {
+	revisions := []ServiceRevision{
+		{Revision: 1, Name: "billing", Description: "Bills the customers"},
+		{Revision: 2, Name: "billing", Description: "Bills the customers"},
+		{Revision: 3, Name: "payments", Description: "Bills the customers"},
+		{Revision: 4, Name: "payments", Description: "Bills the customers"},
+	}
+	versions := []ServiceVersionHistory{
+		{SvID: 1, Version: "v1.0.0", AddedIn: 2, RemovedIn: 4},
+		{SvID: 2, Version: "v1.1.0", AddedIn: 4},
+	}
+
+	DiffServiceRevisions(revisions, versions)
+
+	assert.Empty(t, revisions[0].Diff)
+	assert.Equal(t, []string{"v1.0.0"}, revisions[1].VersionsAdded)
+	assert.Equal(t, map[string]AuditChange{"name": {Before: "billing", After: "payments"}}, revisions[2].Diff)
+	assert.Empty(t, revisions[2].VersionsAdded)
+	assert.Equal(t, []string{"v1.1.0"}, revisions[3].VersionsAdded)
+	assert.Equal(t, []string{"v1.0.0"}, revisions[3].VersionsRemoved)
+}
+
+// TestQueryGetServiceAsOf is used to test whether the index is used to query a service at a point in time
+func TestQueryGetServiceAsOf(t *testing.T) {
+	setupTest()
+
+	plan := queryPlan(t, queryGetServiceAsOf, map[string]interface{}{
+		"user_uuid":  uuid.New(),
+		"service_id": 1,
+		"as_of":      time.Now(),
+	})
+
+	if !usesIndex(plan, "Index Scan") {
+		t.Error("Expected index scan but index is not being used")
+	}
+}
@@ -2,122 +2,195 @@ package model
 
 import (
 	"context"
-	"strings"
+	"crypto/sha256"
+	"fmt"
+	"sync"
 	"testing"
+
+	database "github.com/ZiyanK/service-catalog-api/app/db"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
 )
 
+// countRows is used to count the rows of a table matching the condition
+func countRows(t *testing.T, table, condition string, args ...interface{}) int {
+	var count int
+	if err := db.Sqlx.Get(&count, db.Sqlx.Rebind("SELECT COUNT(1) FROM "+table+" WHERE "+condition), args...); err != nil {
+		t.Fatal("Failed to count rows:", err)
+	}
+	return count
+}
+
+// TestServiceChangesAreAtomic is used to test that nothing is written when a statement of a change fails
+func TestServiceChangesAreAtomic(t *testing.T) {
+	setupTest()
+	ctx := context.Background()
+
+	service := &Service{Name: "atomic-" + uuid.NewString(), Description: "before", UserUUID: userUUID}
+	assert.NoError(t, service.CreateService(ctx))
+	assert.NoError(t, (&ServiceVersion{ServiceID: service.ServiceID, Version: "1.0.0"}).CreateServiceVersion(ctx, userUUID))
+
+	before, err := GetServiceByID(ctx, service.ServiceID, userUUID)
+	assert.NoError(t, err)
+
+	events := countRows(t, "outbox_events", "service_id = ?", service.ServiceID)
+	revisions := countRows(t, "services_history", "service_id = ?", service.ServiceID)
+
+	// The audit entry is the last statement of every change
+	injectFailure(t, "audit_events", "INSERT")
+
+	// Case fail: The service is not created
+	name := "atomic-" + uuid.NewString()
+	assert.Error(t, (&Service{Name: name, UserUUID: userUUID}).CreateService(ctx))
+	assert.Equal(t, 0, countRows(t, "services", "name = ?", name))
+
+	// Case fail: The service is not updated
+	assert.Error(t, (&Service{ServiceID: service.ServiceID, Name: "updated", UserUUID: userUUID, RowVersion: before.RowVersion}).UpdateService(ctx))
+
+	current, err := GetServiceByID(ctx, service.ServiceID, userUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, before.Name, current.Name)
+	assert.Equal(t, before.RowVersion, current.RowVersion)
+
+	// Case fail: The service and it's versions are not deleted
+	assert.Error(t, (&Service{ServiceID: service.ServiceID, UserUUID: userUUID}).DeleteService(ctx))
+	assert.Equal(t, 1, countRows(t, "services", "service_id = ?", service.ServiceID))
+	assert.Equal(t, 1, countRows(t, "service_versions", "service_id = ?", service.ServiceID))
+
+	// Case fail: The service is not reverted
+	assert.Error(t, (&Service{ServiceID: service.ServiceID, UserUUID: userUUID}).RevertService(ctx, 1))
+	assert.Equal(t, 1, countRows(t, "service_versions", "service_id = ?", service.ServiceID))
+
+	// None of the changes recorded an event or a revision
+	assert.Equal(t, events, countRows(t, "outbox_events", "service_id = ?", service.ServiceID))
+	assert.Equal(t, revisions, countRows(t, "services_history", "service_id = ?", service.ServiceID))
+}
+
 // Test query indexes
 
 // TestQueryGetServiceByNameAndUserUUID is used to test whether the index is used to query the service by it's name and user uuid
 func TestQueryGetServiceByNameAndUserUUID(t *testing.T) {
 	setupTest()
 
-	rows, err := db.NamedExplainQuery(context.Background(), appendExplain(queryCheckServiceByNameAndUserUUID), map[string]interface{}{
+	plan := queryPlan(t, queryCheckServiceByNameAndUserUUID, map[string]interface{}{
 		"name":      "backend",
 		"user_uuid": userUUID,
 	})
-	if err != nil {
-		t.Fatal("Failed to execute query:", err)
-	}
-	defer rows.Close()
-
-	// Analyze the query execution plan
-	var plan string
-	var indexUsed bool
-	for rows.Next() {
-		if err := rows.Scan(&plan); err != nil {
-			t.Fatal("Failed to scan row:", err)
-		}
 
-		// Check if the index is being used
-		if strings.Contains(plan, "Index Scan") {
-			log.Info("Index scan being used")
-			indexUsed = true
-			break
-		}
-	}
-
-	if !indexUsed {
+	if !usesIndex(plan, "Index Scan") {
 		t.Error("Expected index scan but index is not being used")
 	}
-
-	if err := rows.Err(); err != nil {
-		t.Fatal("Error iterating over rows:", err)
-	}
 }
 
 // TestQueryGetServiceByIDAndUserUUID is used to test whether the index is used to query the service by it's service id and user uuid
 func TestQueryGetServiceByIDAndUserUUID(t *testing.T) {
 	setupTest()
 
-	rows, err := db.NamedExplainQuery(context.Background(), appendExplain(queryCheckServiceByIDAndUserUUID), map[string]interface{}{
+	plan := queryPlan(t, queryCheckServiceByIDAndUserUUID, map[string]interface{}{
 		"service_id": 1,
 		"user_uuid":  userUUID,
 	})
-	if err != nil {
-		t.Fatal("Failed to execute query:", err)
-	}
-	defer rows.Close()
-
-	// Analyze the query execution plan
-	var plan string
-	var indexUsed bool
-	for rows.Next() {
-		if err := rows.Scan(&plan); err != nil {
-			t.Fatal("Failed to scan row:", err)
-		}
 
-		// Check if the index is being used
-		if strings.Contains(plan, "Index Scan") {
-			log.Info("Index scan being used")
-			indexUsed = true
-			break
-		}
-	}
-
-	if !indexUsed {
+	if !usesIndex(plan, "Index Scan") {
 		t.Error("Expected index scan but index is not being used")
 	}
-
-	if err := rows.Err(); err != nil {
-		t.Fatal("Error iterating over rows:", err)
-	}
 }
 
 // TestQueryGetService is used to test whether the index is used to query the service and it's version by it's service id and user uuid
 func TestQueryGetService(t *testing.T) {
 	setupTest()
 
-	rows, err := db.NamedExplainQuery(context.Background(), appendExplain(queryGetService), map[string]interface{}{
+	plan := queryPlan(t, queryGetService, map[string]interface{}{
 		"service_id": 1,
 		"user_uuid":  userUUID,
 	})
-	if err != nil {
-		t.Fatal("Failed to execute query:", err)
-	}
-	defer rows.Close()
-
-	// Analyze the query execution plan
-	var plan string
-	var indexUsed bool
-	for rows.Next() {
-		if err := rows.Scan(&plan); err != nil {
-			t.Fatal("Failed to scan row:", err)
-		}
 
-		// Check if the index is being used
-		if strings.Contains(plan, "Index Scan") {
-			log.Info("Index scan being used")
-			indexUsed = true
-			break
-		}
+	if !usesIndex(plan, "Index Scan") {
+		t.Error("Expected index scan but index is not being used")
 	}
+}
 
-	if !indexUsed {
-		t.Error("Expected index scan but index is not being used")
+// concurrently runs fn n times at once and returns the errors
+func concurrently(n int, fn func() error) []error {
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = fn()
+		}(i)
 	}
+	close(start)
+	wg.Wait()
 
-	if err := rows.Err(); err != nil {
-		t.Fatal("Error iterating over rows:", err)
+	return errs
+}
+
+// TestCreateServiceConcurrently is used to test that a single service is created when the same service is created at once
+func TestCreateServiceConcurrently(t *testing.T) {
+	setupTest()
+	ctx := context.Background()
+
+	name := "concurrent-" + uuid.NewString()
+	errs := concurrently(8, func() error {
+		return (&Service{Name: name, UserUUID: userUUID}).CreateService(ctx)
+	})
+
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		assert.Equal(t, ErrServiceExists, err)
 	}
+	assert.Equal(t, 1, created)
+	assert.Equal(t, 1, countRows(t, "services", "name = ?", name))
+}
+
+// TestServicesUniqueConstraints is used to test that the schema rejects duplicated services and versions
+func TestServicesUniqueConstraints(t *testing.T) {
+	setupTest()
+	ctx := context.Background()
+
+	service := &Service{Name: "unique-" + uuid.NewString(), UserUUID: userUUID}
+	assert.NoError(t, service.CreateService(ctx))
+	assert.NoError(t, (&ServiceVersion{ServiceID: service.ServiceID, Version: "1.0.0"}).CreateServiceVersion(ctx, userUUID))
+
+	// Case fail: The checks of the model are bypassed
+	_, err := db.Sqlx.Exec(db.Sqlx.Rebind(`INSERT INTO services(name, user_uuid) VALUES(?, ?)`), service.Name, userUUID)
+	assert.True(t, database.IsUniqueViolation(err), err)
+
+	_, err = db.Sqlx.Exec(db.Sqlx.Rebind(`INSERT INTO service_versions(version, service_id) VALUES(?, ?)`), "1.0.0", service.ServiceID)
+	assert.True(t, database.IsUniqueViolation(err), err)
+
+	// Case fail: Renamed to the name of another service
+	other := &Service{Name: "unique-" + uuid.NewString(), UserUUID: userUUID}
+	assert.NoError(t, other.CreateService(ctx))
+	assert.Equal(t, ErrServiceExists, (&Service{ServiceID: other.ServiceID, Name: service.Name, UserUUID: userUUID}).UpdateService(ctx))
+}
+
+// TestDeleteServiceCascades is used to test that the versions of a service and their artifacts and changes are deleted with it
+func TestDeleteServiceCascades(t *testing.T) {
+	setupTest()
+	ctx := context.Background()
+
+	service := &Service{Name: "cascade-" + uuid.NewString(), UserUUID: userUUID}
+	assert.NoError(t, service.CreateService(ctx))
+
+	sv := &ServiceVersion{ServiceID: service.ServiceID, Version: "1.0.0", Changes: []ServiceVersionChange{{Category: "Added", Description: "cascade"}}}
+	assert.NoError(t, sv.CreateServiceVersion(ctx, userUUID))
+
+	artifact := &Artifact{SvID: sv.SvID, Type: ArtifactTypeTarball, URI: "https://example.com/cascade.tgz", Digest: fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(service.Name)))}
+	assert.NoError(t, artifact.CreateArtifact(ctx, userUUID, service.ServiceID))
+
+	assert.NoError(t, (&Service{ServiceID: service.ServiceID, UserUUID: userUUID}).DeleteService(ctx))
+
+	assert.Equal(t, 0, countRows(t, "service_versions", "service_id = ?", service.ServiceID))
+	assert.Equal(t, 0, countRows(t, "service_version_changes", "sv_id = ?", sv.SvID))
+	assert.Equal(t, 0, countRows(t, "service_version_artifacts", "sv_id = ?", sv.SvID))
 }
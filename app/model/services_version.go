@@ -3,16 +3,20 @@ package model
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"strconv"
 	"time"
 
+	database "github.com/ZiyanK/service-catalog-api/app/db"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
 const (
-	queryInsertServiceVersion = `INSERT INTO service_versions(version, changelog, service_id) VALUES (:version, :changelog, :service_id)`
+	queryInsertServiceVersion = `
+	INSERT INTO service_versions(version, changelog, service_id, created_at)
+	VALUES (:version, :changelog, :service_id, COALESCE(:created_at, CURRENT_TIMESTAMP))
+	RETURNING sv_id`
 
 	queryCheckServiceVersionUsingVersion = `
 	SELECT count(1)
@@ -34,6 +38,17 @@ const (
 		AND s.service_id = :service_id
 		AND u.user_uuid = :user_uuid`
 
+	querySelectServiceVersionState = `
+	SELECT sv.sv_id, sv.version, COALESCE(sv.changelog, '') as changelog, sv.service_id
+	FROM service_versions sv
+	WHERE sv.sv_id = :sv_id`
+
+	querySelectServiceVersionsState = `
+	SELECT sv.sv_id, sv.version, COALESCE(sv.changelog, '') as changelog, sv.service_id
+	FROM service_versions sv
+	WHERE sv.service_id = :service_id
+	ORDER BY sv.sv_id`
+
 	queryDeleteServiceVersion = `DELETE FROM service_versions WHERE sv_id = :sv_id`
 )
 
@@ -45,168 +60,281 @@ type ServiceVersion struct {
 	ServiceID int       `db:"service_id" json:"service_id"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+
+	Changes []ServiceVersionChange `db:"-" json:"changes,omitempty"`
 }
 
 // CreateServiceVersion is used to create a new service version for a given service
 func (sv *ServiceVersion) CreateServiceVersion(ctx context.Context, userUUID uuid.UUID) error {
-	tx, err := db.Sqlx.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		// Check if service exists
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceByIDAndUserUUID, map[string]interface{}{
+			"service_id": sv.ServiceID,
+			"user_uuid":  userUUID,
+		})
+		if err != nil {
+			log.Error("error building service fetch query", zap.Error(err))
+			return err
+		}
 
-	// Check if service exists
-	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceByIDAndUserUUID, map[string]interface{}{
-		"service_id": sv.ServiceID,
-		"user_uuid":  userUUID,
-	})
-	if err != nil {
-		log.Error("error building service fetch query", zap.Error(err))
-		tx.Rollback()
-		return err
-	}
+		var serviceCount int
 
-	var serviceCount int
+		err = tx.GetContext(ctx, &serviceCount, q, args...)
+		if err != nil && err != sql.ErrNoRows {
+			log.Error("error querying user", zap.Error(err))
+			return err
+		}
 
-	err = tx.GetContext(ctx, &serviceCount, q, args...)
-	if err != nil && err != sql.ErrNoRows {
-		log.Error("error querying user", zap.Error(err))
-		return err
-	}
+		// If service was not found
+		if serviceCount == 0 {
+			log.Info("service does not exist")
+			return ErrServiceNotFound
+		}
 
-	// If service was not found
-	if serviceCount == 0 {
-		tx.Rollback()
-		log.Info("service does not exist")
-		return errors.New("service does not exist")
-	}
+		// Check if service version with same version exists
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceVersionUsingVersion, map[string]interface{}{
+			"version":    sv.Version,
+			"service_id": sv.ServiceID,
+			"user_uuid":  userUUID,
+		})
+		if err != nil {
+			log.Error("error building service version check query", zap.Error(err))
+			return err
+		}
 
-	// Check if service version with same version exists
-	q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceVersionUsingVersion, map[string]interface{}{
-		"version":    sv.Version,
-		"service_id": sv.ServiceID,
-		"user_uuid":  userUUID,
+		var svCount int
+
+		err = tx.GetContext(ctx, &svCount, q, args...)
+		if err != nil && err != sql.ErrNoRows {
+			log.Error("error querying service version", zap.Error(err))
+			return err
+		}
+
+		// If service version with same version exists, svCount is 1
+		if svCount == 1 {
+			log.Info("service with same version exists")
+			return ErrServiceVersionExists
+		}
+
+		// If service version with same version doesn't exist
+		err = insertServiceVersion(ctx, tx, sv)
+		if err != nil {
+			return err
+		}
+
+		err = recordEvent(ctx, tx, userUUID, sv.ServiceID, EventVersionCreated, versionEventData(sv))
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, tx, userUUID, EventVersionCreated, AuditTargetVersion, strconv.Itoa(sv.SvID), nil, versionEventData(sv))
+		if err != nil {
+			return err
+		}
+
+		return nil
 	})
+}
+
+// insertServiceVersion inserts the service version along with it's changes using the given transaction.
+// The service gets a new revision with the version.
+func insertServiceVersion(ctx context.Context, tx *sqlx.Tx, sv *ServiceVersion) error {
+	err := insertServiceVersionRow(ctx, tx, sv)
 	if err != nil {
-		log.Error("error building service version check query", zap.Error(err))
-		tx.Rollback()
 		return err
 	}
 
-	var svCount int
-
-	err = tx.GetContext(ctx, &svCount, q, args...)
-	if err != nil && err != sql.ErrNoRows {
-		log.Error("error querying service version", zap.Error(err))
+	err = bumpServiceRowVersion(ctx, tx, sv.ServiceID)
+	if err != nil {
 		return err
 	}
 
-	// If service version with same version exists, svCount is 1
-	if svCount == 1 {
-		tx.Rollback()
-		log.Info("service with same version exists")
-		return errors.New("service version exists")
+	return recordServiceRevision(ctx, tx, sv.ServiceID, []*ServiceVersion{sv}, nil)
+}
+
+// insertServiceVersionRow inserts the service version along with it's changes without changing the revision of the service
+func insertServiceVersionRow(ctx context.Context, tx *sqlx.Tx, sv *ServiceVersion) error {
+	// The creation time is only set when importing versions released in the past
+	var createdAt *time.Time
+	if !sv.CreatedAt.IsZero() {
+		createdAt = &sv.CreatedAt
 	}
 
-	// If service version with same version doesn't exist
-	q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertServiceVersion, map[string]interface{}{
+	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertServiceVersion, map[string]interface{}{
 		"version":    sv.Version,
 		"changelog":  sv.Changelog,
 		"service_id": sv.ServiceID,
+		"created_at": createdAt,
 	})
 	if err != nil {
 		log.Error("error building service insert query", zap.Error(err))
-		tx.Rollback()
 		return err
 	}
 
-	result, err := tx.ExecContext(ctx, q, args...)
+	// A version created concurrently for the service violates the unique constraint
+	err = tx.QueryRowxContext(ctx, q, args...).Scan(&sv.SvID)
+	if database.IsUniqueViolation(err) {
+		log.Info("service with same version exists")
+		return ErrServiceVersionExists
+	}
 	if err != nil {
 		log.Error("error inserting service", zap.Error(err))
-		tx.Rollback()
 		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		tx.Rollback()
-		log.Error("Error while getting no. of rows affected", zap.Error(err))
-		return err
-	}
+	for i := range sv.Changes {
+		change := &sv.Changes[i]
+		change.SvID = sv.SvID
+		change.Position = i
 
-	// Service version created
-	if rowsAffected == 0 {
-		tx.Rollback()
-		log.Info("no row were added")
-		return errors.New("error while creating service")
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertServiceVersionChange, change)
+		if err != nil {
+			log.Error("error building service version change insert query", zap.Error(err))
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("error inserting service version change", zap.Error(err))
+			return err
+		}
 	}
 
-	tx.Commit()
 	return nil
 }
 
 // DeleteServiceVersion is used to delete a particular service version for a given service
 func DeleteServiceVersion(ctx context.Context, userUUID uuid.UUID, serviceID, svID int) error {
-	tx, err := db.Sqlx.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		// Check if service_version belongs to user
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceVersionUsingSVID, map[string]interface{}{
+			"sv_id":      svID,
+			"service_id": serviceID,
+			"user_uuid":  userUUID,
+		})
+		if err != nil {
+			log.Error("error building service version check query", zap.Error(err))
+			return err
+		}
 
-	// Check if service_version belongs to user
-	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceVersionUsingSVID, map[string]interface{}{
-		"sv_id":      svID,
-		"service_id": serviceID,
-		"user_uuid":  userUUID,
-	})
-	if err != nil {
-		log.Error("error building service version check query", zap.Error(err))
-		tx.Rollback()
-		return err
-	}
+		var count int
 
-	var count int
+		err = tx.GetContext(ctx, &count, q, args...)
+		if err != nil && err != sql.ErrNoRows {
+			log.Error("error querying service version", zap.Error(err))
+			return err
+		}
 
-	err = tx.GetContext(ctx, &count, q, args...)
-	if err != nil && err != sql.ErrNoRows {
-		log.Error("error querying service version", zap.Error(err))
-		return err
-	}
+		if count == 0 {
+			log.Info("service version does not exist")
+			return ErrServiceVersionNotFound
+		}
 
-	if count == 0 {
-		tx.Rollback()
-		log.Info("service version does not exist")
-		return errors.New("service version does not exist")
-	}
+		// If service version is present, it's current state is recorded in the audit log
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), querySelectServiceVersionState, map[string]interface{}{
+			"sv_id": svID,
+		})
+		if err != nil {
+			log.Error("error building service version fetch query", zap.Error(err))
+			return err
+		}
+
+		var before ServiceVersion
+
+		err = tx.GetContext(ctx, &before, q, args...)
+		if err != nil {
+			log.Error("error querying service version", zap.Error(err))
+			return err
+		}
+
+		err = deleteServiceVersion(ctx, tx, svID)
+		if err != nil {
+			return err
+		}
+
+		err = bumpServiceRowVersion(ctx, tx, serviceID)
+		if err != nil {
+			return err
+		}
+
+		err = recordServiceRevision(ctx, tx, serviceID, nil, []int{svID})
+		if err != nil {
+			return err
+		}
+
+		err = recordEvent(ctx, tx, userUUID, serviceID, EventVersionDeleted, map[string]interface{}{
+			"service_id": serviceID,
+			"sv_id":      svID,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, tx, userUUID, EventVersionDeleted, AuditTargetVersion, strconv.Itoa(svID), versionEventData(&before), nil)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
 
-	// If service version is present
-	q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryDeleteServiceVersion, map[string]interface{}{
+// deleteServiceVersion deletes the service version along with it's artifacts and changes using the given transaction
+func deleteServiceVersion(ctx context.Context, tx *sqlx.Tx, svID int) error {
+	// The artifacts and changes of the version are deleted along with it
+	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryDeleteServiceVersion, map[string]interface{}{
 		"sv_id": svID,
 	})
 	if err != nil {
 		log.Error("error building service version delete query", zap.Error(err))
-		tx.Rollback()
 		return err
 	}
 
 	result, err := tx.ExecContext(ctx, q, args...)
 	if err != nil {
 		log.Error("error deleting service", zap.Error(err))
-		tx.Rollback()
 		return err
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		tx.Rollback()
 		log.Error("Error while getting no. of rows affected", zap.Error(err))
 		return err
 	}
 
-	if rowsAffected == 1 {
-		tx.Commit()
-		return nil
+	if rowsAffected != 1 {
+		log.Info("no row were deleted")
+		return ErrServiceVersionNotFound
 	}
 
-	tx.Rollback()
-	log.Info("no row were deleted")
-	return errors.New("no rows deleted")
+	return nil
+}
+
+// bumpServiceRowVersion increments the row version of a service when it's versions change using the given transaction
+func bumpServiceRowVersion(ctx context.Context, tx *sqlx.Tx, serviceID int) error {
+	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryBumpServiceRowVersion, map[string]interface{}{
+		"service_id": serviceID,
+	})
+	if err != nil {
+		log.Error("error building service row version query", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		log.Error("error updating service row version", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// versionEventData is the data of the event recorded when a service version is created
+func versionEventData(sv *ServiceVersion) map[string]interface{} {
+	return map[string]interface{}{
+		"service_id": sv.ServiceID,
+		"sv_id":      sv.SvID,
+		"version":    sv.Version,
+		"changelog":  sv.Changelog,
+	}
 }
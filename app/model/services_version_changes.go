@@ -0,0 +1,241 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	queryInsertServiceVersionChange = `
+	INSERT INTO service_version_changes(sv_id, category, description, position)
+	VALUES (:sv_id, :category, :description, :position)`
+
+	queryGetServiceVersions = `
+	SELECT sv.sv_id, sv.version, COALESCE(sv.changelog, '') as changelog, sv.service_id, sv.created_at, sv.updated_at
+	FROM service_versions sv
+	JOIN services s ON s.service_id = sv.service_id
+	WHERE
+		s.service_id = :service_id
+		AND s.user_uuid = :user_uuid
+	ORDER BY sv.created_at DESC, sv.sv_id DESC`
+
+	queryGetServiceVersionChanges = `
+	SELECT c.change_id, c.sv_id, c.category, c.description, c.position
+	FROM service_version_changes c
+	JOIN service_versions sv ON sv.sv_id = c.sv_id
+	JOIN services s ON s.service_id = sv.service_id
+	WHERE
+		s.service_id = :service_id
+		AND s.user_uuid = :user_uuid
+	ORDER BY c.sv_id, c.position`
+
+	queryGetVersionsOfServices = `
+	SELECT sv.sv_id, sv.version, COALESCE(sv.changelog, '') as changelog, sv.service_id, sv.created_at, sv.updated_at
+	FROM service_versions sv
+	JOIN services s ON s.service_id = sv.service_id
+	WHERE
+		s.service_id IN (:service_ids)
+		AND s.user_uuid = :user_uuid
+	ORDER BY sv.created_at DESC, sv.sv_id DESC`
+
+	queryGetVersionChangesOfServices = `
+	SELECT c.change_id, c.sv_id, c.category, c.description, c.position
+	FROM service_version_changes c
+	JOIN service_versions sv ON sv.sv_id = c.sv_id
+	JOIN services s ON s.service_id = sv.service_id
+	WHERE
+		s.service_id IN (:service_ids)
+		AND s.user_uuid = :user_uuid
+	ORDER BY c.sv_id, c.position`
+)
+
+// ServiceVersionChange is a struct used to represent the `service_version_changes` table in the database
+type ServiceVersionChange struct {
+	ChangeID    int    `db:"change_id" json:"-"`
+	SvID        int    `db:"sv_id" json:"-"`
+	Category    string `db:"category" json:"category"`
+	Description string `db:"description" json:"description"`
+	Position    int    `db:"position" json:"-"`
+}
+
+// ServiceExists is used to check if a service exists for a user
+func ServiceExists(ctx context.Context, serviceID int, userUUID uuid.UUID) (bool, error) {
+	var count int
+
+	err := db.NamedGetContext(ctx, &count, queryCheckServiceByIDAndUserUUID, map[string]interface{}{
+		"service_id": serviceID,
+		"user_uuid":  userUUID,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		log.Error("Error while checking service", zap.Error(err))
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// GetServiceVersions is used to fetch all the versions of a given service along with their changes
+func GetServiceVersions(ctx context.Context, serviceID int, userUUID uuid.UUID) ([]ServiceVersion, error) {
+	var versions []ServiceVersion
+
+	params := map[string]interface{}{
+		"service_id": serviceID,
+		"user_uuid":  userUUID,
+	}
+
+	err := db.NamedSelectContext(ctx, &versions, queryGetServiceVersions, params)
+	if err != nil {
+		log.Error("Error while fetching service versions", zap.Error(err))
+		return nil, err
+	}
+
+	var changes []ServiceVersionChange
+
+	err = db.NamedSelectContext(ctx, &changes, queryGetServiceVersionChanges, params)
+	if err != nil {
+		log.Error("Error while fetching service version changes", zap.Error(err))
+		return nil, err
+	}
+
+	changesBySvID := make(map[int][]ServiceVersionChange)
+	for _, change := range changes {
+		changesBySvID[change.SvID] = append(changesBySvID[change.SvID], change)
+	}
+
+	for i := range versions {
+		versions[i].Changes = changesBySvID[versions[i].SvID]
+	}
+
+	return versions, nil
+}
+
+// GetVersionsOfServices is used to fetch the versions of several services of a user at once along with their changes.
+// The versions are grouped by service id, services without versions are not present in the map.
+func GetVersionsOfServices(ctx context.Context, serviceIDs []int, userUUID uuid.UUID) (map[int][]ServiceVersion, error) {
+	versionsByServiceID := make(map[int][]ServiceVersion)
+	if len(serviceIDs) == 0 {
+		return versionsByServiceID, nil
+	}
+
+	params := map[string]interface{}{
+		"service_ids": serviceIDs,
+		"user_uuid":   userUUID,
+	}
+
+	var versions []ServiceVersion
+
+	err := db.NamedSelectInContext(ctx, &versions, queryGetVersionsOfServices, params)
+	if err != nil {
+		log.Error("Error while fetching versions of services", zap.Error(err))
+		return nil, err
+	}
+
+	var changes []ServiceVersionChange
+
+	err = db.NamedSelectInContext(ctx, &changes, queryGetVersionChangesOfServices, params)
+	if err != nil {
+		log.Error("Error while fetching version changes of services", zap.Error(err))
+		return nil, err
+	}
+
+	changesBySvID := make(map[int][]ServiceVersionChange)
+	for _, change := range changes {
+		changesBySvID[change.SvID] = append(changesBySvID[change.SvID], change)
+	}
+
+	for _, version := range versions {
+		version.Changes = changesBySvID[version.SvID]
+		versionsByServiceID[version.ServiceID] = append(versionsByServiceID[version.ServiceID], version)
+	}
+
+	return versionsByServiceID, nil
+}
+
+// ImportServiceVersions is used to create multiple versions for a given service in a single transaction.
+// It returns the versions that were created and the versions that were skipped as they already exist.
+func ImportServiceVersions(ctx context.Context, userUUID uuid.UUID, serviceID int, versions []ServiceVersion) ([]string, []string, error) {
+	var created, skipped []string
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		created, skipped = nil, nil
+
+		// Check if service exists
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceByIDAndUserUUID, map[string]interface{}{
+			"service_id": serviceID,
+			"user_uuid":  userUUID,
+		})
+		if err != nil {
+			log.Error("error building service fetch query", zap.Error(err))
+			return err
+		}
+
+		var serviceCount int
+
+		err = tx.GetContext(ctx, &serviceCount, q, args...)
+		if err != nil && err != sql.ErrNoRows {
+			log.Error("error querying service", zap.Error(err))
+			return err
+		}
+
+		if serviceCount == 0 {
+			log.Info("service does not exist")
+			return ErrServiceNotFound
+		}
+
+		for _, sv := range versions {
+			sv.ServiceID = serviceID
+
+			q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckServiceVersionUsingVersion, map[string]interface{}{
+				"version":    sv.Version,
+				"service_id": serviceID,
+				"user_uuid":  userUUID,
+			})
+			if err != nil {
+				log.Error("error building service version check query", zap.Error(err))
+				return err
+			}
+
+			var svCount int
+
+			err = tx.GetContext(ctx, &svCount, q, args...)
+			if err != nil && err != sql.ErrNoRows {
+				log.Error("error querying service version", zap.Error(err))
+				return err
+			}
+
+			if svCount > 0 {
+				skipped = append(skipped, sv.Version)
+				continue
+			}
+
+			err = insertServiceVersion(ctx, tx, &sv)
+			if err != nil {
+				return err
+			}
+
+			err = recordEvent(ctx, tx, userUUID, sv.ServiceID, EventVersionCreated, versionEventData(&sv))
+			if err != nil {
+				return err
+			}
+
+			err = recordAudit(ctx, tx, userUUID, EventVersionCreated, AuditTargetVersion, strconv.Itoa(sv.SvID), nil, versionEventData(&sv))
+			if err != nil {
+				return err
+			}
+
+			created = append(created, sv.Version)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return created, skipped, nil
+}
@@ -0,0 +1,17 @@
+package model
+
+import "testing"
+
+// TestQueryGetServiceVersionChanges is used to test whether the index is used to query the changes of the versions of a service
+func TestQueryGetServiceVersionChanges(t *testing.T) {
+	setupTest()
+
+	plan := queryPlan(t, queryGetServiceVersionChanges, map[string]interface{}{
+		"service_id": 1,
+		"user_uuid":  userUUID,
+	})
+
+	if !usesIndex(plan, "Index Scan") {
+		t.Error("Expected index scan but index is not being used")
+	}
+}
@@ -2,84 +2,84 @@ package model
 
 import (
 	"context"
-	"strings"
 	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
 )
 
 // TestQueryCheckServiceVersionUsingVersion is used to test whether the index is used to query the service version by it's version and service id
 func TestQueryCheckServiceVersionUsingVersion(t *testing.T) {
 	setupTest()
 
-	rows, err := db.NamedExplainQuery(context.Background(), appendExplain(queryCheckServiceVersionUsingVersion), map[string]interface{}{
+	plan := queryPlan(t, queryCheckServiceVersionUsingVersion, map[string]interface{}{
 		"version":    "v1.0.0",
 		"service_id": 1,
 		"user_uuid":  userUUID,
 	})
-	if err != nil {
-		t.Fatal("Failed to execute query:", err)
-	}
-	defer rows.Close()
-
-	// Analyze the query execution plan
-	var plan string
-	var indexUsed bool
-	for rows.Next() {
-		if err := rows.Scan(&plan); err != nil {
-			t.Fatal("Failed to scan row:", err)
-		}
 
-		// Check if the index is being used
-		if strings.Contains(plan, "Index Scan") {
-			log.Info("Index scan being used")
-			indexUsed = true
-			break
-		}
-	}
-
-	if !indexUsed {
+	if !usesIndex(plan, "Index Scan") {
 		t.Error("Expected index scan but index is not being used")
 	}
-
-	if err := rows.Err(); err != nil {
-		t.Fatal("Error iterating over rows:", err)
-	}
 }
 
 // TestQueryCheckServiceVersionUsingSVIR is used to test whether the index is used to query the service version by it's service version id and service id
 func TestQueryCheckServiceVersionUsingSVIR(t *testing.T) {
 	setupTest()
 
-	rows, err := db.NamedExplainQuery(context.Background(), appendExplain(queryCheckServiceVersionUsingSVID), map[string]interface{}{
+	plan := queryPlan(t, queryCheckServiceVersionUsingSVID, map[string]interface{}{
 		"sv_id":      1,
 		"service_id": 1,
 		"user_uuid":  userUUID,
 	})
-	if err != nil {
-		t.Fatal("Failed to execute query:", err)
-	}
-	defer rows.Close()
-
-	// Analyze the query execution plan
-	var plan string
-	var indexUsed bool
-	for rows.Next() {
-		if err := rows.Scan(&plan); err != nil {
-			t.Fatal("Failed to scan row:", err)
-		}
 
-		// Check if the index is being used
-		if strings.Contains(plan, "Index Scan") {
-			log.Info("Index scan being used")
-			indexUsed = true
-			break
-		}
-	}
-
-	if !indexUsed {
+	if !usesIndex(plan, "Index Scan") {
 		t.Error("Expected index scan but index is not being used")
 	}
+}
 
-	if err := rows.Err(); err != nil {
-		t.Fatal("Error iterating over rows:", err)
+// TestCreateServiceVersionIsAtomic is used to test that the version is not created when it's event fails to be recorded
+func TestCreateServiceVersionIsAtomic(t *testing.T) {
+	setupTest()
+	ctx := context.Background()
+
+	service := &Service{Name: "atomic-" + uuid.NewString(), UserUUID: userUUID}
+	assert.NoError(t, service.CreateService(ctx))
+
+	before, err := GetServiceByID(ctx, service.ServiceID, userUUID)
+	assert.NoError(t, err)
+
+	injectFailure(t, "outbox_events", "INSERT")
+
+	// Case fail: The version is not created and the service is unchanged
+	assert.Error(t, (&ServiceVersion{ServiceID: service.ServiceID, Version: "1.0.0"}).CreateServiceVersion(ctx, userUUID))
+	assert.Equal(t, 0, countRows(t, "service_versions", "service_id = ?", service.ServiceID))
+
+	current, err := GetServiceByID(ctx, service.ServiceID, userUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, before.RowVersion, current.RowVersion)
+}
+
+// TestCreateServiceVersionConcurrently is used to test that a single version is created when the same version is created at once
+func TestCreateServiceVersionConcurrently(t *testing.T) {
+	setupTest()
+	ctx := context.Background()
+
+	service := &Service{Name: "concurrent-" + uuid.NewString(), UserUUID: userUUID}
+	assert.NoError(t, service.CreateService(ctx))
+
+	errs := concurrently(8, func() error {
+		return (&ServiceVersion{ServiceID: service.ServiceID, Version: "1.0.0"}).CreateServiceVersion(ctx, userUUID)
+	})
+
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		assert.Equal(t, ErrServiceVersionExists, err)
 	}
+	assert.Equal(t, 1, created)
+	assert.Equal(t, 1, countRows(t, "service_versions", "service_id = ?", service.ServiceID))
 }
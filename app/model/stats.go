@@ -0,0 +1,47 @@
+package model
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+const (
+	querySelectCatalogStats = `
+	SELECT
+		(SELECT COUNT(1) FROM users) AS users,
+		(SELECT COUNT(1) FROM services) AS services,
+		(SELECT COUNT(1) FROM service_versions) AS service_versions,
+		(SELECT COUNT(1) FROM service_version_artifacts) AS artifacts,
+		(SELECT COUNT(1) FROM webhooks) AS webhooks,
+		(SELECT COUNT(1) FROM outbox_events WHERE published_at IS NULL AND dead_at IS NULL) AS unpublished_events,
+		(SELECT COUNT(1) FROM outbox_events WHERE dead_at IS NOT NULL) AS dead_events,
+		(SELECT COUNT(1) FROM webhook_deliveries WHERE status = :pending) AS pending_deliveries`
+)
+
+// CatalogStats is a struct used to represent the size of the catalog across the users, it is exported as metrics
+type CatalogStats struct {
+	Users             int64 `db:"users"`
+	Services          int64 `db:"services"`
+	ServiceVersions   int64 `db:"service_versions"`
+	Artifacts         int64 `db:"artifacts"`
+	Webhooks          int64 `db:"webhooks"`
+	UnpublishedEvents int64 `db:"unpublished_events"`
+	DeadEvents        int64 `db:"dead_events"`
+	PendingDeliveries int64 `db:"pending_deliveries"`
+}
+
+// GetCatalogStats is used to count the resources of the catalog
+func GetCatalogStats(ctx context.Context) (*CatalogStats, error) {
+	var stats CatalogStats
+
+	err := db.NamedGetContext(ctx, &stats, querySelectCatalogStats, map[string]interface{}{
+		"pending": DeliveryStatusPending,
+	})
+	if err != nil {
+		log.Error("Error while counting the catalog", zap.Error(err))
+		return nil, err
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCatalogStats(t *testing.T) {
+	setupTest()
+	ctx := context.Background()
+
+	before, err := GetCatalogStats(ctx)
+	assert.NoError(t, err)
+
+	service := &Service{Name: "stats-" + uuid.NewString(), UserUUID: userUUID}
+	assert.NoError(t, service.CreateService(ctx))
+
+	sv := &ServiceVersion{ServiceID: service.ServiceID, Version: "1.0.0", Changes: []ServiceVersionChange{{Category: "Added", Description: "stats"}}}
+	assert.NoError(t, sv.CreateServiceVersion(ctx, userUUID))
+
+	after, err := GetCatalogStats(ctx)
+	assert.NoError(t, err)
+
+	assert.Equal(t, before.Users, after.Users)
+	assert.Equal(t, before.Services+1, after.Services)
+	assert.Equal(t, before.ServiceVersions+1, after.ServiceVersions)
+	assert.Equal(t, before.Artifacts, after.Artifacts)
+}
@@ -0,0 +1,515 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	database "github.com/ZiyanK/service-catalog-api/app/db"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	queryInsertTeam = `
+	INSERT INTO teams(name) VALUES(:name)
+	RETURNING team_id, created_at, updated_at`
+
+	// The creator of a team is a member which accepted, the other users are invited until they accept
+	queryInsertTeamCreator = `INSERT INTO team_members(team_id, user_uuid, accepted_at) VALUES(:team_id, :user_uuid, CURRENT_TIMESTAMP)`
+
+	queryInsertTeamInvitation = `INSERT INTO team_members(team_id, user_uuid) VALUES(:team_id, :user_uuid)`
+
+	queryAcceptTeamInvitation = `
+	UPDATE team_members SET accepted_at = CURRENT_TIMESTAMP
+	WHERE team_id = :team_id AND user_uuid = :user_uuid AND accepted_at IS NULL`
+
+	// The invitations of the user are listed along with it's teams
+	querySelectTeams = `
+	SELECT t.team_id, t.name, t.created_at, t.updated_at, tm.accepted_at IS NULL AS pending
+	FROM teams t
+	JOIN team_members tm ON tm.team_id = t.team_id
+	WHERE tm.user_uuid = :user_uuid
+	ORDER BY t.team_id`
+
+	queryGetTeam = `
+	SELECT t.team_id, t.name, t.created_at, t.updated_at
+	FROM teams t
+	JOIN team_members tm ON tm.team_id = t.team_id
+	WHERE t.team_id = :team_id AND tm.user_uuid = :user_uuid AND tm.accepted_at IS NOT NULL`
+
+	querySelectTeamMembers = `
+	SELECT u.email
+	FROM team_members tm
+	JOIN users u ON u.user_uuid = tm.user_uuid
+	WHERE tm.team_id = :team_id AND tm.accepted_at IS NOT NULL
+	ORDER BY u.email`
+
+	querySelectTeamInvitations = `
+	SELECT u.email
+	FROM team_members tm
+	JOIN users u ON u.user_uuid = tm.user_uuid
+	WHERE tm.team_id = :team_id AND tm.accepted_at IS NULL
+	ORDER BY u.email`
+
+	querySelectTeamServices = `SELECT s.service_id FROM services s WHERE s.team_id = :team_id ORDER BY s.service_id`
+
+	queryGetTeamMember = `
+	SELECT tm.accepted_at FROM team_members tm
+	WHERE tm.team_id = :team_id AND tm.user_uuid = :user_uuid`
+
+	queryGetTeamUserByEmail = `SELECT user_uuid FROM users WHERE email = :email`
+
+	queryDeleteTeamMember = `DELETE FROM team_members WHERE team_id = :team_id AND user_uuid = :user_uuid`
+
+	// The services of a member leaving the team are detached from it
+	queryDetachTeamMemberServices = `UPDATE services SET team_id = NULL WHERE team_id = :team_id AND user_uuid = :user_uuid`
+
+	queryAttachTeamService = `
+	UPDATE services SET team_id = :team_id
+	WHERE service_id = :service_id AND user_uuid = :user_uuid`
+
+	queryDetachTeamService = `
+	UPDATE services SET team_id = NULL
+	WHERE service_id = :service_id AND user_uuid = :user_uuid AND team_id = :team_id`
+)
+
+// Team is a struct used to represent the `teams` table in the database
+type Team struct {
+	TeamID int    `db:"team_id" json:"team_id"`
+	Name   string `db:"name" json:"name"`
+	// Pending is true when the user is invited to the team and did not accept yet
+	Pending     bool      `db:"pending" json:"pending,omitempty"`
+	Members     []string  `db:"-" json:"members,omitempty"`
+	Invitations []string  `db:"-" json:"invitations,omitempty"`
+	Services    []int     `db:"-" json:"services,omitempty"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// CreateTeam is used to create a new team, the user creating it is it's first member
+func (team *Team) CreateTeam(ctx context.Context, userUUID uuid.UUID) error {
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertTeam, map[string]interface{}{
+			"name": team.Name,
+		})
+		if err != nil {
+			log.Error("error building team insert query", zap.Error(err))
+			return err
+		}
+
+		err = tx.QueryRowxContext(ctx, q, args...).StructScan(team)
+		if err != nil {
+			log.Error("Error while creating team", zap.Error(err))
+			return err
+		}
+
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertTeamCreator, map[string]interface{}{
+			"team_id":   team.TeamID,
+			"user_uuid": userUUID,
+		})
+		if err != nil {
+			log.Error("error building team member insert query", zap.Error(err))
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("Error while adding team member", zap.Error(err))
+			return err
+		}
+
+		err = recordAudit(ctx, tx, userUUID, AuditTeamCreated, AuditTargetTeam, strconv.Itoa(team.TeamID), nil, map[string]interface{}{
+			"team_id": team.TeamID,
+			"name":    team.Name,
+		})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// GetTeams is used to fetch all the teams the user is a member of, along with the teams it is invited to
+func GetTeams(ctx context.Context, userUUID uuid.UUID) ([]Team, error) {
+	var teams []Team
+
+	err := db.NamedSelectContext(ctx, &teams, querySelectTeams, map[string]interface{}{
+		"user_uuid": userUUID,
+	})
+	if err != nil {
+		log.Error("Error while fetching teams", zap.Error(err))
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+// GetTeam is used to fetch a team of the user along with the emails of it's members, the emails of the invited users
+// and it's services. ErrTeamNotFound is returned if the user is not a member.
+func GetTeam(ctx context.Context, userUUID uuid.UUID, teamID int) (*Team, error) {
+	var team Team
+
+	params := map[string]interface{}{
+		"team_id":   teamID,
+		"user_uuid": userUUID,
+	}
+
+	err := db.NamedGetContext(ctx, &team, queryGetTeam, params)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTeamNotFound
+		}
+		log.Error("Error while fetching team", zap.Error(err))
+		return nil, err
+	}
+
+	err = db.NamedSelectContext(ctx, &team.Members, querySelectTeamMembers, params)
+	if err != nil {
+		log.Error("Error while fetching team members", zap.Error(err))
+		return nil, err
+	}
+
+	err = db.NamedSelectContext(ctx, &team.Invitations, querySelectTeamInvitations, params)
+	if err != nil {
+		log.Error("Error while fetching team invitations", zap.Error(err))
+		return nil, err
+	}
+
+	err = db.NamedSelectContext(ctx, &team.Services, querySelectTeamServices, params)
+	if err != nil {
+		log.Error("Error while fetching team services", zap.Error(err))
+		return nil, err
+	}
+
+	return &team, nil
+}
+
+// AddTeamMember is used by a member of a team to invite the user with the given email to it, the user joins the team
+// once it accepts. Nothing is done for an email which is not registered so that the response does not tell whether it is.
+func AddTeamMember(ctx context.Context, userUUID uuid.UUID, teamID int, email string) error {
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		exists, accepted, err := getTeamMember(ctx, tx, teamID, userUUID)
+		if err != nil {
+			return err
+		}
+
+		if !exists || !accepted {
+			log.Info("user is not a member of the team")
+			return ErrTeamNotFound
+		}
+
+		memberUUID, err := getTeamUserByEmail(ctx, tx, email)
+		if err != nil {
+			return err
+		}
+
+		if memberUUID == uuid.Nil {
+			log.Info("invited user does not exist")
+			return nil
+		}
+
+		exists, _, err = getTeamMember(ctx, tx, teamID, memberUUID)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			log.Info("user is already a member of the team")
+			return ErrTeamMemberExists
+		}
+
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertTeamInvitation, map[string]interface{}{
+			"team_id":   teamID,
+			"user_uuid": memberUUID,
+		})
+		if err != nil {
+			log.Error("error building team invitation insert query", zap.Error(err))
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			if database.IsUniqueViolation(err) {
+				return ErrTeamMemberExists
+			}
+			log.Error("Error while inviting team member", zap.Error(err))
+			return err
+		}
+
+		err = recordAudit(ctx, tx, userUUID, AuditTeamMemberInvited, AuditTargetTeam, strconv.Itoa(teamID), nil, map[string]interface{}{
+			"team_id": teamID,
+			"email":   email,
+		})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// AcceptTeamInvitation is used by an invited user to join the team, ErrTeamNotFound is returned when it is not invited
+func AcceptTeamInvitation(ctx context.Context, userUUID uuid.UUID, teamID int) error {
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryAcceptTeamInvitation, map[string]interface{}{
+			"team_id":   teamID,
+			"user_uuid": userUUID,
+		})
+		if err != nil {
+			log.Error("error building team invitation accept query", zap.Error(err))
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("Error while accepting team invitation", zap.Error(err))
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.Error("Error while getting no. of rows affected", zap.Error(err))
+			return err
+		}
+
+		if rowsAffected == 0 {
+			log.Info("user is not invited to the team")
+			return ErrTeamNotFound
+		}
+
+		err = recordAudit(ctx, tx, userUUID, AuditTeamMemberAdded, AuditTargetTeam, strconv.Itoa(teamID), nil, map[string]interface{}{
+			"team_id":   teamID,
+			"user_uuid": userUUID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// RemoveTeamMember is used by a member of a team to remove the user with the given email from it or to cancel it's
+// invitation. A user can remove itself, which declines an invitation. The services of the user are detached from the team.
+func RemoveTeamMember(ctx context.Context, userUUID uuid.UUID, teamID int, email string) error {
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		exists, accepted, err := getTeamMember(ctx, tx, teamID, userUUID)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			log.Info("user is not a member of the team")
+			return ErrTeamNotFound
+		}
+
+		memberUUID, err := getTeamUserByEmail(ctx, tx, email)
+		if err != nil {
+			return err
+		}
+
+		// An invited user can only decline it's own invitation
+		if !accepted && memberUUID != userUUID {
+			log.Info("user is not a member of the team")
+			return ErrTeamNotFound
+		}
+
+		if memberUUID == uuid.Nil {
+			log.Info("team member does not exist")
+			return ErrTeamMemberNotFound
+		}
+
+		params := map[string]interface{}{
+			"team_id":   teamID,
+			"user_uuid": memberUUID,
+		}
+
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryDeleteTeamMember, params)
+		if err != nil {
+			log.Error("error building team member delete query", zap.Error(err))
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("Error while removing team member", zap.Error(err))
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.Error("Error while getting no. of rows affected", zap.Error(err))
+			return err
+		}
+
+		if rowsAffected == 0 {
+			log.Info("team member does not exist")
+			return ErrTeamMemberNotFound
+		}
+
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryDetachTeamMemberServices, params)
+		if err != nil {
+			log.Error("error building team member services detach query", zap.Error(err))
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("Error while detaching team member services", zap.Error(err))
+			return err
+		}
+
+		err = recordAudit(ctx, tx, userUUID, AuditTeamMemberRemoved, AuditTargetTeam, strconv.Itoa(teamID), map[string]interface{}{
+			"team_id": teamID,
+			"email":   email,
+		}, nil)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// AddTeamService is used by the owner of a service to attach it to one of it's teams, the webhooks of the team then get
+// the events of the service. A service is attached to a single team.
+func AddTeamService(ctx context.Context, userUUID uuid.UUID, teamID, serviceID int) error {
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		exists, accepted, err := getTeamMember(ctx, tx, teamID, userUUID)
+		if err != nil {
+			return err
+		}
+
+		if !exists || !accepted {
+			log.Info("user is not a member of the team")
+			return ErrTeamNotFound
+		}
+
+		err = updateServiceTeam(ctx, tx, queryAttachTeamService, userUUID, teamID, serviceID)
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, tx, userUUID, AuditTeamServiceAdded, AuditTargetTeam, strconv.Itoa(teamID), nil, map[string]interface{}{
+			"team_id":    teamID,
+			"service_id": serviceID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// RemoveTeamService is used by the owner of a service to detach it from a team
+func RemoveTeamService(ctx context.Context, userUUID uuid.UUID, teamID, serviceID int) error {
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		err := updateServiceTeam(ctx, tx, queryDetachTeamService, userUUID, teamID, serviceID)
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, tx, userUUID, AuditTeamServiceRemoved, AuditTargetTeam, strconv.Itoa(teamID), map[string]interface{}{
+			"team_id":    teamID,
+			"service_id": serviceID,
+		}, nil)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// updateServiceTeam attaches or detaches a service of the user using the given transaction, ErrServiceNotFound is returned
+// when the user does not own the service
+func updateServiceTeam(ctx context.Context, tx *sqlx.Tx, query string, userUUID uuid.UUID, teamID, serviceID int) error {
+	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), query, map[string]interface{}{
+		"team_id":    teamID,
+		"service_id": serviceID,
+		"user_uuid":  userUUID,
+	})
+	if err != nil {
+		log.Error("error building service team update query", zap.Error(err))
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		log.Error("Error while updating service team", zap.Error(err))
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Error("Error while getting no. of rows affected", zap.Error(err))
+		return err
+	}
+
+	if rowsAffected == 0 {
+		log.Info("service does not exist")
+		return ErrServiceNotFound
+	}
+
+	return nil
+}
+
+// getTeamMember checks if the user is a member of the team using the given transaction, and if it accepted to be
+func getTeamMember(ctx context.Context, tx *sqlx.Tx, teamID int, userUUID uuid.UUID) (bool, bool, error) {
+	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryGetTeamMember, map[string]interface{}{
+		"team_id":   teamID,
+		"user_uuid": userUUID,
+	})
+	if err != nil {
+		log.Error("error building team member fetch query", zap.Error(err))
+		return false, false, err
+	}
+
+	var acceptedAt sql.NullTime
+
+	err = tx.GetContext(ctx, &acceptedAt, q, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, false, nil
+		}
+		log.Error("Error while fetching team member", zap.Error(err))
+		return false, false, err
+	}
+
+	return true, acceptedAt.Valid, nil
+}
+
+// isTeamMember checks if the user is a member of the team which accepted to be using the given transaction
+func isTeamMember(ctx context.Context, tx *sqlx.Tx, teamID int, userUUID uuid.UUID) (bool, error) {
+	exists, accepted, err := getTeamMember(ctx, tx, teamID, userUUID)
+	return exists && accepted, err
+}
+
+// getTeamUserByEmail returns the uuid of the user with the email using the given transaction, uuid.Nil when there is none
+func getTeamUserByEmail(ctx context.Context, tx *sqlx.Tx, email string) (uuid.UUID, error) {
+	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryGetTeamUserByEmail, map[string]interface{}{
+		"email": email,
+	})
+	if err != nil {
+		log.Error("error building user fetch query", zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	var userUUID uuid.UUID
+
+	err = tx.GetContext(ctx, &userUUID, q, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, nil
+		}
+		log.Error("Error while fetching user by email", zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	return userUUID, nil
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamWebhooks(t *testing.T) {
+	setupTest()
+	ctx := context.Background()
+
+	member := User{UserUUID: uuid.New(), Email: uuid.NewString() + "@example.com", Password: "secret"}
+	assert.NoError(t, member.CreateUser(ctx))
+	outsider := User{UserUUID: uuid.New(), Email: uuid.NewString() + "@example.com", Password: "secret"}
+	assert.NoError(t, outsider.CreateUser(ctx))
+
+	team := &Team{Name: "platform"}
+	assert.NoError(t, team.CreateTeam(ctx, userUUID))
+	assert.NoError(t, AddTeamMember(ctx, userUUID, team.TeamID, member.Email))
+
+	// Case fail: The member is already invited to the team
+	assert.ErrorIs(t, AddTeamMember(ctx, userUUID, team.TeamID, member.Email), ErrTeamMemberExists)
+
+	// Case: Inviting an email which is not registered gets the same response as a registered one
+	assert.NoError(t, AddTeamMember(ctx, userUUID, team.TeamID, uuid.NewString()+"@example.com"))
+
+	// Case fail: Only the members of the team can invite users
+	assert.ErrorIs(t, AddTeamMember(ctx, outsider.UserUUID, team.TeamID, outsider.Email), ErrTeamNotFound)
+
+	// Case fail: Only the invited user can accept it's invitation
+	assert.ErrorIs(t, AcceptTeamInvitation(ctx, outsider.UserUUID, team.TeamID), ErrTeamNotFound)
+
+	// Case fail: An invited user is not a member until it accepts
+	_, err := GetTeam(ctx, member.UserUUID, team.TeamID)
+	assert.ErrorIs(t, err, ErrTeamNotFound)
+	assert.ErrorIs(t, AddTeamMember(ctx, member.UserUUID, team.TeamID, outsider.Email), ErrTeamNotFound)
+
+	assert.NoError(t, AcceptTeamInvitation(ctx, member.UserUUID, team.TeamID))
+	got, err := GetTeam(ctx, member.UserUUID, team.TeamID)
+	assert.NoError(t, err)
+	assert.Contains(t, got.Members, member.Email)
+
+	// Case fail: A webhook of a team the user is not a member of
+	hook := &Webhook{UserUUID: outsider.UserUUID, TeamID: &team.TeamID, URL: "https://hooks.example.com/team", Secret: "secret", Events: WebhookEvents{EventAll}}
+	assert.ErrorIs(t, hook.CreateWebhook(ctx), ErrTeamNotFound)
+
+	hook.UserUUID = userUUID
+	assert.NoError(t, hook.CreateWebhook(ctx))
+
+	// Case: The members of the team share the webhook, the other users do not see it
+	_, err = GetWebhook(ctx, member.UserUUID, hook.WebhookID)
+	assert.NoError(t, err)
+	_, err = GetWebhook(ctx, outsider.UserUUID, hook.WebhookID)
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+
+	// Case: The webhook of the team does not get the events of the services of a member which are not in the team
+	eventID := uuid.New()
+	assert.NoError(t, EnqueueWebhookDeliveries(ctx, member.UserUUID, nil, eventID, EventServiceCreated, "{}"))
+	assert.Equal(t, 0, countRows(t, "webhook_deliveries", "webhook_id = ? AND event_id = ?", hook.WebhookID, eventID))
+
+	// Case fail: Only the owner of a service can add it to a team
+	service := &Service{Name: "team-" + uuid.NewString(), UserUUID: member.UserUUID}
+	assert.NoError(t, service.CreateService(ctx))
+	assert.ErrorIs(t, AddTeamService(ctx, userUUID, team.TeamID, service.ServiceID), ErrServiceNotFound)
+
+	// Case: The events of the services of the team are recorded with the team and delivered to it's webhooks
+	assert.NoError(t, AddTeamService(ctx, member.UserUUID, team.TeamID, service.ServiceID))
+	service.Description = "updated"
+	assert.NoError(t, service.UpdateService(ctx))
+	assert.Equal(t, 1, countRows(t, "outbox_events", "service_id = ? AND event = ? AND team_id = ?", service.ServiceID, EventServiceUpdated, team.TeamID))
+
+	eventID = uuid.New()
+	assert.NoError(t, EnqueueWebhookDeliveries(ctx, member.UserUUID, &team.TeamID, eventID, EventServiceUpdated, "{}"))
+	assert.Equal(t, 1, countRows(t, "webhook_deliveries", "webhook_id = ? AND event_id = ?", hook.WebhookID, eventID))
+
+	// Case: The services of a removed member leave the team with it
+	assert.NoError(t, RemoveTeamMember(ctx, userUUID, team.TeamID, member.Email))
+	assert.Equal(t, 0, countRows(t, "services", "service_id = ? AND team_id IS NOT NULL", service.ServiceID))
+
+	_, err = GetWebhook(ctx, member.UserUUID, hook.WebhookID)
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+
+	// Case fail: Removing an email which is not registered or not in the team gets the same response
+	assert.ErrorIs(t, RemoveTeamMember(ctx, userUUID, team.TeamID, member.Email), ErrTeamMemberNotFound)
+	assert.ErrorIs(t, RemoveTeamMember(ctx, userUUID, team.TeamID, uuid.NewString()+"@example.com"), ErrTeamMemberNotFound)
+}
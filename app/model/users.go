@@ -3,7 +3,6 @@ package model
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,7 +26,7 @@ const (
 	WHERE email = :email`
 
 	queryUpdateUserByID = `
-	UPDATE users SET email = :email, updated_at = NOW()
+	UPDATE users SET email = :email, updated_at = CURRENT_TIMESTAMP
 	WHERE user_uuid = :user_uuid`
 )
 
@@ -42,65 +41,59 @@ type User struct {
 
 // CreateUser is used to create a new user in the database
 func (user *User) CreateUser(ctx context.Context) error {
-	tx, err := db.Sqlx.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckUserExist, map[string]interface{}{
+			"email": user.Email,
+		})
+		if err != nil {
+			log.Error("error building user fetch query", zap.Error(err))
+			return err
+		}
 
-	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckUserExist, map[string]interface{}{
-		"email": user.Email,
-	})
-	if err != nil {
-		log.Error("error building user fetch query", zap.Error(err))
-		tx.Rollback()
-		return err
-	}
+		var count int
 
-	var count int
+		err = tx.GetContext(ctx, &count, q, args...)
+		if err != nil && err != sql.ErrNoRows {
+			log.Error("error querying user", zap.Error(err))
+			return err
+		}
 
-	err = tx.GetContext(ctx, &count, q, args...)
-	if err != nil && err != sql.ErrNoRows {
-		tx.Rollback()
-		log.Error("error querying user", zap.Error(err))
-		return err
-	}
+		if count == 1 {
+			log.Info("user with mail exists")
+			return ErrEmailExists
+		}
 
-	if count == 1 {
-		tx.Rollback()
-		log.Info("user with mail exists")
-		return errors.New("mail exists")
-	}
+		// If mail doesn't exist
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertUser, user)
+		if err != nil {
+			log.Error("error building user insert query", zap.Error(err))
+			return err
+		}
 
-	// If mail doesn't exist
-	q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertUser, user)
-	if err != nil {
-		tx.Rollback()
-		log.Error("error building user insert query", zap.Error(err))
-		return err
-	}
+		result, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("error inserting user", zap.Error(err))
+			return err
+		}
 
-	result, err := tx.ExecContext(ctx, q, args...)
-	if err != nil {
-		tx.Rollback()
-		log.Error("error inserting user", zap.Error(err))
-		return err
-	}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.Error("Error while getting no. of rows affected", zap.Error(err))
+			return err
+		}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		tx.Rollback()
-		log.Error("Error while getting no. of rows affected", zap.Error(err))
-		return err
-	}
+		if rowsAffected == 0 {
+			log.Info("no row was updated")
+			return err
+		}
 
-	if rowsAffected == 0 {
-		tx.Rollback()
-		log.Info("no row was updated")
-		return err
-	}
+		err = recordAudit(ctx, tx, user.UserUUID, AuditUserCreated, AuditTargetUser, user.UserUUID.String(), nil, userAuditState(user.Email))
+		if err != nil {
+			return err
+		}
 
-	tx.Commit()
-	return nil
+		return nil
+	})
 }
 
 // GetUserByEmail is used to fetch a user using the email
@@ -112,7 +105,7 @@ func GetUserByEmail(ctx context.Context, email string) (*User, error) {
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, sql.ErrNoRows
+			return nil, ErrUserNotFound
 		}
 		log.Error("Error while fetching user by email", zap.Error(err))
 		return nil, err
@@ -131,7 +124,7 @@ func GetUserByID(ctx context.Context, userUUID uuid.UUID) (*User, error) {
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Info("No user found for id", zap.Any("uuid", userUUID))
-			return nil, sql.ErrNoRows
+			return nil, ErrUserNotFound
 		}
 		log.Error("Error while fetching user by user_uuid", zap.Error(err))
 		return nil, err
@@ -142,66 +135,84 @@ func GetUserByID(ctx context.Context, userUUID uuid.UUID) (*User, error) {
 
 // UpdateUser is used to update the user email
 func UpdateUser(ctx context.Context, updatedEmail string, userUUID uuid.UUID) error {
-	tx, err := db.Sqlx.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryGetUserByEmail, map[string]interface{}{
+			"email": updatedEmail,
+		})
+		if err != nil {
+			log.Error("error building user fetch query", zap.Error(err))
+			return err
+		}
 
-	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryGetUserByEmail, map[string]interface{}{
-		"email": updatedEmail,
-	})
-	if err != nil {
-		log.Error("error building user fetch query", zap.Error(err))
-		tx.Rollback()
-		return err
-	}
+		var user User
 
-	var user User
+		err = tx.GetContext(ctx, &user, q, args...)
+		if err != nil && err != sql.ErrNoRows {
+			log.Error("error querying user", zap.Error(err))
+			return err
+		}
 
-	err = tx.GetContext(ctx, &user, q, args...)
-	if err != nil && err != sql.ErrNoRows {
-		tx.Rollback()
-		log.Error("error querying user", zap.Error(err))
-		return err
-	}
+		if user.Email != "" {
+			log.Info("user with mail exists")
+			return ErrEmailExists
+		}
 
-	if user.Email != "" {
-		tx.Rollback()
-		log.Info("user with mail exists")
-		return errors.New("mail exists")
-	}
+		// The current email is recorded in the audit log
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryGetUserByID, map[string]interface{}{
+			"user_uuid": userUUID,
+		})
+		if err != nil {
+			log.Error("error building user fetch query", zap.Error(err))
+			return err
+		}
 
-	// If mail doesn't exist
-	q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryUpdateUserByID, map[string]interface{}{
-		"email":     updatedEmail,
-		"user_uuid": userUUID,
-	})
-	if err != nil {
-		tx.Rollback()
-		log.Error("error building user update query", zap.Error(err))
-		return err
-	}
+		var current User
 
-	result, err := tx.ExecContext(ctx, q, args...)
-	if err != nil {
-		tx.Rollback()
-		log.Error("error updating user", zap.Error(err))
-		return err
-	}
+		err = tx.GetContext(ctx, &current, q, args...)
+		if err != nil {
+			log.Error("error querying user", zap.Error(err))
+			return err
+		}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		tx.Rollback()
-		log.Error("Error while getting no. of rows affected", zap.Error(err))
-		return err
-	}
+		// If mail doesn't exist
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryUpdateUserByID, map[string]interface{}{
+			"email":     updatedEmail,
+			"user_uuid": userUUID,
+		})
+		if err != nil {
+			log.Error("error building user update query", zap.Error(err))
+			return err
+		}
 
-	if rowsAffected == 0 {
-		tx.Rollback()
-		log.Info("no row was updated")
-		return err
-	}
+		result, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("error updating user", zap.Error(err))
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.Error("Error while getting no. of rows affected", zap.Error(err))
+			return err
+		}
+
+		if rowsAffected == 0 {
+			log.Info("no row was updated")
+			return err
+		}
 
-	tx.Commit()
-	return nil
+		err = recordAudit(ctx, tx, userUUID, AuditUserUpdated, AuditTargetUser, userUUID.String(), userAuditState(current.Email), userAuditState(updatedEmail))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// userAuditState is the state of a user recorded in the audit log, the password is never recorded
+func userAuditState(email string) map[string]interface{} {
+	return map[string]interface{}{
+		"email": email,
+	}
 }
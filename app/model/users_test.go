@@ -2,7 +2,11 @@ package model
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	database "github.com/ZiyanK/service-catalog-api/app/db"
@@ -24,30 +28,132 @@ var (
 	config      configuration
 	userUUID    uuid.UUID
 	userUUIDStr string
+
+	setupOnce sync.Once
+	sqliteDir string
 )
 
-func setupTest() {
-	viper.AddConfigPath("../..")
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
+func TestMain(m *testing.M) {
+	code := m.Run()
 
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatal("Error reading config file", zap.String("err", err.Error()))
+	if sqliteDir != "" {
+		os.RemoveAll(sqliteDir)
 	}
+	os.Exit(code)
+}
 
-	if err := viper.Unmarshal(&config); err != nil {
-		log.Fatal("unable to decode into struct", zap.String("err", err.Error()))
+// setupTest connects to the database of the configuration, the tests run against a new SQLite database when there is none
+func setupTest() {
+	setupOnce.Do(func() {
+		viper.AddConfigPath("../..")
+		viper.SetConfigName("config")
+		viper.SetConfigType("yaml")
+
+		if err := viper.ReadInConfig(); err == nil {
+			if err := viper.Unmarshal(&config); err != nil {
+				log.Fatal("unable to decode into struct", zap.String("err", err.Error()))
+			}
+		}
+
+		if config.DSN == "" {
+			setupSQLite()
+			return
+		}
+
+		if err := database.InitConn(config.DSN); err != nil {
+			log.Fatal("Error connecting to db: ", zap.Error(err))
+		}
+	})
+
+	userUUIDStr = "d90f9b49-dcd9-4feb-8250-d013098e45ee"
+	userUUID, _ = uuid.Parse(userUUIDStr)
+}
+
+// setupSQLite creates a SQLite database in a temporary directory and applies the migrations to it
+func setupSQLite() {
+	var err error
+	sqliteDir, err = os.MkdirTemp("", "service-catalog")
+	if err != nil {
+		log.Fatal("Error creating database directory", zap.Error(err))
 	}
 
-	if err := database.InitConn(config.DSN); err != nil {
+	if err := database.InitConn("sqlite://" + filepath.Join(sqliteDir, "catalog.db")); err != nil {
 		log.Fatal("Error connecting to db: ", zap.Error(err))
 	}
-	userUUIDStr = "d90f9b49-dcd9-4feb-8250-d013098e45ee"
-	userUUID, _ = uuid.Parse(userUUIDStr)
+
+	if _, err := db.MigrateUp(context.Background()); err != nil {
+		log.Fatal("Error migrating db: ", zap.Error(err))
+	}
+}
+
+// queryPlan returns the execution plan of a query, the plan of SQLite is the detail of `EXPLAIN QUERY PLAN`
+func queryPlan(t *testing.T, query string, arg interface{}) []string {
+	explain := "EXPLAIN "
+	if db.IsSQLite() {
+		explain = "EXPLAIN QUERY PLAN "
+	}
+
+	rows, err := db.NamedExplainQuery(context.Background(), explain+query, arg)
+	if err != nil {
+		t.Fatal("Failed to execute query:", err)
+	}
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var line string
+		if db.IsSQLite() {
+			var id, parent, notUsed int
+			err = rows.Scan(&id, &parent, &notUsed, &line)
+		} else {
+			err = rows.Scan(&line)
+		}
+		if err != nil {
+			t.Fatal("Failed to scan row:", err)
+		}
+
+		plan = append(plan, line)
+	}
+
+	if err := rows.Err(); err != nil {
+		t.Fatal("Error iterating over rows:", err)
+	}
+
+	return plan
 }
 
-func appendExplain(query string) string {
-	return "EXPLAIN " + query
+// usesIndex reports whether an index is used by the plan, nodes are the PostgreSQL plan nodes expected to use it
+func usesIndex(plan []string, nodes ...string) bool {
+	if db.IsSQLite() {
+		nodes = []string{"USING INDEX", "USING COVERING INDEX", "USING INTEGER PRIMARY KEY", "USING PRIMARY KEY"}
+	}
+
+	for _, line := range plan {
+		for _, node := range nodes {
+			if strings.Contains(line, node) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// injectFailure makes the given statements on a table fail until the end of the test, the failure is a SQLite trigger
+func injectFailure(t *testing.T, table, statement string) {
+	if !db.IsSQLite() {
+		t.Skip("failures are injected with SQLite triggers")
+	}
+
+	trigger := fmt.Sprintf("inject_%v_%v", table, strings.ToLower(statement))
+	_, err := db.Sqlx.Exec(fmt.Sprintf(`CREATE TRIGGER %v BEFORE %v ON %v BEGIN SELECT RAISE(ABORT, 'injected failure'); END`, trigger, statement, table))
+	if err != nil {
+		t.Fatal("Failed to inject failure:", err)
+	}
+
+	t.Cleanup(func() {
+		db.Sqlx.Exec("DROP TRIGGER " + trigger)
+	})
 }
 
 func TestCreateUser(t *testing.T) {
@@ -116,37 +222,12 @@ func TestUpdateUser(t *testing.T) {
 func TestQueryGetUserByEmail(t *testing.T) {
 	setupTest()
 
-	rows, err := db.NamedExplainQuery(context.Background(), appendExplain(queryCheckUserExist), map[string]interface{}{
+	plan := queryPlan(t, queryCheckUserExist, map[string]interface{}{
 		"email": "test@gmail.com",
 	})
-	if err != nil {
-		t.Fatal("Failed to execute query:", err)
-	}
-	defer rows.Close()
 
-	// Analyze the query execution plan
-	var plan string
-	var indexUsed bool
-	for rows.Next() {
-		if err := rows.Scan(&plan); err != nil {
-			t.Fatal("Failed to scan row:", err)
-		}
-
-		// Check if the index is being used
-		if strings.Contains(plan, "Index Only") {
-			log.Info("Index scan being used")
-			indexUsed = true
-			break
-		}
-	}
-
-	if !indexUsed {
+	if !usesIndex(plan, "Index Only") {
 		t.Log("error in index used")
 		t.Error("Expected index scan but index is not being used")
 	}
-
-	if err := rows.Err(); err != nil {
-		t.Log("error in scanning rows")
-		t.Fatal("Error iterating over rows:", err)
-	}
 }
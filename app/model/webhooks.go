@@ -0,0 +1,644 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	queryInsertWebhook = `
+	INSERT INTO webhooks(user_uuid, team_id, url, secret, events) VALUES(:user_uuid, :team_id, :url, :secret, :events)
+	RETURNING webhook_id, active, failure_count, created_at, updated_at`
+
+	// A webhook of a team is shared by all it's members
+	querySelectWebhooks = `
+	SELECT w.webhook_id, w.user_uuid, w.team_id, w.url, w.events, w.active, w.failure_count, w.disabled_at, w.created_at, w.updated_at
+	FROM webhooks w
+	WHERE (w.user_uuid = :user_uuid OR w.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_uuid = :user_uuid AND tm.accepted_at IS NOT NULL))
+	ORDER BY w.webhook_id`
+
+	queryGetWebhook = `
+	SELECT w.webhook_id, w.user_uuid, w.team_id, w.url, w.events, w.active, w.failure_count, w.disabled_at, w.created_at, w.updated_at
+	FROM webhooks w
+	WHERE w.webhook_id = :webhook_id
+		AND (w.user_uuid = :user_uuid OR w.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_uuid = :user_uuid AND tm.accepted_at IS NOT NULL))`
+
+	// The webhooks of the user get the events of it's services, the webhooks of a team get the events of the services of the team
+	querySelectActiveWebhooks = `
+	SELECT w.webhook_id, w.events
+	FROM webhooks w
+	WHERE w.active AND ((w.team_id IS NULL AND w.user_uuid = :user_uuid) OR w.team_id = :team_id)`
+
+	// Enabling a webhook resets it's consecutive failures
+	queryUpdateWebhook = `
+	UPDATE webhooks SET url = :url, events = :events, active = :active,
+		failure_count = CASE WHEN :active THEN 0 ELSE failure_count END,
+		disabled_at = CASE WHEN :active THEN NULL ELSE COALESCE(disabled_at, CURRENT_TIMESTAMP) END,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE webhook_id = :webhook_id
+		AND (user_uuid = :user_uuid OR team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_uuid = :user_uuid AND tm.accepted_at IS NOT NULL))`
+
+	queryDeleteWebhookDeliveries = `DELETE FROM webhook_deliveries WHERE webhook_id = :webhook_id`
+	queryDeleteWebhook           = `DELETE FROM webhooks WHERE webhook_id = :webhook_id`
+
+	queryCheckWebhookDeliveryByEventID = `
+	SELECT COUNT(1) FROM webhook_deliveries d
+	WHERE d.webhook_id = :webhook_id AND d.event_id = :event_id`
+
+	queryInsertWebhookDelivery = `
+	INSERT INTO webhook_deliveries(webhook_id, event_id, event, payload)
+	VALUES(:webhook_id, :event_id, :event, :payload)`
+
+	querySelectWebhookDeliveries = `
+	SELECT d.delivery_id, d.webhook_id, d.event_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at,
+		COALESCE(d.response_status, 0) as response_status, COALESCE(d.response_body, '') as response_body,
+		COALESCE(d.error, '') as error, d.delivered_at, d.created_at, d.updated_at
+	FROM webhook_deliveries d
+	JOIN webhooks w ON w.webhook_id = d.webhook_id
+	WHERE d.webhook_id = :webhook_id
+		AND (w.user_uuid = :user_uuid OR w.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_uuid = :user_uuid AND tm.accepted_at IS NOT NULL))
+	ORDER BY d.delivery_id DESC
+	LIMIT :limit OFFSET :offset`
+
+	// A redelivery is a new delivery of the same event, so receivers can deduplicate it using the event id
+	queryRedeliverWebhookDelivery = `
+	INSERT INTO webhook_deliveries(webhook_id, event_id, event, payload)
+	SELECT d.webhook_id, d.event_id, d.event, d.payload
+	FROM webhook_deliveries d
+	JOIN webhooks w ON w.webhook_id = d.webhook_id
+	WHERE d.delivery_id = :delivery_id AND d.webhook_id = :webhook_id
+		AND (w.user_uuid = :user_uuid OR w.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_uuid = :user_uuid AND tm.accepted_at IS NOT NULL))
+	RETURNING delivery_id, webhook_id, event_id, event, payload, status, attempts, next_attempt_at, created_at, updated_at`
+
+	// The claimed deliveries are leased so that they are not sent twice by concurrent dispatchers
+	queryClaimWebhookDeliveries = `
+	UPDATE webhook_deliveries d SET next_attempt_at = :lease_until
+	FROM webhooks w
+	WHERE w.webhook_id = d.webhook_id AND d.delivery_id IN (
+		SELECT pd.delivery_id
+		FROM webhook_deliveries pd
+		JOIN webhooks pw ON pw.webhook_id = pd.webhook_id
+		WHERE pd.status = 'pending' AND pd.next_attempt_at <= :now AND pw.active
+		ORDER BY pd.next_attempt_at, pd.delivery_id
+		LIMIT :limit
+		FOR UPDATE OF pd SKIP LOCKED
+	)
+	RETURNING d.delivery_id, d.webhook_id, d.event_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at, w.url, w.secret`
+
+	// SQLite has a single writer so the deliveries are not locked, and the webhook is read in the returned columns
+	queryClaimWebhookDeliveriesSQLite = `
+	UPDATE webhook_deliveries SET next_attempt_at = :lease_until
+	WHERE delivery_id IN (
+		SELECT pd.delivery_id
+		FROM webhook_deliveries pd
+		JOIN webhooks pw ON pw.webhook_id = pd.webhook_id
+		WHERE pd.status = 'pending' AND pd.next_attempt_at <= :now AND pw.active
+		ORDER BY pd.next_attempt_at, pd.delivery_id
+		LIMIT :limit
+	)
+	RETURNING delivery_id, webhook_id, event_id, event, payload, status, attempts, next_attempt_at,
+		(SELECT w.url FROM webhooks w WHERE w.webhook_id = webhook_deliveries.webhook_id) AS url,
+		(SELECT w.secret FROM webhooks w WHERE w.webhook_id = webhook_deliveries.webhook_id) AS secret`
+
+	queryUpdateWebhookDeliveryAttempt = `
+	UPDATE webhook_deliveries SET status = :status, attempts = attempts + 1, next_attempt_at = :next_attempt_at,
+		response_status = :response_status, response_body = :response_body, error = :error,
+		delivered_at = COALESCE(:delivered_at, delivered_at), updated_at = CURRENT_TIMESTAMP
+	WHERE delivery_id = :delivery_id`
+
+	queryResetWebhookFailures = `UPDATE webhooks SET failure_count = 0 WHERE webhook_id = :webhook_id`
+
+	// The webhook is disabled once the consecutive failed deliveries reach the limit
+	queryIncrementWebhookFailures = `
+	UPDATE webhooks SET failure_count = failure_count + 1,
+		active = CASE WHEN failure_count + 1 >= :disable_after THEN FALSE ELSE active END,
+		disabled_at = CASE WHEN failure_count + 1 >= :disable_after THEN COALESCE(disabled_at, CURRENT_TIMESTAMP) ELSE disabled_at END,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE webhook_id = :webhook_id`
+)
+
+// Statuses of a webhook delivery
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// WebhookEvents is the list of events a webhook is subscribed to, it is stored as a comma separated list
+type WebhookEvents []string
+
+// Value implements driver.Valuer
+func (events WebhookEvents) Value() (driver.Value, error) {
+	return strings.Join(events, ","), nil
+}
+
+// Scan implements sql.Scanner
+func (events *WebhookEvents) Scan(src interface{}) error {
+	var value string
+	switch src := src.(type) {
+	case string:
+		value = src
+	case []byte:
+		value = string(src)
+	case nil:
+		*events = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into WebhookEvents", src)
+	}
+
+	*events = nil
+	for _, event := range strings.Split(value, ",") {
+		if event != "" {
+			*events = append(*events, event)
+		}
+	}
+	return nil
+}
+
+// Matches checks if the given event is one of the events
+func (events WebhookEvents) Matches(event string) bool {
+	for _, e := range events {
+		if e == EventAll || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook is a struct used to represent the `webhooks` table in the database
+type Webhook struct {
+	WebhookID    int           `db:"webhook_id" json:"webhook_id"`
+	UserUUID     uuid.UUID     `db:"user_uuid" json:"-"`
+	TeamID       *int          `db:"team_id" json:"team_id,omitempty"`
+	URL          string        `db:"url" json:"url"`
+	Secret       string        `db:"secret" json:"secret,omitempty"`
+	Events       WebhookEvents `db:"events" json:"events"`
+	Active       bool          `db:"active" json:"active"`
+	FailureCount int           `db:"failure_count" json:"failure_count"`
+	DisabledAt   *time.Time    `db:"disabled_at" json:"disabled_at,omitempty"`
+	CreatedAt    time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time     `db:"updated_at" json:"updated_at"`
+}
+
+// WebhookDelivery is a struct used to represent the `webhook_deliveries` table in the database
+type WebhookDelivery struct {
+	DeliveryID     int        `db:"delivery_id" json:"delivery_id"`
+	WebhookID      int        `db:"webhook_id" json:"webhook_id"`
+	EventID        uuid.UUID  `db:"event_id" json:"event_id"`
+	Event          string     `db:"event" json:"event"`
+	Payload        string     `db:"payload" json:"payload"`
+	Status         string     `db:"status" json:"status"`
+	Attempts       int        `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time  `db:"next_attempt_at" json:"next_attempt_at"`
+	ResponseStatus int        `db:"response_status" json:"response_status,omitempty"`
+	ResponseBody   string     `db:"response_body" json:"response_body,omitempty"`
+	Error          string     `db:"error" json:"error,omitempty"`
+	DeliveredAt    *time.Time `db:"delivered_at" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// PendingWebhookDelivery is a struct used to send a delivery along with the url and secret of it's webhook
+type PendingWebhookDelivery struct {
+	WebhookDelivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+// WebhookAttempt is a struct used to record the result of sending a delivery
+type WebhookAttempt struct {
+	Status         string
+	ResponseStatus int
+	ResponseBody   string
+	Error          string
+	AttemptedAt    time.Time
+	NextAttemptAt  time.Time
+}
+
+// CreateWebhook is used to create a new webhook for a user, or for a team of the user when it has a team id
+func (webhook *Webhook) CreateWebhook(ctx context.Context) error {
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if webhook.TeamID != nil {
+			isMember, err := isTeamMember(ctx, tx, *webhook.TeamID, webhook.UserUUID)
+			if err != nil {
+				return err
+			}
+
+			if !isMember {
+				log.Info("user is not a member of the team")
+				return ErrTeamNotFound
+			}
+		}
+
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertWebhook, map[string]interface{}{
+			"user_uuid": webhook.UserUUID,
+			"team_id":   webhook.TeamID,
+			"url":       webhook.URL,
+			"secret":    webhook.Secret,
+			"events":    webhook.Events,
+		})
+		if err != nil {
+			log.Error("error building webhook insert query", zap.Error(err))
+			return err
+		}
+
+		err = tx.QueryRowxContext(ctx, q, args...).StructScan(webhook)
+		if err != nil {
+			log.Error("Error while creating webhook", zap.Error(err))
+			return err
+		}
+
+		err = recordAudit(ctx, tx, webhook.UserUUID, AuditWebhookCreated, AuditTargetWebhook, strconv.Itoa(webhook.WebhookID), nil, webhookAuditState(webhook))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// GetWebhooks is used to fetch all the webhooks of a user and of it's teams
+func GetWebhooks(ctx context.Context, userUUID uuid.UUID) ([]Webhook, error) {
+	var webhooks []Webhook
+
+	err := db.NamedSelectContext(ctx, &webhooks, querySelectWebhooks, map[string]interface{}{
+		"user_uuid": userUUID,
+	})
+	if err != nil {
+		log.Error("Error while fetching webhooks", zap.Error(err))
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhook is used to fetch a given webhook of a user or of it's teams, ErrWebhookNotFound is returned if it does not exist
+func GetWebhook(ctx context.Context, userUUID uuid.UUID, webhookID int) (*Webhook, error) {
+	var webhook Webhook
+
+	err := db.NamedGetContext(ctx, &webhook, queryGetWebhook, map[string]interface{}{
+		"webhook_id": webhookID,
+		"user_uuid":  userUUID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrWebhookNotFound
+		}
+		log.Error("Error while fetching webhook", zap.Error(err))
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// UpdateWebhook is used to update the url, events and state of a given webhook
+func (webhook *Webhook) UpdateWebhook(ctx context.Context) error {
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		before, err := getWebhookState(ctx, tx, webhook.UserUUID, webhook.WebhookID)
+		if err != nil {
+			return err
+		}
+
+		// The team of a webhook does not change
+		webhook.TeamID = before.TeamID
+
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryUpdateWebhook, map[string]interface{}{
+			"webhook_id": webhook.WebhookID,
+			"user_uuid":  webhook.UserUUID,
+			"url":        webhook.URL,
+			"events":     webhook.Events,
+			"active":     webhook.Active,
+		})
+		if err != nil {
+			log.Error("error building webhook update query", zap.Error(err))
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("Error while updating webhook", zap.Error(err))
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.Error("Error while getting no. of rows affected", zap.Error(err))
+			return err
+		}
+
+		if rowsAffected == 0 {
+			log.Info("webhook does not exist")
+			return ErrWebhookNotFound
+		}
+
+		err = recordAudit(ctx, tx, webhook.UserUUID, AuditWebhookUpdated, AuditTargetWebhook, strconv.Itoa(webhook.WebhookID), webhookAuditState(before), webhookAuditState(webhook))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// DeleteWebhook is used to delete a given webhook along with it's deliveries
+func DeleteWebhook(ctx context.Context, userUUID uuid.UUID, webhookID int) error {
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		before, err := getWebhookState(ctx, tx, userUUID, webhookID)
+		if err != nil {
+			return err
+		}
+
+		// Deleting deliveries first due to foreign key constraint
+		for _, query := range []string{queryDeleteWebhookDeliveries, queryDeleteWebhook} {
+			q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), query, map[string]interface{}{
+				"webhook_id": webhookID,
+			})
+			if err != nil {
+				log.Error("error building webhook delete query", zap.Error(err))
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, q, args...)
+			if err != nil {
+				log.Error("error deleting webhook", zap.Error(err))
+				return err
+			}
+		}
+
+		err = recordAudit(ctx, tx, userUUID, AuditWebhookDeleted, AuditTargetWebhook, strconv.Itoa(webhookID), webhookAuditState(before), nil)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// getWebhookState fetches the current state of a webhook of the user using the given transaction
+func getWebhookState(ctx context.Context, tx *sqlx.Tx, userUUID uuid.UUID, webhookID int) (*Webhook, error) {
+	q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryGetWebhook, map[string]interface{}{
+		"webhook_id": webhookID,
+		"user_uuid":  userUUID,
+	})
+	if err != nil {
+		log.Error("error building webhook fetch query", zap.Error(err))
+		return nil, err
+	}
+
+	var webhook Webhook
+	err = tx.GetContext(ctx, &webhook, q, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			log.Info("webhook does not exist")
+			return nil, ErrWebhookNotFound
+		}
+		log.Error("error querying webhook", zap.Error(err))
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// webhookAuditState is the state of a webhook recorded in the audit log, the secret is never recorded
+func webhookAuditState(webhook *Webhook) map[string]interface{} {
+	return map[string]interface{}{
+		"webhook_id": webhook.WebhookID,
+		"team_id":    webhook.TeamID,
+		"url":        webhook.URL,
+		"events":     webhook.Events,
+		"active":     webhook.Active,
+	}
+}
+
+// GetWebhookDeliveries is used to fetch the delivery log of a given webhook, the latest deliveries come first
+func GetWebhookDeliveries(ctx context.Context, userUUID uuid.UUID, webhookID, limit, offset int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+
+	params := map[string]interface{}{
+		"webhook_id": webhookID,
+		"user_uuid":  userUUID,
+		"limit":      20,
+		"offset":     0,
+	}
+
+	if limit > 0 {
+		params["limit"] = limit
+	}
+	if offset > 0 {
+		params["offset"] = offset
+	}
+
+	err := db.NamedSelectContext(ctx, &deliveries, querySelectWebhookDeliveries, params)
+	if err != nil {
+		log.Error("Error while fetching webhook deliveries", zap.Error(err))
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RedeliverWebhookDelivery is used to send the event of a given delivery again as a new delivery
+func RedeliverWebhookDelivery(ctx context.Context, userUUID uuid.UUID, webhookID, deliveryID int) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryRedeliverWebhookDelivery, map[string]interface{}{
+			"delivery_id": deliveryID,
+			"webhook_id":  webhookID,
+			"user_uuid":   userUUID,
+		})
+		if err != nil {
+			log.Error("error building webhook redelivery query", zap.Error(err))
+			return err
+		}
+
+		err = tx.QueryRowxContext(ctx, q, args...).StructScan(&delivery)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				log.Info("webhook delivery does not exist")
+				return ErrWebhookDeliveryNotFound
+			}
+			log.Error("Error while redelivering webhook delivery", zap.Error(err))
+			return err
+		}
+
+		err = recordAudit(ctx, tx, userUUID, AuditWebhookRedelivered, AuditTargetWebhookDelivery, strconv.Itoa(delivery.DeliveryID), nil, map[string]interface{}{
+			"webhook_id":           webhookID,
+			"delivery_id":          delivery.DeliveryID,
+			"redelivered_delivery": deliveryID,
+			"event_id":             delivery.EventID,
+			"event":                delivery.Event,
+		})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// ClaimWebhookDeliveries is used to fetch the pending deliveries which are due, they are not returned again until leaseUntil
+func ClaimWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]PendingWebhookDelivery, error) {
+	var deliveries []PendingWebhookDelivery
+
+	query := queryClaimWebhookDeliveries
+	if db.IsSQLite() {
+		query = queryClaimWebhookDeliveriesSQLite
+	}
+
+	err := db.NamedSelectContext(ctx, &deliveries, query, map[string]interface{}{
+		"now":         now.UTC(),
+		"lease_until": leaseUntil.UTC(),
+		"limit":       limit,
+	})
+	if err != nil {
+		log.Error("Error while claiming webhook deliveries", zap.Error(err))
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RecordWebhookAttempt is used to save the result of sending a delivery.
+// A failed delivery counts as a failure of it's webhook, which is disabled after disableAfter consecutive failures.
+func RecordWebhookAttempt(ctx context.Context, delivery WebhookDelivery, attempt WebhookAttempt, disableAfter int) error {
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var deliveredAt *time.Time
+		if attempt.Status == DeliveryStatusSucceeded {
+			deliveredAt = &attempt.AttemptedAt
+		}
+
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryUpdateWebhookDeliveryAttempt, map[string]interface{}{
+			"delivery_id":     delivery.DeliveryID,
+			"status":          attempt.Status,
+			"next_attempt_at": attempt.NextAttemptAt,
+			"response_status": attempt.ResponseStatus,
+			"response_body":   attempt.ResponseBody,
+			"error":           attempt.Error,
+			"delivered_at":    deliveredAt,
+		})
+		if err != nil {
+			log.Error("error building webhook delivery update query", zap.Error(err))
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("error updating webhook delivery", zap.Error(err))
+			return err
+		}
+
+		var query string
+		switch attempt.Status {
+		case DeliveryStatusSucceeded:
+			query = queryResetWebhookFailures
+		case DeliveryStatusFailed:
+			query = queryIncrementWebhookFailures
+		default:
+			// The delivery will be retried
+			return nil
+		}
+
+		q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), query, map[string]interface{}{
+			"webhook_id":    delivery.WebhookID,
+			"disable_after": disableAfter,
+		})
+		if err != nil {
+			log.Error("error building webhook update query", zap.Error(err))
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			log.Error("error updating webhook failures", zap.Error(err))
+			return err
+		}
+
+		return nil
+	})
+}
+
+// EnqueueWebhookDeliveries creates a delivery of the event for every active webhook of the user, or of the team of the service
+// the event is about, subscribed to it. Events are published at least once, so a webhook gets a single delivery of an event
+// however many times it is called.
+func EnqueueWebhookDeliveries(ctx context.Context, userUUID uuid.UUID, teamID *int, eventID uuid.UUID, event, payload string) error {
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		q, args, err := sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), querySelectActiveWebhooks, map[string]interface{}{
+			"user_uuid": userUUID,
+			"team_id":   teamID,
+		})
+		if err != nil {
+			log.Error("error building webhooks fetch query", zap.Error(err))
+			return err
+		}
+
+		var webhooks []Webhook
+
+		err = tx.SelectContext(ctx, &webhooks, q, args...)
+		if err != nil {
+			log.Error("Error while fetching webhooks", zap.Error(err))
+			return err
+		}
+
+		for _, webhook := range webhooks {
+			if !webhook.Events.Matches(event) {
+				continue
+			}
+
+			params := map[string]interface{}{
+				"webhook_id": webhook.WebhookID,
+				"event_id":   eventID,
+				"event":      event,
+				"payload":    payload,
+			}
+
+			q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryCheckWebhookDeliveryByEventID, params)
+			if err != nil {
+				log.Error("error building webhook delivery check query", zap.Error(err))
+				return err
+			}
+
+			var count int
+
+			err = tx.GetContext(ctx, &count, q, args...)
+			if err != nil && err != sql.ErrNoRows {
+				log.Error("Error while checking webhook delivery", zap.Error(err))
+				return err
+			}
+
+			// The event was already published to this webhook
+			if count > 0 {
+				continue
+			}
+
+			q, args, err = sqlx.BindNamed(sqlx.BindType(db.Sqlx.DriverName()), queryInsertWebhookDelivery, params)
+			if err != nil {
+				log.Error("error building webhook delivery insert query", zap.Error(err))
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, q, args...)
+			if err != nil {
+				log.Error("Error while creating webhook delivery", zap.Error(err))
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// TestQueryClaimWebhookDeliveries is used to test whether the index is used to query the pending deliveries which are due
+func TestQueryClaimWebhookDeliveries(t *testing.T) {
+	setupTest()
+
+	query := queryClaimWebhookDeliveries
+	if db.IsSQLite() {
+		query = queryClaimWebhookDeliveriesSQLite
+	}
+
+	now := time.Now()
+	plan := queryPlan(t, query, map[string]interface{}{
+		"now":         now,
+		"lease_until": now.Add(time.Minute),
+		"limit":       20,
+	})
+
+	if !usesIndex(plan, "Index Scan") {
+		t.Error("Expected index scan but index is not being used")
+	}
+}
+
+func TestWebhookEventsMatches(t *testing.T) {
+	var events WebhookEvents
+	if err := events.Scan("service.created,version.deleted"); err != nil {
+		t.Fatal("Failed to scan events:", err)
+	}
+
+	if !events.Matches(EventVersionDeleted) || events.Matches(EventVersionCreated) {
+		t.Error("Expected only the subscribed events to match")
+	}
+
+	if !(WebhookEvents{EventAll}).Matches(EventArtifactCreated) {
+		t.Error("Expected every event to match *")
+	}
+
+	value, _ := events.Value()
+	if value != "service.created,version.deleted" {
+		t.Errorf("Unexpected value %v", value)
+	}
+}
@@ -0,0 +1,69 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/logger"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/google/uuid"
+)
+
+var (
+	log = logger.CreateLogger()
+)
+
+// Event is a catalog event published by the relay
+type Event struct {
+	ID        uuid.UUID       `json:"id"`
+	Sequence  int64           `json:"sequence"`
+	Event     string          `json:"event"`
+	UserUUID  uuid.UUID       `json:"-"`
+	ServiceID int             `json:"service_id"`
+	TeamID    *int            `json:"-"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+
+	// PublishedSeq is the position of the event in the event stream, it is only set for the events streamed once published
+	PublishedSeq int64 `json:"-"`
+}
+
+// FromOutboxEvent converts an outbox row into the event published
+func FromOutboxEvent(event model.OutboxEvent) Event {
+	return Event{
+		ID:        event.EventID,
+		Sequence:  event.OutboxID,
+		Event:     event.Event,
+		UserUUID:  event.UserUUID,
+		ServiceID: event.ServiceID,
+		TeamID:    event.TeamID,
+		CreatedAt: event.CreatedAt.UTC(),
+		Data:      json.RawMessage(event.Payload),
+
+		PublishedSeq: event.PublishedSeq,
+	}
+}
+
+// Publisher publishes the events of the outbox.
+// Events are published at least once, so publishers and their consumers should deduplicate them using the event id.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// PublisherFunc is an adapter to use a function as a Publisher
+type PublisherFunc func(ctx context.Context, event Event) error
+
+// Publish calls f(ctx, event)
+func (f PublisherFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// Sink is a destination the relay publishes the events to.
+// The sinks an event was published to are recorded, so an event which failed to be published to a sink is only
+// published again to the sinks which failed.
+type Sink struct {
+	// Name identifies the sink in the outbox, it should not change
+	Name      string
+	Publisher Publisher
+}
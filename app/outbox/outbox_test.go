@@ -0,0 +1,116 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var event = FromOutboxEvent(model.OutboxEvent{
+	OutboxID:  42,
+	EventID:   uuid.MustParse("0b8f6a52-4d1c-4a4e-9a39-7f0e6f2b1c11"),
+	Event:     model.EventVersionCreated,
+	ServiceID: 3,
+	Payload:   `{"service_id":3,"sv_id":7,"version":"v1.1.0"}`,
+	CreatedAt: time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC),
+})
+
+func TestEventJSON(t *testing.T) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal("Failed to encode event:", err)
+	}
+
+	assert.JSONEq(t, `{
+		"id": "0b8f6a52-4d1c-4a4e-9a39-7f0e6f2b1c11",
+		"sequence": 42,
+		"event": "version.created",
+		"service_id": 3,
+		"created_at": "2024-05-06T09:00:00Z",
+		"data": {"service_id": 3, "sv_id": 7, "version": "v1.1.0"}
+	}`, string(body))
+}
+
+func TestRelayPublish(t *testing.T) {
+	published := map[string]int{}
+	sink := func(name string, err error) Sink {
+		return Sink{Name: name, Publisher: PublisherFunc(func(ctx context.Context, e Event) error {
+			published[name]++
+			return err
+		})}
+	}
+
+	relay := NewRelay(sink("first", nil), sink("second", errors.New("unavailable")))
+	outboxEvent := model.OutboxEvent{OutboxID: 42, ServiceID: 3, Attempts: 2}
+
+	// Case fail: The event is still published to the other sinks and is retried later
+	attempt := relay.publish(context.Background(), outboxEvent)
+	assert.Equal(t, model.OutboxSinks{"first"}, attempt.PublishedSinks)
+	assert.Equal(t, "second: unavailable", attempt.Error)
+	assert.WithinDuration(t, time.Now().Add(4*relay.BaseBackoff), attempt.NextAttemptAt, time.Second)
+
+	// Case: The event is only published again to the sinks which failed
+	relay.Sinks[1] = sink("second", nil)
+	outboxEvent.PublishedSinks = attempt.PublishedSinks
+	attempt = relay.publish(context.Background(), outboxEvent)
+	assert.Equal(t, model.OutboxSinks{"first", "second"}, attempt.PublishedSinks)
+	assert.Empty(t, attempt.Error)
+	assert.Equal(t, map[string]int{"first": 1, "second": 2}, published)
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	publisher := NewFilePublisher(path)
+
+	assert.NoError(t, publisher.Publish(context.Background(), event))
+	assert.NoError(t, publisher.Publish(context.Background(), event))
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal("Failed to open file:", err)
+	}
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var decoded Event
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &decoded))
+		assert.Equal(t, event.ID, decoded.ID)
+		lines++
+	}
+	assert.Equal(t, 2, lines)
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, event.ID.String(), r.Header.Get(HeaderEventID))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	publisher := &HTTPPublisher{
+		URL:    server.URL,
+		Client: server.Client(),
+		Header: http.Header{"Authorization": []string{"Bearer token"}},
+	}
+
+	assert.NoError(t, publisher.Publish(context.Background(), event))
+
+	// Case fail: Server error
+	status = http.StatusServiceUnavailable
+	assert.Error(t, publisher.Publish(context.Background(), event))
+}
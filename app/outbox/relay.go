@@ -0,0 +1,153 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/backoff"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"go.uber.org/zap"
+)
+
+// Relay periodically publishes the events recorded in the outbox to the sinks.
+// The events are claimed in a short transaction and published outside of it, a failed event is retried with an
+// exponential backoff and is dead after MaxAttempts attempts.
+type Relay struct {
+	Sinks []Sink
+
+	// Stream gets the events once they are published to every sink, along with their position in the event stream.
+	// It is not retried, the clients of the stream replay the events they missed from their last position.
+	Stream Publisher
+
+	// Interval is the time between two polls of the outbox
+	Interval time.Duration
+	// BatchSize is the maximum number of events read from the outbox at once
+	BatchSize int
+	// Lease is the time claimed events are hidden from other relays while they are being published
+	Lease time.Duration
+
+	// MaxAttempts is the number of attempts after which an event is dead and is not published anymore
+	MaxAttempts int
+	// BaseBackoff and MaxBackoff bound the delay between two attempts of an event
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// NewRelay returns a relay with the default settings.
+// An event is attempted 10 times over about 30 minutes.
+func NewRelay(sinks ...Sink) *Relay {
+	return &Relay{
+		Sinks:       sinks,
+		Interval:    time.Second,
+		BatchSize:   100,
+		Lease:       5 * time.Minute,
+		MaxAttempts: 10,
+		BaseBackoff: 5 * time.Second,
+		MaxBackoff:  10 * time.Minute,
+	}
+}
+
+// Run publishes the events until the context is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		// A full batch means there could be more events to publish
+		if r.relay(ctx) == r.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay publishes a batch of events and returns the number of events claimed
+func (r *Relay) relay(ctx context.Context) int {
+	now := time.Now().UTC()
+	leaseUntil := now.Add(r.Lease)
+
+	events, err := model.ClaimOutboxEvents(ctx, now, leaseUntil, r.BatchSize)
+	if err != nil {
+		log.Error("Error while claiming outbox events", zap.Error(err))
+		return 0
+	}
+
+	// The following events of a service which failed are held back so that the events of a service are published in order
+	heldBack := make(map[int]bool)
+	for _, event := range events {
+		// The events left once the lease ended could already be claimed by another relay
+		if heldBack[event.ServiceID] || ctx.Err() != nil || time.Now().After(leaseUntil) {
+			err := model.ReleaseOutboxEvent(context.Background(), event)
+			if err != nil {
+				log.Error("Error while releasing outbox event", zap.Error(err), zap.Int64("outbox_id", event.OutboxID))
+			}
+			continue
+		}
+
+		attempt := r.publish(ctx, event)
+		if attempt.Error != "" {
+			heldBack[event.ServiceID] = true
+		}
+
+		// The attempt is recorded even if the relay is stopping so that the event is not published twice to a sink
+		publishedSeq, err := model.RecordOutboxAttempt(context.Background(), event, attempt)
+		if err != nil {
+			log.Error("Error while recording outbox attempt", zap.Error(err), zap.Int64("outbox_id", event.OutboxID))
+			continue
+		}
+
+		if publishedSeq != 0 && r.Stream != nil {
+			event.PublishedSeq = publishedSeq
+			err = r.Stream.Publish(ctx, FromOutboxEvent(event))
+			if err != nil {
+				log.Error("Error while streaming outbox event", zap.Error(err), zap.Int64("published_seq", publishedSeq))
+			}
+		}
+	}
+
+	return len(events)
+}
+
+// publish publishes an event to the sinks it was not published to yet and returns the result
+func (r *Relay) publish(ctx context.Context, event model.OutboxEvent) model.OutboxAttempt {
+	attempt := model.OutboxAttempt{
+		PublishedSinks: append(model.OutboxSinks(nil), event.PublishedSinks...),
+		MaxAttempts:    r.MaxAttempts,
+	}
+
+	var errs []error
+	for _, sink := range r.Sinks {
+		if attempt.PublishedSinks.Contains(sink.Name) {
+			continue
+		}
+
+		err := sink.Publisher.Publish(ctx, FromOutboxEvent(event))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", sink.Name, err))
+			continue
+		}
+
+		attempt.PublishedSinks = append(attempt.PublishedSinks, sink.Name)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		attempts := event.Attempts + 1
+		attempt.Error = err.Error()
+		attempt.NextAttemptAt = time.Now().UTC().Add(backoff.Exponential(attempts, r.BaseBackoff, r.MaxBackoff))
+
+		if attempts >= r.MaxAttempts {
+			log.Error("Outbox event is dead", zap.Int64("outbox_id", event.OutboxID), zap.Int("attempts", attempts), zap.Error(err))
+		} else {
+			log.Info("Error while publishing outbox event", zap.Int64("outbox_id", event.OutboxID), zap.Int("attempts", attempts), zap.Error(err))
+		}
+	}
+
+	return attempt
+}
@@ -0,0 +1,214 @@
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/go-playground/validator/v10"
+)
+
+// ContentType is the media type of the problem responses
+const ContentType = "application/problem+json"
+
+// typePrefix is the prefix of the problem types, the code of the problem is appended to it
+const typePrefix = "/problems/"
+
+// Codes of the problems which are not returned by the model
+const (
+	CodeInvalidBody        = "invalid_body"
+	CodeInvalidParameter   = "invalid_parameter"
+	CodeValidationFailed   = "validation_failed"
+	CodeNotFound           = "not_found"
+	CodeUnauthorized       = "unauthorized"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeInternal           = "internal_error"
+)
+
+// Problem is the body of an error response as defined by RFC 7807, with a machine-readable code
+// and the fields which failed the validation
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is a field of the body which failed the validation
+type FieldError struct {
+	Field  string `json:"field,omitempty"`
+	Rule   string `json:"rule,omitempty"`
+	Param  string `json:"param,omitempty"`
+	Detail string `json:"detail"`
+}
+
+// Error is an error of a request which is reported to the client as a problem
+type Error struct {
+	Status int
+	Code   string
+	Detail string
+	Fields []FieldError
+
+	// Err is the cause of the error, it is logged but not reported to the client
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Detail + ": " + e.Err.Error()
+	}
+	return e.Detail
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error reported with the given status, code and detail
+func New(status int, code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+// NotFound is returned for resources which do not exist, including invalid ids in the path
+func NotFound() *Error {
+	return New(http.StatusNotFound, CodeNotFound, "The resource does not exist.")
+}
+
+// Unauthorized is returned when the auth token is missing or invalid
+func Unauthorized() *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, "A valid access token is required.")
+}
+
+// InvalidBody is returned when the body cannot be decoded
+func InvalidBody(err error) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeInvalidBody, Detail: "Invalid body.", Err: err}
+}
+
+// InvalidParameter is returned when a query parameter or a header has an invalid value
+func InvalidParameter(name string, err error) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeInvalidParameter, Detail: fmt.Sprintf("Invalid %s value", name), Err: err}
+}
+
+// Validation reports the fields of a body which failed the validation
+func Validation(err error) *Error {
+	problem := &Error{Status: http.StatusBadRequest, Code: CodeValidationFailed, Detail: "Invalid body.", Err: err}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, fieldErr := range validationErrors {
+			problem.Fields = append(problem.Fields, FieldError{
+				Field:  fieldName(fieldErr),
+				Rule:   fieldErr.Tag(),
+				Param:  fieldErr.Param(),
+				Detail: fieldDetail(fieldErr),
+			})
+		}
+	}
+
+	return problem
+}
+
+// NewValidator returns a validator reporting the fields with their JSON names
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return validate
+}
+
+// FromError returns the problem reported for an error.
+// Errors of the model are reported with their code, other errors are internal errors and their detail is not reported.
+func FromError(err error) Problem {
+	var problemErr *Error
+	var modelErr *model.Error
+	var validationErrors validator.ValidationErrors
+
+	switch {
+	case errors.As(err, &problemErr):
+	case errors.As(err, &modelErr):
+		problemErr = fromModelError(modelErr)
+	case errors.As(err, &validationErrors):
+		problemErr = Validation(err)
+	default:
+		problemErr = New(http.StatusInternalServerError, CodeInternal, "An internal error occurred.")
+	}
+
+	return Problem{
+		Type:   typePrefix + problemErr.Code,
+		Title:  http.StatusText(problemErr.Status),
+		Status: problemErr.Status,
+		Detail: problemErr.Detail,
+		Code:   problemErr.Code,
+		Errors: problemErr.Fields,
+	}
+}
+
+// fromModelError maps the kind of an error of the model to a status.
+// Conflicts are reported as 400 Bad Request as they were before the problem responses.
+func fromModelError(err *model.Error) *Error {
+	status := http.StatusInternalServerError
+	switch err.Kind {
+	case model.KindNotFound:
+		status = http.StatusNotFound
+	case model.KindConflict:
+		status = http.StatusBadRequest
+	case model.KindPrecondition:
+		status = http.StatusPreconditionFailed
+	}
+
+	detail := err.Message
+	if detail != "" {
+		detail = strings.ToUpper(detail[:1]) + detail[1:] + "."
+	}
+
+	return &Error{Status: status, Code: err.Code, Detail: detail, Err: err}
+}
+
+// fieldName returns the path of a field without the name of the validated struct, e.g. `changes[0].category`
+func fieldName(err validator.FieldError) string {
+	namespace := err.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// fieldDetail describes the rule a field failed
+func fieldDetail(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return fmt.Sprintf("is required without %s", err.Param())
+	case "min":
+		if err.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", err.Param())
+		}
+		return fmt.Sprintf("must have at least %s items", err.Param())
+	case "max":
+		if err.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", err.Param())
+		}
+		return fmt.Sprintf("must have at most %s items", err.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", err.Param())
+	case "email":
+		return "must be a valid email address"
+	case "http_url":
+		return "must be an http or https URL"
+	case "public_url":
+		return "must not be a loopback, link-local or private address"
+	}
+	return fmt.Sprintf("failed the %s rule", err.Tag())
+}
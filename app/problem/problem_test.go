@@ -0,0 +1,77 @@
+package problem
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type testChange struct {
+	Category string `json:"category" validate:"required,oneof=added fixed"`
+}
+
+type testBody struct {
+	Name    string       `json:"name" validate:"required,min=3"`
+	Changes []testChange `json:"changes" validate:"dive"`
+}
+
+func TestFromError(t *testing.T) {
+	// Case: Errors of the model are reported with their code
+	p := FromError(fmt.Errorf("creating service: %w", model.ErrServiceExists))
+	assert.Equal(t, http.StatusBadRequest, p.Status)
+	assert.Equal(t, "service_exists", p.Code)
+	assert.Equal(t, "/problems/service_exists", p.Type)
+	assert.Equal(t, "Service with same name exists.", p.Detail)
+
+	p = FromError(model.ErrServiceModified)
+	assert.Equal(t, http.StatusPreconditionFailed, p.Status)
+
+	p = FromError(model.ErrServiceVersionNotFound)
+	assert.Equal(t, http.StatusNotFound, p.Status)
+	assert.Equal(t, "service_version_not_found", p.Code)
+	assert.Equal(t, "Not Found", p.Title)
+
+	// Case: Problems are reported as they are
+	p = FromError(New(http.StatusUnsupportedMediaType, "unsupported_media_type", "Unsupported content type."))
+	assert.Equal(t, http.StatusUnsupportedMediaType, p.Status)
+	assert.Equal(t, "Unsupported content type.", p.Detail)
+
+	p = FromError(InvalidBody(errors.New("unexpected EOF")))
+	assert.Equal(t, CodeInvalidBody, p.Code)
+	assert.NotContains(t, p.Detail, "EOF")
+
+	// Case: Missing rows which are not reported by the model are internal errors
+	p = FromError(sql.ErrNoRows)
+	assert.Equal(t, http.StatusInternalServerError, p.Status)
+	assert.Equal(t, CodeInternal, p.Code)
+
+	// Case: The detail of other errors is not reported
+	p = FromError(errors.New("pq: connection refused"))
+	assert.Equal(t, http.StatusInternalServerError, p.Status)
+	assert.Equal(t, CodeInternal, p.Code)
+	assert.NotContains(t, p.Detail, "pq")
+}
+
+func TestValidation(t *testing.T) {
+	validate := NewValidator()
+
+	err := validate.Struct(testBody{Name: "be", Changes: []testChange{{Category: "added"}, {}}})
+	p := FromError(Validation(err))
+
+	assert.Equal(t, http.StatusBadRequest, p.Status)
+	assert.Equal(t, CodeValidationFailed, p.Code)
+	assert.Equal(t, []FieldError{
+		{Field: "name", Rule: "min", Param: "3", Detail: "must be at least 3 characters long"},
+		{Field: "changes[1].category", Rule: "required", Detail: "is required"},
+	}, p.Errors)
+
+	// Case: Errors of the validator are reported without the handlers wrapping them
+	p = FromError(err)
+	assert.Equal(t, CodeValidationFailed, p.Code)
+	assert.Len(t, p.Errors, 2)
+}
@@ -1,13 +1,32 @@
 package route
 
 import (
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/ZiyanK/service-catalog-api/app/handler"
+	"github.com/ZiyanK/service-catalog-api/app/logger"
 	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+var (
+	log = logger.CreateLogger()
 )
 
 const (
-	pathPing = "/ping"
+	pathPing    = "/ping"
+	pathHealthz = "/healthz"
+	pathReadyz  = "/readyz"
+	pathMetrics = "/metrics"
+
+	pathV1 = "/v1"
+	pathV2 = "/v2"
 
 	pathSignup = "/signup"
 	pathLogin  = "/login"
@@ -18,39 +37,294 @@ const (
 	pathServiceID = "/service/:id"
 
 	pathServiceIDVersion   = "/service/:id/version"
+	pathServiceIDChangelog = "/service/:id/changelog"
+	pathServiceIDRelease   = "/service/:id/release"
 	pathServiceIDVersionID = "/service/:id/version/:vid"
+	pathServiceIDHistory   = "/service/:id/history"
+	pathServiceIDRevert    = "/service/:id/revert"
+
+	pathServiceIDVersionIDArtifact  = "/service/:id/version/:vid/artifact"
+	pathServiceIDVersionIDArtifacts = "/service/:id/version/:vid/artifacts"
+	pathArtifactLookup              = "/artifacts/lookup"
+
+	pathImport = "/import"
+	pathExport = "/export"
+
+	pathImportBackstage = "/import/backstage"
+	pathExportBackstage = "/export/backstage"
+
+	pathTeam              = "/team"
+	pathTeams             = "/teams"
+	pathTeamID            = "/team/:tid"
+	pathTeamIDMember      = "/team/:tid/member"
+	pathTeamIDMemberEmail = "/team/:tid/member/:email"
+	pathTeamIDAccept      = "/team/:tid/accept"
+	pathTeamIDServiceID   = "/team/:tid/service/:id"
+
+	pathWebhook                      = "/webhook"
+	pathWebhooks                     = "/webhooks"
+	pathWebhookID                    = "/webhook/:wid"
+	pathWebhookIDDeliveries          = "/webhook/:wid/deliveries"
+	pathWebhookIDDeliveryIDRedeliver = "/webhook/:wid/delivery/:did/redeliver"
+
+	pathEventsStream = "/events/stream"
+
+	pathGraphQL = "/graphql"
+
+	pathAudit       = "/audit"
+	pathAuditVerify = "/audit/verify"
 )
 
+// route is a route of a version of the API
+type route struct {
+	method  string
+	path    string
+	handler gin.HandlerFunc
+
+	// public routes do not require an auth token
+	public bool
+	// deprecation is set once the route is deprecated
+	deprecation *middleware.Deprecation
+}
+
+// version is a version of the API mounted under it's prefix.
+// A version serves the routes of the previous version, a route with the same method and path replaces it
+// and a route without a handler removes it.
+type version struct {
+	prefix string
+	routes []route
+
+	// deprecation is set once the version is deprecated, it applies to all the routes of the version
+	deprecation *middleware.Deprecation
+}
+
+var v1 = version{
+	prefix: pathV1,
+	routes: []route{
+		// Auth routes
+		{method: http.MethodPost, path: pathSignup, handler: handler.HandlerSignUp, public: true},
+		{method: http.MethodPost, path: pathLogin, handler: handler.HandlerLogin, public: true},
+
+		// User routes
+		{method: http.MethodGet, path: pathUser, handler: handler.HandlerGetUser},
+		{method: http.MethodPut, path: pathUser, handler: handler.HandlerUpdateUser},
+		{method: http.MethodPatch, path: pathUser, handler: handler.HandlerPatchUser},
+
+		// Service routes
+		{method: http.MethodGet, path: pathServices, handler: handler.HandlerGetServices},
+		{method: http.MethodPost, path: pathService, handler: handler.HandlerCreateService},
+		{method: http.MethodGet, path: pathServiceID, handler: handler.HandlerGetService},
+		{method: http.MethodPut, path: pathServiceID, handler: handler.HandlerUpdateService},
+		{method: http.MethodPatch, path: pathServiceID, handler: handler.HandlerPatchService},
+		{method: http.MethodDelete, path: pathServiceID, handler: handler.HandlerDeleteService},
+		{method: http.MethodGet, path: pathServiceIDHistory, handler: handler.HandlerGetServiceHistory},
+		{method: http.MethodPost, path: pathServiceIDRevert, handler: handler.HandlerRevertService},
+
+		// Service version routes
+		{method: http.MethodPost, path: pathServiceIDVersion, handler: handler.HandlerCreateServiceVersion},
+		{method: http.MethodDelete, path: pathServiceIDVersionID, handler: handler.HandlerDeleteServiceVersion},
+
+		// Changelog routes
+		{method: http.MethodPost, path: pathServiceIDChangelog, handler: handler.HandlerImportChangelog},
+		{method: http.MethodGet, path: pathServiceIDChangelog, handler: handler.HandlerGetChangelog},
+		{method: http.MethodPost, path: pathServiceIDRelease, handler: handler.HandlerProposeRelease},
+
+		// Artifact routes
+		{method: http.MethodPost, path: pathServiceIDVersionIDArtifact, handler: handler.HandlerCreateArtifact},
+		{method: http.MethodGet, path: pathServiceIDVersionIDArtifacts, handler: handler.HandlerGetArtifacts},
+		{method: http.MethodGet, path: pathArtifactLookup, handler: handler.HandlerLookupArtifact},
+
+		// Catalog routes
+		{method: http.MethodPost, path: pathImport, handler: handler.HandlerImportCatalog},
+		{method: http.MethodGet, path: pathExport, handler: handler.HandlerExportCatalog},
+		{method: http.MethodPost, path: pathImportBackstage, handler: handler.HandlerImportBackstage},
+		{method: http.MethodGet, path: pathExportBackstage, handler: handler.HandlerExportBackstage},
+
+		// Team routes
+		{method: http.MethodPost, path: pathTeam, handler: handler.HandlerCreateTeam},
+		{method: http.MethodGet, path: pathTeams, handler: handler.HandlerGetTeams},
+		{method: http.MethodGet, path: pathTeamID, handler: handler.HandlerGetTeam},
+		{method: http.MethodPost, path: pathTeamIDMember, handler: handler.HandlerAddTeamMember},
+		{method: http.MethodDelete, path: pathTeamIDMemberEmail, handler: handler.HandlerRemoveTeamMember},
+		{method: http.MethodPost, path: pathTeamIDAccept, handler: handler.HandlerAcceptTeamInvitation},
+		{method: http.MethodPut, path: pathTeamIDServiceID, handler: handler.HandlerAddTeamService},
+		{method: http.MethodDelete, path: pathTeamIDServiceID, handler: handler.HandlerRemoveTeamService},
+
+		// Webhook routes
+		{method: http.MethodPost, path: pathWebhook, handler: handler.HandlerCreateWebhook},
+		{method: http.MethodGet, path: pathWebhooks, handler: handler.HandlerGetWebhooks},
+		{method: http.MethodGet, path: pathWebhookID, handler: handler.HandlerGetWebhook},
+		{method: http.MethodPut, path: pathWebhookID, handler: handler.HandlerUpdateWebhook},
+		{method: http.MethodDelete, path: pathWebhookID, handler: handler.HandlerDeleteWebhook},
+		{method: http.MethodGet, path: pathWebhookIDDeliveries, handler: handler.HandlerGetWebhookDeliveries},
+		{method: http.MethodPost, path: pathWebhookIDDeliveryIDRedeliver, handler: handler.HandlerRedeliverWebhookDelivery},
+
+		// Event routes
+		{method: http.MethodGet, path: pathEventsStream, handler: handler.HandlerEventStream},
+
+		// GraphQL routes
+		{method: http.MethodPost, path: pathGraphQL, handler: handler.HandlerGraphQL},
+
+		// Audit routes
+		{method: http.MethodGet, path: pathAudit, handler: handler.HandlerGetAuditEvents},
+		{method: http.MethodGet, path: pathAuditVerify, handler: handler.HandlerVerifyAuditLog},
+	},
+}
+
+// v2 only lists the routes which change from v1, it is mounted once it has routes
+var v2 = version{
+	prefix: pathV2,
+}
+
+var versions = []version{v1, v2}
+
+// unversioned are the paths of the API before it was versioned, they are kept as aliases of v1 until their sunset
+var unversioned = middleware.Deprecation{
+	Since:     time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+	Sunset:    time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC),
+	Successor: pathV1,
+}
+
 func AddRouter() *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
+	// The client IP is only read from the X-Forwarded-For header when the request comes from a trusted proxy
+	err := router.SetTrustedProxies(trustedProxies())
+	if err != nil {
+		log.Fatal("Invalid trusted proxies", zap.Error(err))
+	}
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Metrics())
 	router.Use(middleware.LogRoutesMiddleware())
+	router.Use(middleware.HandleErrors())
+	// CORS is used before the routes are added, the middlewares used after a route do not apply to it
+	router.Use(cors.New(cors.Config{
+		AllowOrigins:     []string{"http://localhost:8080", "*"}, // TODO: restrict origins
+		AllowMethods:     []string{http.MethodGet, http.MethodPatch, http.MethodPost, http.MethodPut, http.MethodHead, http.MethodDelete, http.MethodOptions},
+		AllowHeaders:     []string{"Content-Type", "X-XSRF-TOKEN", "Accept", "Origin", "X-Requested-With", "Authorization", "If-Match", "If-None-Match", "Idempotency-Key", "X-Request-ID"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "Idempotent-Replayed", "X-Request-ID"},
+		AllowCredentials: true,
+	}))
 
 	router.GET(pathPing, func(c *gin.Context) {
 		c.JSON(200, "pong")
 	})
+	router.GET(pathHealthz, handler.HandlerHealthz)
+	router.GET(pathReadyz, handler.HandlerReadyz)
+	// The metrics are served on the API port behind the metrics token, unless they have their own internal port
+	if viper.GetString("metrics_port") == "" {
+		router.GET(pathMetrics, middleware.MetricsToken(), gin.WrapH(promhttp.Handler()))
+	}
 
-	// Auth routes
-	router.POST(pathSignup, handler.HandlerSignUp)
-	router.POST(pathLogin, handler.HandlerLogin)
+	resolved := resolveVersions(versions)
+	for i, version := range versions {
+		if i > 0 && len(version.routes) == 0 {
+			continue
+		}
+		mount(router.Group(version.prefix), resolved[i], version.deprecation)
+	}
 
-	// Protected routes
-	router.Use(middleware.VerifyAuthToken)
+	// The unversioned paths serve v1
+	mount(router.Group(""), resolved[0], &unversioned)
 
-	// User routes
-	router.GET(pathUser, handler.HandlerGetUser)
-	router.PUT(pathUser, handler.HandlerUpdateUser)
+	return router
+}
 
-	// Service routes
-	router.GET(pathServices, handler.HandlerGetServices)
-	router.POST(pathService, handler.HandlerCreateService)
-	router.GET(pathServiceID, handler.HandlerGetService)
-	router.PUT(pathServiceID, handler.HandlerUpdateService)
-	router.DELETE(pathServiceID, handler.HandlerDeleteService)
+// AddMetricsRouter returns the router of the internal metrics port, the scrapes need the metrics token when it is set
+func AddMetricsRouter() *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(middleware.HandleErrors())
 
-	// Service version routes
-	router.POST(pathServiceIDVersion, handler.HandlerCreateServiceVersion)
-	router.DELETE(pathServiceIDVersionID, handler.HandlerDeleteServiceVersion)
+	handlers := []gin.HandlerFunc{gin.WrapH(promhttp.Handler())}
+	if viper.GetString("metrics_token") != "" {
+		handlers = append([]gin.HandlerFunc{middleware.MetricsToken()}, handlers...)
+	}
+	router.GET(pathMetrics, handlers...)
 
 	return router
 }
+
+// resolveVersions returns the routes served by each version
+func resolveVersions(versions []version) [][]route {
+	resolved := make([][]route, 0, len(versions))
+
+	var previous []route
+	for _, version := range versions {
+		routes := make([]route, 0, len(previous)+len(version.routes))
+		routes = append(routes, previous...)
+
+		for _, r := range version.routes {
+			replaced := false
+			for i := range routes {
+				if routes[i].method == r.method && routes[i].path == r.path {
+					routes[i] = r
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				routes = append(routes, r)
+			}
+		}
+
+		served := make([]route, 0, len(routes))
+		for _, r := range routes {
+			if r.handler != nil {
+				served = append(served, r)
+			}
+		}
+
+		resolved = append(resolved, served)
+		previous = served
+	}
+
+	return resolved
+}
+
+// mount adds the routes to the group, the public routes are added before the auth middleware.
+// The idempotency keys of the protected routes are scoped by the user as they are checked after the auth middleware.
+func mount(group *gin.RouterGroup, routes []route, deprecation *middleware.Deprecation) {
+	if deprecation != nil {
+		group.Use(middleware.Deprecated(*deprecation))
+	}
+
+	add := func(group *gin.RouterGroup, r route) {
+		var handlers []gin.HandlerFunc
+		if r.deprecation != nil && deprecation == nil {
+			handlers = append(handlers, middleware.Deprecated(*r.deprecation))
+		}
+		// POST requests are made safe to retry with an Idempotency-Key
+		if r.method == http.MethodPost {
+			handlers = append(handlers, middleware.Idempotency())
+		}
+		handlers = append(handlers, r.handler)
+		group.Handle(r.method, r.path, handlers...)
+	}
+
+	for _, r := range routes {
+		if r.public {
+			add(group, r)
+		}
+	}
+
+	// Protected routes
+	protected := group.Group("", middleware.VerifyAuthToken)
+	for _, r := range routes {
+		if !r.public {
+			add(protected, r)
+		}
+	}
+}
+
+// trustedProxies returns the IPs and CIDRs of the proxies listed in `trusted_proxies`, none are trusted when it is not set
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(viper.GetString("trusted_proxies"), ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
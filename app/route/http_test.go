@@ -0,0 +1,171 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func respond(body string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.String(http.StatusOK, body)
+	}
+}
+
+func TestResolveVersions(t *testing.T) {
+	versions := []version{
+		{prefix: "/v1", routes: []route{
+			{method: http.MethodGet, path: "/services", handler: respond("v1 services")},
+			{method: http.MethodGet, path: "/service/:id", handler: respond("v1 service")},
+			{method: http.MethodDelete, path: "/service/:id", handler: respond("v1 delete")},
+		}},
+		{prefix: "/v2", routes: []route{
+			{method: http.MethodGet, path: "/service/:id", handler: respond("v2 service"), public: true},
+			{method: http.MethodDelete, path: "/service/:id"},
+			{method: http.MethodPatch, path: "/service/:id", handler: respond("v2 patch")},
+		}},
+	}
+
+	resolved := resolveVersions(versions)
+	assert.Len(t, resolved, 2)
+	assert.Len(t, resolved[0], 3)
+
+	// Case: v2 keeps the routes it does not change, replaces and removes the others
+	var served []string
+	for _, r := range resolved[1] {
+		served = append(served, r.method+" "+r.path)
+	}
+	assert.Equal(t, []string{"GET /services", "GET /service/:id", "PATCH /service/:id"}, served)
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	mount(router.Group("/v2"), resolved[1], nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/service/1", nil))
+	assert.Equal(t, "v2 service", w.Body.String())
+}
+
+func TestDeprecatedRoutes(t *testing.T) {
+	since := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	deprecation := middleware.Deprecation{
+		Since:     since,
+		Sunset:    since.AddDate(0, 6, 0),
+		Successor: "/v1",
+	}
+	routes := []route{
+		{method: http.MethodPost, path: "/signup", handler: respond("signup"), public: true},
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	mount(router.Group("/v1"), routes, nil)
+	mount(router.Group(""), routes, &deprecation)
+
+	// Case: The versioned route is not deprecated
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/signup", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	// Case: The alias serves the same route and reports it's deprecation
+	before := middleware.DeprecatedRequests(http.MethodPost, "/signup")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/signup", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "signup", w.Body.String())
+	assert.Equal(t, "@1792368000", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Mon, 19 Apr 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</v1/signup>; rel="successor-version"`, w.Header().Get("Link"))
+	assert.Equal(t, before+1, middleware.DeprecatedRequests(http.MethodPost, "/signup"))
+}
+
+func TestAddRouterCORS(t *testing.T) {
+	router := AddRouter()
+
+	// Case: The preflight requests of the routes are answered by the CORS middleware
+	req := httptest.NewRequest(http.MethodOptions, "/v1/services", nil)
+	req.Header.Set("Origin", "http://localhost:8080")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	// Case: The routes updated with a PUT are allowed
+	req = httptest.NewRequest(http.MethodOptions, "/v1/service/1", nil)
+	req.Header.Set("Origin", "http://localhost:8080")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), http.MethodPut)
+
+	// Case: The responses of the routes expose their headers
+	req = httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Origin", "http://localhost:8080")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "Etag")
+}
+
+func TestAddRouterTrustedProxies(t *testing.T) {
+	clientIP := func(router *gin.Engine) string {
+		router.GET("/client-ip", func(c *gin.Context) {
+			c.String(http.StatusOK, c.ClientIP())
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/client-ip", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	// Case: X-Forwarded-For is ignored when no proxy is trusted
+	assert.Equal(t, "192.0.2.1", clientIP(AddRouter()))
+
+	// Case: X-Forwarded-For is read from a trusted proxy
+	viper.Set("trusted_proxies", "10.0.0.0/8, 192.0.2.1")
+	defer viper.Set("trusted_proxies", "")
+	assert.Equal(t, "203.0.113.7", clientIP(AddRouter()))
+}
+
+func TestAddRouterMetrics(t *testing.T) {
+	scrape := func(router *gin.Engine, authorization string) int {
+		req := httptest.NewRequest(http.MethodGet, pathMetrics, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Case fail: The metrics are not public on the API port
+	assert.Equal(t, http.StatusUnauthorized, scrape(AddRouter(), ""))
+
+	// Case: The metrics are served on the API port to the scrapes with the metrics token
+	viper.Set("metrics_token", "scrape-token")
+	defer viper.Set("metrics_token", "")
+	assert.Equal(t, http.StatusUnauthorized, scrape(AddRouter(), "Bearer wrong-token"))
+	assert.Equal(t, http.StatusOK, scrape(AddRouter(), "Bearer scrape-token"))
+	assert.Equal(t, http.StatusOK, scrape(AddMetricsRouter(), "Bearer scrape-token"))
+
+	// Case: The metrics are only served on the internal port when it is set
+	viper.Set("metrics_port", "9100")
+	defer viper.Set("metrics_port", "")
+	assert.Equal(t, http.StatusNotFound, scrape(AddRouter(), "Bearer scrape-token"))
+
+	viper.Set("metrics_token", "")
+	assert.Equal(t, http.StatusOK, scrape(AddMetricsRouter(), ""))
+}
@@ -0,0 +1,120 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/ZiyanK/service-catalog-api/app/middleware"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// userUUIDKey is the key of the uuid of the authenticated user in the context of a call
+type userUUIDKey struct{}
+
+// publicServices are the services which do not require an auth token
+var publicServices = map[string]bool{
+	"grpc.reflection.v1.ServerReflection":      true,
+	"grpc.reflection.v1alpha.ServerReflection": true,
+}
+
+// authenticate verifies the bearer token in the `authorization` metadata and stores the user in the context
+func authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	if publicServices[serviceName(fullMethod)] {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) != 1 {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+
+	userUUID, err := middleware.VerifyToken(ctx, values[0])
+	if err != nil {
+		if errors.Is(err, middleware.ErrUnauthorized) {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		log.Error("Error while verifying token", zap.Error(err))
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return context.WithValue(ctx, userUUIDKey{}, userUUID), nil
+}
+
+// UnaryAuthInterceptor authenticates the unary calls
+func UnaryAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := authenticate(withRequestInfo(ctx), info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// StreamAuthInterceptor authenticates the streaming calls
+func StreamAuthInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := authenticate(withRequestInfo(ss.Context()), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticatedStream is a server stream carrying the context with the authenticated user
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// withRequestInfo stores the request id and the address of the peer of a call in it's context, they are recorded in the audit log.
+// The request id is taken from the `x-request-id` metadata when the client sends it.
+func withRequestInfo(ctx context.Context) context.Context {
+	info := model.RequestInfo{RequestID: uuid.NewString()}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(middleware.RequestIDHeader); len(values) == 1 && values[0] != "" {
+		info.RequestID = values[0]
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		info.ClientIP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(info.ClientIP); err == nil {
+			info.ClientIP = host
+		}
+	}
+
+	return model.WithRequestInfo(ctx, info)
+}
+
+// userUUID returns the uuid of the user making the call
+func userUUID(ctx context.Context) (uuid.UUID, error) {
+	userUUID, ok := ctx.Value(userUUIDKey{}).(uuid.UUID)
+	if !ok {
+		return uuid.Nil, status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	return userUUID, nil
+}
+
+// serviceName returns the service of a full method name, e.g. `catalog.v1.CatalogService` for
+// `/catalog.v1.CatalogService/GetService`
+func serviceName(fullMethod string) string {
+	for i := len(fullMethod) - 1; i > 0; i-- {
+		if fullMethod[i] == '/' {
+			return fullMethod[1:i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus maps the errors returned by the model to gRPC status errors using their kind
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	var modelErr *model.Error
+	if errors.As(err, &modelErr) {
+		switch modelErr.Kind {
+		case model.KindNotFound:
+			return status.Error(codes.NotFound, modelErr.Message)
+		case model.KindConflict:
+			return status.Error(codes.AlreadyExists, modelErr.Message)
+		case model.KindPrecondition:
+			return status.Error(codes.FailedPrecondition, modelErr.Message)
+		}
+	}
+
+	log.Error("Internal error in gRPC call", zap.Error(err))
+	return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,104 @@
+package rpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	catalogv1 "github.com/ZiyanK/service-catalog-api/app/rpc/catalog/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{err: nil, code: codes.OK},
+		{err: sql.ErrNoRows, code: codes.Internal},
+		{err: model.ErrUserNotFound, code: codes.NotFound},
+		{err: model.ErrServiceNotFound, code: codes.NotFound},
+		{err: model.ErrServiceVersionNotFound, code: codes.NotFound},
+		{err: model.ErrServiceExists, code: codes.AlreadyExists},
+		{err: model.ErrServiceModified, code: codes.FailedPrecondition},
+		{err: fmt.Errorf("importing versions: %w", model.ErrServiceVersionExists), code: codes.AlreadyExists},
+		{err: context.DeadlineExceeded, code: codes.DeadlineExceeded},
+		{err: status.Error(codes.InvalidArgument, "invalid"), code: codes.InvalidArgument},
+		{err: errors.New("connection refused"), code: codes.Internal},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.code, status.Code(toStatus(test.err)), "%v", test.err)
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	assert.Equal(t, "catalog.v1.CatalogService", serviceName("/catalog.v1.CatalogService/GetService"))
+	assert.Equal(t, "grpc.reflection.v1.ServerReflection", serviceName("/grpc.reflection.v1.ServerReflection/ServerReflectionInfo"))
+	assert.Equal(t, "", serviceName("GetService"))
+}
+
+// dial starts the server on an in-memory listener and returns a client connection to it
+func dial(t *testing.T) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(model.NewSQLRepositories())
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal("Failed to dial server:", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestServerRequiresAuthorization(t *testing.T) {
+	client := catalogv1.NewCatalogServiceClient(dial(t))
+
+	_, err := client.ListServices(context.Background(), &catalogv1.ListServicesRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestServerReflection(t *testing.T) {
+	client := reflectionpb.NewServerReflectionClient(dial(t))
+
+	stream, err := client.ServerReflectionInfo(context.Background())
+	if err != nil {
+		t.Fatal("Failed to open reflection stream:", err)
+	}
+
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		t.Fatal("Failed to send reflection request:", err)
+	}
+
+	response, err := stream.Recv()
+	if err != nil {
+		t.Fatal("Failed to receive reflection response:", err)
+	}
+
+	var services []string
+	for _, service := range response.GetListServicesResponse().GetService() {
+		services = append(services, service.GetName())
+	}
+	assert.Contains(t, services, "catalog.v1.CatalogService")
+}
@@ -0,0 +1,372 @@
+package rpc
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/ZiyanK/service-catalog-api/app/changelog"
+	"github.com/ZiyanK/service-catalog-api/app/logger"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	catalogv1 "github.com/ZiyanK/service-catalog-api/app/rpc/catalog/v1"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	log = logger.CreateLogger()
+)
+
+// serviceInput validates the name and description of a service with the same rules as the HTTP API
+type serviceInput struct {
+	Name        string `validate:"required,min=3"`
+	Description string `validate:"required,min=20"`
+}
+
+// versionInput validates a new version with the same rules as the HTTP API
+type versionInput struct {
+	Version   string        `validate:"required,min=2,max=64"`
+	Changelog string        `validate:"required_without=Changes,omitempty,min=10"`
+	Changes   []changeInput `validate:"omitempty,dive"`
+}
+
+// changeInput validates a categorized changelog entry of a version
+type changeInput struct {
+	Category    string `validate:"required,oneof=Added Changed Deprecated Removed Fixed Security"`
+	Description string `validate:"required"`
+}
+
+// Server implements the catalog gRPC service over the repositories
+type Server struct {
+	catalogv1.UnimplementedCatalogServiceServer
+
+	repos model.Repositories
+}
+
+// NewServer returns a gRPC server with the catalog service reading and writing through the repositories,
+// the auth interceptors and server reflection
+func NewServer(repos model.Repositories) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(UnaryAuthInterceptor),
+		grpc.ChainStreamInterceptor(StreamAuthInterceptor),
+	)
+
+	catalogv1.RegisterCatalogServiceServer(server, &Server{repos: repos})
+	reflection.Register(server)
+
+	return server
+}
+
+// ListServices returns the services of the user
+func (s *Server) ListServices(ctx context.Context, req *catalogv1.ListServicesRequest) (*catalogv1.ListServicesResponse, error) {
+	userUUID, err := userUUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	order := "ASC"
+	if req.GetOrder() == catalogv1.Order_ORDER_DESC {
+		order = "DESC"
+	}
+
+	services, err := s.repos.Services.GetServices(ctx, userUUID, int(req.GetLimit()), int(req.GetOffset()), req.GetName(), order)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	response := &catalogv1.ListServicesResponse{}
+	for _, service := range services {
+		response.Services = append(response.Services, toService(service))
+	}
+
+	return response, nil
+}
+
+// GetService returns a service with it's versions
+func (s *Server) GetService(ctx context.Context, req *catalogv1.GetServiceRequest) (*catalogv1.GetServiceResponse, error) {
+	userUUID, err := userUUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceID, err := toID(req.GetServiceId())
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := s.repos.Services.GetServiceByID(ctx, serviceID, userUUID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	versions, err := s.repos.Versions.GetServiceVersions(ctx, serviceID, userUUID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	response := &catalogv1.GetServiceResponse{
+		Service: toService(*service),
+	}
+	for _, version := range versions {
+		response.Versions = append(response.Versions, toVersion(version))
+	}
+
+	return response, nil
+}
+
+// CreateService creates a service for the user
+func (s *Server) CreateService(ctx context.Context, req *catalogv1.CreateServiceRequest) (*catalogv1.CreateServiceResponse, error) {
+	userUUID, err := userUUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validator.New().Struct(serviceInput{Name: req.GetName(), Description: req.GetDescription()})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	service := &model.Service{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		UserUUID:    userUUID,
+	}
+
+	err = s.repos.Services.CreateService(ctx, service)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	created, err := s.repos.Services.GetServiceByID(ctx, service.ServiceID, userUUID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &catalogv1.CreateServiceResponse{Service: toService(*created)}, nil
+}
+
+// UpdateService updates the name and the description of a service
+func (s *Server) UpdateService(ctx context.Context, req *catalogv1.UpdateServiceRequest) (*catalogv1.UpdateServiceResponse, error) {
+	userUUID, err := userUUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceID, err := toID(req.GetServiceId())
+	if err != nil {
+		return nil, err
+	}
+
+	err = validator.New().Struct(serviceInput{Name: req.GetName(), Description: req.GetDescription()})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	service := model.Service{
+		ServiceID:   serviceID,
+		UserUUID:    userUUID,
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+	}
+
+	err = s.repos.Services.UpdateService(ctx, &service)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	updated, err := s.repos.Services.GetServiceByID(ctx, serviceID, userUUID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &catalogv1.UpdateServiceResponse{Service: toService(*updated)}, nil
+}
+
+// DeleteService deletes a service along with it's versions
+func (s *Server) DeleteService(ctx context.Context, req *catalogv1.DeleteServiceRequest) (*catalogv1.DeleteServiceResponse, error) {
+	userUUID, err := userUUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceID, err := toID(req.GetServiceId())
+	if err != nil {
+		return nil, err
+	}
+
+	service := model.Service{
+		ServiceID: serviceID,
+		UserUUID:  userUUID,
+	}
+
+	err = s.repos.Services.DeleteService(ctx, &service)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &catalogv1.DeleteServiceResponse{}, nil
+}
+
+// ListVersions returns the versions of a service, newest first
+func (s *Server) ListVersions(ctx context.Context, req *catalogv1.ListVersionsRequest) (*catalogv1.ListVersionsResponse, error) {
+	userUUID, err := userUUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceID, err := toID(req.GetServiceId())
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.repos.Services.ServiceExists(ctx, serviceID, userUUID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if !exists {
+		return nil, status.Error(codes.NotFound, "service does not exist")
+	}
+
+	versions, err := s.repos.Versions.GetServiceVersions(ctx, serviceID, userUUID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	response := &catalogv1.ListVersionsResponse{}
+	for _, version := range versions {
+		response.Versions = append(response.Versions, toVersion(version))
+	}
+
+	return response, nil
+}
+
+// CreateVersion creates a version of a service
+func (s *Server) CreateVersion(ctx context.Context, req *catalogv1.CreateVersionRequest) (*catalogv1.CreateVersionResponse, error) {
+	userUUID, err := userUUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceID, err := toID(req.GetServiceId())
+	if err != nil {
+		return nil, err
+	}
+
+	input := versionInput{
+		Version:   req.GetVersion(),
+		Changelog: req.GetChangelog(),
+	}
+	changes := make([]model.ServiceVersionChange, 0, len(req.GetChanges()))
+	entries := make([]changelog.Change, 0, len(req.GetChanges()))
+	for _, change := range req.GetChanges() {
+		input.Changes = append(input.Changes, changeInput{Category: change.GetCategory(), Description: change.GetDescription()})
+		changes = append(changes, model.ServiceVersionChange{Category: change.GetCategory(), Description: change.GetDescription()})
+		entries = append(entries, changelog.Change{Category: change.GetCategory(), Description: change.GetDescription()})
+	}
+
+	err = validator.New().Struct(input)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Structured changes are also rendered into the changelog text when it is not given
+	changelogText := req.GetChangelog()
+	if changelogText == "" {
+		var b strings.Builder
+		changelog.RenderChanges(&b, entries)
+		changelogText = strings.TrimSpace(b.String())
+	}
+
+	serviceVersion := model.ServiceVersion{
+		Version:   req.GetVersion(),
+		Changelog: changelogText,
+		ServiceID: serviceID,
+		Changes:   changes,
+	}
+
+	err = s.repos.Versions.CreateServiceVersion(ctx, userUUID, &serviceVersion)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	versions, err := s.repos.Versions.GetServiceVersions(ctx, serviceID, userUUID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	for _, version := range versions {
+		if version.SvID == serviceVersion.SvID {
+			return &catalogv1.CreateVersionResponse{Version: toVersion(version)}, nil
+		}
+	}
+
+	return &catalogv1.CreateVersionResponse{Version: toVersion(serviceVersion)}, nil
+}
+
+// DeleteVersion deletes a version of a service
+func (s *Server) DeleteVersion(ctx context.Context, req *catalogv1.DeleteVersionRequest) (*catalogv1.DeleteVersionResponse, error) {
+	userUUID, err := userUUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceID, err := toID(req.GetServiceId())
+	if err != nil {
+		return nil, err
+	}
+
+	svID, err := toID(req.GetVersionId())
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repos.Versions.DeleteServiceVersion(ctx, userUUID, serviceID, svID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &catalogv1.DeleteVersionResponse{}, nil
+}
+
+// toID converts an id of a request, ids which cannot exist are not found like invalid ids in the HTTP API
+func toID(id int64) (int, error) {
+	if id <= 0 || id > math.MaxInt32 {
+		return 0, status.Error(codes.NotFound, "not found")
+	}
+	return int(id), nil
+}
+
+// toService converts a service of the model to it's message
+func toService(service model.Service) *catalogv1.Service {
+	return &catalogv1.Service{
+		ServiceId:     int64(service.ServiceID),
+		Name:          service.Name,
+		Description:   service.Description,
+		VersionsCount: int64(service.VersionsCount),
+		CreatedAt:     timestamppb.New(service.CreatedAt),
+		UpdatedAt:     timestamppb.New(service.UpdatedAt),
+	}
+}
+
+// toVersion converts a version of the model to it's message
+func toVersion(version model.ServiceVersion) *catalogv1.Version {
+	message := &catalogv1.Version{
+		VersionId: int64(version.SvID),
+		ServiceId: int64(version.ServiceID),
+		Version:   version.Version,
+		Changelog: version.Changelog,
+		CreatedAt: timestamppb.New(version.CreatedAt),
+		UpdatedAt: timestamppb.New(version.UpdatedAt),
+	}
+	for _, change := range version.Changes {
+		message.Changes = append(message.Changes, &catalogv1.Change{
+			Category:    change.Category,
+			Description: change.Description,
+		})
+	}
+	return message
+}
@@ -0,0 +1,207 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a struct used to represent a semantic version (https://semver.org)
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string
+	Build      string
+}
+
+// Parse parses a semantic version, an optional leading `v` is allowed
+func Parse(value string) (Version, error) {
+	var version Version
+
+	s := strings.TrimPrefix(strings.TrimSpace(value), "v")
+
+	if i := strings.Index(s, "+"); i >= 0 {
+		version.Build = s[i+1:]
+		s = s[:i]
+		if version.Build == "" {
+			return Version{}, fmt.Errorf("invalid version %q: empty build metadata", value)
+		}
+	}
+
+	if i := strings.Index(s, "-"); i >= 0 {
+		version.Prerelease = strings.Split(s[i+1:], ".")
+		s = s[:i]
+		for _, identifier := range version.Prerelease {
+			if identifier == "" {
+				return Version{}, fmt.Errorf("invalid version %q: empty prerelease identifier", value)
+			}
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version %q: expected MAJOR.MINOR.PATCH", value)
+	}
+
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || (len(part) > 1 && part[0] == '0') {
+			return Version{}, fmt.Errorf("invalid version %q: %q is not a valid number", value, part)
+		}
+		numbers[i] = n
+	}
+
+	version.Major, version.Minor, version.Patch = numbers[0], numbers[1], numbers[2]
+
+	return version, nil
+}
+
+// MustParse is like Parse but panics if the version cannot be parsed
+func MustParse(value string) Version {
+	version, err := Parse(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return version
+}
+
+// IsValid reports whether the value is a semantic version
+func IsValid(value string) bool {
+	_, err := Parse(value)
+	return err == nil
+}
+
+// String returns the version without the leading `v`
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+
+	return s
+}
+
+// Compare returns -1, 0 or 1 depending on the precedence of v compared to other.
+// Build metadata is ignored as required by the specification.
+func (v Version) Compare(other Version) int {
+	if c := compareInt(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+
+	// A version without prerelease has a higher precedence
+	switch {
+	case len(v.Prerelease) == 0 && len(other.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(other.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(other.Prerelease); i++ {
+		if c := compareIdentifier(v.Prerelease[i], other.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	return compareInt(len(v.Prerelease), len(other.Prerelease))
+}
+
+// LessThan reports whether v has a lower precedence than other
+func (v Version) LessThan(other Version) bool {
+	return v.Compare(other) < 0
+}
+
+// Bump is the kind of increment applied to a version
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+// String returns the name of the bump
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	}
+
+	return "none"
+}
+
+// Increment returns the next version for the given bump, prerelease and build metadata are dropped.
+// A prerelease of the version the bump leads to is released instead of incremented (1.0.0-rc.1 is released as 1.0.0 by
+// any bump, 1.1.0-rc.1 by a minor or patch bump), like npm does. Incrementing with BumpNone returns the version unchanged.
+func (v Version) Increment(bump Bump) Version {
+	next := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	prerelease := len(v.Prerelease) > 0
+
+	switch bump {
+	case BumpMajor:
+		if !prerelease || v.Minor != 0 || v.Patch != 0 {
+			next.Major++
+		}
+		next.Minor = 0
+		next.Patch = 0
+	case BumpMinor:
+		if !prerelease || v.Patch != 0 {
+			next.Minor++
+		}
+		next.Patch = 0
+	case BumpPatch:
+		if !prerelease {
+			next.Patch++
+		}
+	default:
+		return v
+	}
+
+	return next
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// compareIdentifier compares two prerelease identifiers, numeric identifiers have a lower precedence
+func compareIdentifier(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareInt(an, bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
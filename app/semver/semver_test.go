@@ -0,0 +1,70 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	v, err := Parse("v1.2.3-rc.1+build.5")
+	if err != nil {
+		t.Fatal("Failed to parse version:", err)
+	}
+
+	assert.Equal(t, 1, v.Major)
+	assert.Equal(t, 2, v.Minor)
+	assert.Equal(t, 3, v.Patch)
+	assert.Equal(t, []string{"rc", "1"}, v.Prerelease)
+	assert.Equal(t, "build.5", v.Build)
+	assert.Equal(t, "1.2.3-rc.1+build.5", v.String())
+
+	// Case fail: Invalid versions
+	for _, value := range []string{"", "1.2", "1.2.3.4", "01.2.3", "1.2.3-", "1.2.3-rc..1", "a.b.c"} {
+		_, err := Parse(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Ordered by precedence as given in the specification
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+	}
+
+	for i := 0; i < len(ordered)-1; i++ {
+		assert.True(t, MustParse(ordered[i]).LessThan(MustParse(ordered[i+1])), ordered[i]+" < "+ordered[i+1])
+		assert.Equal(t, 1, MustParse(ordered[i+1]).Compare(MustParse(ordered[i])))
+	}
+
+	assert.Equal(t, 0, MustParse("v1.0.0+a").Compare(MustParse("1.0.0+b")))
+}
+
+func TestIncrement(t *testing.T) {
+	v := MustParse("1.2.3")
+
+	assert.Equal(t, "2.0.0", v.Increment(BumpMajor).String())
+	assert.Equal(t, "1.3.0", v.Increment(BumpMinor).String())
+	assert.Equal(t, "1.2.4", v.Increment(BumpPatch).String())
+	assert.Equal(t, "1.2.3", v.Increment(BumpNone).String())
+
+	// Case: Releasing a prerelease of the version the bump leads to
+	assert.Equal(t, "1.2.3", MustParse("1.2.3-rc.1").Increment(BumpPatch).String())
+	assert.Equal(t, "1.0.0", MustParse("1.0.0-rc.1").Increment(BumpMinor).String())
+	assert.Equal(t, "1.0.0", MustParse("1.0.0-rc.1").Increment(BumpMajor).String())
+	assert.Equal(t, "1.1.0", MustParse("1.1.0-rc.1").Increment(BumpMinor).String())
+
+	// Case: Incrementing past a prerelease of a smaller bump
+	assert.Equal(t, "1.2.0", MustParse("1.1.1-rc.1").Increment(BumpMinor).String())
+	assert.Equal(t, "2.0.0", MustParse("1.1.0-rc.1").Increment(BumpMajor).String())
+}
@@ -0,0 +1,145 @@
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ZiyanK/service-catalog-api/app/outbox"
+	"github.com/google/uuid"
+)
+
+// subscriptionBuffer is the number of events buffered for a subscriber before it is dropped
+const subscriptionBuffer = 256
+
+var (
+	hub = NewHub()
+)
+
+// Hub fans out the published catalog events to the subscribers of each user
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[uuid.UUID]map[*Subscription]struct{}
+	closed      bool
+}
+
+// Subscription receives the events of a user until it is closed
+type Subscription struct {
+	userUUID uuid.UUID
+	events   chan outbox.Event
+	once     sync.Once
+}
+
+// NewHub returns a hub without subscribers
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[uuid.UUID]map[*Subscription]struct{}),
+	}
+}
+
+// GetHub gets the hub of the instance, the events streamed to the clients are passed to it
+func GetHub() *Hub {
+	return hub
+}
+
+// Events returns the channel of events, it is closed when the subscription is dropped or closed
+func (s *Subscription) Events() <-chan outbox.Event {
+	return s.events
+}
+
+// Subscribe returns a subscription to the events of a user, it must be closed with Unsubscribe.
+// The subscription is already closed when the hub is closed.
+func (h *Hub) Subscribe(userUUID uuid.UUID) *Subscription {
+	subscription := &Subscription{
+		userUUID: userUUID,
+		events:   make(chan outbox.Event, subscriptionBuffer),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		subscription.once.Do(func() {
+			close(subscription.events)
+		})
+		return subscription
+	}
+
+	if h.subscribers[userUUID] == nil {
+		h.subscribers[userUUID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[userUUID][subscription] = struct{}{}
+
+	return subscription
+}
+
+// Unsubscribe removes the subscription from the hub and closes it
+func (h *Hub) Unsubscribe(subscription *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(subscription)
+}
+
+// Close closes the subscriptions and the ones made afterwards, so that the streams end when the server shuts down.
+// The clients reconnect to another instance with their last event.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subscriptions := range h.subscribers {
+		for subscription := range subscriptions {
+			h.remove(subscription)
+		}
+	}
+}
+
+// Subscribers returns the number of subscriptions
+func (h *Hub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	count := 0
+	for _, subscriptions := range h.subscribers {
+		count += len(subscriptions)
+	}
+	return count
+}
+
+// Publish passes the event to the subscribers of it's user without blocking.
+// A subscriber which does not keep up is dropped, it can resume from the last event it received.
+func (h *Hub) Publish(ctx context.Context, event outbox.Event) error {
+	h.mu.RLock()
+	var dropped []*Subscription
+	for subscription := range h.subscribers[event.UserUUID] {
+		select {
+		case subscription.events <- event:
+		default:
+			dropped = append(dropped, subscription)
+		}
+	}
+	h.mu.RUnlock()
+
+	if len(dropped) > 0 {
+		h.mu.Lock()
+		for _, subscription := range dropped {
+			h.remove(subscription)
+		}
+		h.mu.Unlock()
+	}
+
+	return nil
+}
+
+// remove deletes the subscription and closes it's channel, the caller must hold the write lock
+func (h *Hub) remove(subscription *Subscription) {
+	subscriptions := h.subscribers[subscription.userUUID]
+	delete(subscriptions, subscription)
+	if len(subscriptions) == 0 {
+		delete(h.subscribers, subscription.userUUID)
+	}
+
+	subscription.once.Do(func() {
+		close(subscription.events)
+	})
+}
@@ -0,0 +1,71 @@
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ZiyanK/service-catalog-api/app/outbox"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubPublish(t *testing.T) {
+	hub := NewHub()
+	user, other := uuid.New(), uuid.New()
+
+	first := hub.Subscribe(user)
+	second := hub.Subscribe(user)
+	third := hub.Subscribe(other)
+	assert.Equal(t, 3, hub.Subscribers())
+
+	assert.NoError(t, hub.Publish(context.Background(), outbox.Event{Sequence: 1, UserUUID: user}))
+
+	assert.Equal(t, int64(1), (<-first.Events()).Sequence)
+	assert.Equal(t, int64(1), (<-second.Events()).Sequence)
+	assert.Len(t, third.Events(), 0)
+
+	hub.Unsubscribe(first)
+	hub.Unsubscribe(first)
+	_, ok := <-first.Events()
+	assert.False(t, ok)
+	assert.Equal(t, 2, hub.Subscribers())
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := NewHub()
+	user := uuid.New()
+
+	subscription := hub.Subscribe(user)
+	for i := 0; i <= subscriptionBuffer; i++ {
+		assert.NoError(t, hub.Publish(context.Background(), outbox.Event{Sequence: int64(i), UserUUID: user}))
+	}
+
+	// The buffered events are still received before the channel is closed
+	received := 0
+	for range subscription.Events() {
+		received++
+	}
+	assert.Equal(t, subscriptionBuffer, received)
+	assert.Equal(t, 0, hub.Subscribers())
+
+	hub.Unsubscribe(subscription)
+}
+
+func TestHubClose(t *testing.T) {
+	hub := NewHub()
+	user := uuid.New()
+
+	subscription := hub.Subscribe(user)
+	hub.Close()
+
+	_, ok := <-subscription.Events()
+	assert.False(t, ok)
+	assert.Equal(t, 0, hub.Subscribers())
+
+	// Case: The subscriptions made once the hub is closed are closed
+	subscription = hub.Subscribe(user)
+	_, ok = <-subscription.Events()
+	assert.False(t, ok)
+	hub.Unsubscribe(subscription)
+	assert.Equal(t, 0, hub.Subscribers())
+}
@@ -0,0 +1,50 @@
+package stream
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/ZiyanK/service-catalog-api/app/db"
+	"github.com/ZiyanK/service-catalog-api/app/logger"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/outbox"
+	"go.uber.org/zap"
+)
+
+// eventsChannel is the Postgres channel the positions of the events streamed to the clients are sent to
+const eventsChannel = "catalog_events"
+
+var (
+	log = logger.CreateLogger()
+)
+
+// Notifier is the sink of the relay for the event stream when the server runs on several instances.
+// The position of every event is sent with Postgres NOTIFY so that the hub of every instance receives it, not only the
+// hub of the instance running the relay.
+type Notifier struct{}
+
+// Publish notifies the position of the event to the listeners of every instance
+func (Notifier) Publish(ctx context.Context, event outbox.Event) error {
+	return db.GetDBInstance().Notify(ctx, eventsChannel, strconv.FormatInt(event.PublishedSeq, 10))
+}
+
+// Listen passes the events notified by the relays to the subscribers of the hub until the context is cancelled
+func (h *Hub) Listen(ctx context.Context) {
+	err := db.GetDBInstance().Listen(ctx, eventsChannel, func(payload string) {
+		publishedSeq, err := strconv.ParseInt(payload, 10, 64)
+		if err != nil {
+			log.Error("Invalid event position notified", zap.String("payload", payload))
+			return
+		}
+
+		event, err := model.GetPublishedEvent(ctx, publishedSeq)
+		if err != nil {
+			return
+		}
+
+		h.Publish(ctx, outbox.FromOutboxEvent(*event))
+	})
+	if err != nil {
+		log.Error("Error while listening to the events", zap.Error(err))
+	}
+}
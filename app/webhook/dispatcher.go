@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ZiyanK/service-catalog-api/app/backoff"
+	"github.com/ZiyanK/service-catalog-api/app/logger"
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"go.uber.org/zap"
+)
+
+var (
+	log = logger.CreateLogger()
+)
+
+// Dispatcher periodically sends the pending deliveries and retries the failed ones with an exponential backoff
+type Dispatcher struct {
+	Sender *Sender
+
+	// Interval is the time between two polls of the pending deliveries
+	Interval time.Duration
+	// BatchSize is the maximum number of deliveries sent concurrently
+	BatchSize int
+	// Lease is the time a claimed delivery is hidden from other dispatchers while it is being sent
+	Lease time.Duration
+
+	// MaxAttempts is the number of attempts after which a delivery is failed
+	MaxAttempts int
+	// BaseBackoff and MaxBackoff bound the delay between two attempts of a delivery
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	// DisableAfter is the number of consecutive failed deliveries after which a webhook is disabled
+	DisableAfter int
+}
+
+// NewDispatcher returns a dispatcher with the default settings.
+// A delivery is attempted 6 times over about 30 minutes and a webhook is disabled after 5 failed deliveries in a row.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		Sender: &Sender{
+			Client: NewClient(10 * time.Second),
+		},
+		Interval:     5 * time.Second,
+		BatchSize:    20,
+		Lease:        time.Minute,
+		MaxAttempts:  6,
+		BaseBackoff:  time.Minute,
+		MaxBackoff:   time.Hour,
+		DisableAfter: 5,
+	}
+}
+
+// Run sends the pending deliveries until the context is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		// A full batch means there could be more deliveries due
+		for d.dispatch(ctx) == d.BatchSize {
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch sends a batch of due deliveries and returns the number of deliveries sent
+func (d *Dispatcher) dispatch(ctx context.Context) int {
+	now := time.Now().UTC()
+
+	deliveries, err := model.ClaimWebhookDeliveries(ctx, now, now.Add(d.Lease), d.BatchSize)
+	if err != nil {
+		log.Error("Error while claiming webhook deliveries", zap.Error(err))
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery model.PendingWebhookDelivery) {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+
+	return len(deliveries)
+}
+
+// deliver sends a delivery and records the attempt
+func (d *Dispatcher) deliver(ctx context.Context, delivery model.PendingWebhookDelivery) {
+	attemptedAt := time.Now().UTC()
+
+	result := d.Sender.Send(ctx, Request{
+		URL:        delivery.URL,
+		Secret:     delivery.Secret,
+		Event:      delivery.Event,
+		EventID:    delivery.EventID.String(),
+		DeliveryID: delivery.DeliveryID,
+		Payload:    []byte(delivery.Payload),
+	}, attemptedAt)
+
+	attempt := model.WebhookAttempt{
+		ResponseStatus: result.StatusCode,
+		ResponseBody:   result.Body,
+		AttemptedAt:    attemptedAt,
+		NextAttemptAt:  attemptedAt,
+	}
+
+	attempts := delivery.Attempts + 1
+	switch {
+	case result.OK():
+		attempt.Status = model.DeliveryStatusSucceeded
+	case attempts >= d.MaxAttempts:
+		attempt.Status = model.DeliveryStatusFailed
+		attempt.Error = result.Err.Error()
+	default:
+		attempt.Status = model.DeliveryStatusPending
+		attempt.Error = result.Err.Error()
+		attempt.NextAttemptAt = attemptedAt.Add(backoff.Exponential(attempts, d.BaseBackoff, d.MaxBackoff))
+	}
+
+	if !result.OK() {
+		log.Info("Webhook delivery failed",
+			zap.Int("delivery_id", delivery.DeliveryID),
+			zap.Int("webhook_id", delivery.WebhookID),
+			zap.Int("attempts", attempts),
+			zap.Error(result.Err))
+	}
+
+	// The attempt is recorded even if the dispatcher is stopping so that the delivery is not sent twice
+	err := model.RecordWebhookAttempt(context.Background(), delivery.WebhookDelivery, attempt, d.DisableAfter)
+	if err != nil {
+		log.Error("Error while recording webhook delivery attempt", zap.Error(err), zap.Int("delivery_id", delivery.DeliveryID))
+	}
+}
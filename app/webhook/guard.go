@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when a webhook url is or resolves to an address of the internal network
+var ErrForbiddenAddress = errors.New("the address of the webhook is not allowed")
+
+// AllowPrivateNetworks allows the webhooks to be delivered to loopback, link-local and private addresses.
+// It is only meant for local setups where the receivers run next to the server, it is set at startup.
+var AllowPrivateNetworks bool
+
+// forbiddenNetworks are the reserved networks which are not covered by the checks of net.IP
+var forbiddenNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("192.0.0.0/24"),
+	mustParseCIDR("198.18.0.0/15"),
+	mustParseCIDR("240.0.0.0/4"),
+	mustParseCIDR("64:ff9b::/96"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// AllowedIP checks if a webhook can be delivered to an address, the loopback, link-local (including the cloud metadata
+// address 169.254.169.254), private, multicast and reserved addresses are refused
+func AllowedIP(ip net.IP) bool {
+	if AllowPrivateNetworks {
+		return true
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+
+	for _, network := range forbiddenNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidateURL checks the url of a webhook when it is saved, the hosts which are known to be internal are refused.
+// A host name is only resolved at delivery time, where the address is checked again by the client.
+func ValidateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if AllowPrivateNetworks {
+		return nil
+	}
+
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenAddress
+	}
+
+	if ip := net.ParseIP(host); ip != nil && !AllowedIP(ip) {
+		return ErrForbiddenAddress
+	}
+
+	return nil
+}
+
+// NewClient returns the client sending the deliveries. The address is checked when the connection is made, after the
+// host is resolved and for every redirect, so that a host resolving to an internal address at delivery time is refused.
+// The proxies of the environment are not used as the address could not be checked.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			ip := net.ParseIP(host)
+			if ip == nil || !AllowedIP(ip) {
+				return fmt.Errorf("%w: %v", ErrForbiddenAddress, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
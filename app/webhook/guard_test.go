@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowedIP(t *testing.T) {
+	for _, address := range []string{"127.0.0.1", "::1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"fe80::1", "fd00::1", "100.64.0.1", "0.0.0.0", "::", "224.0.0.1", "::ffff:127.0.0.1"} {
+		assert.False(t, AllowedIP(net.ParseIP(address)), address)
+	}
+
+	for _, address := range []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"} {
+		assert.True(t, AllowedIP(net.ParseIP(address)), address)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	assert.NoError(t, ValidateURL("https://hooks.example.com/catalog"))
+
+	for _, rawURL := range []string{"http://localhost:8080", "http://api.localhost", "http://127.0.0.1/hook",
+		"http://[::1]/hook", "http://169.254.169.254/latest/meta-data", "http://10.0.0.5"} {
+		assert.ErrorIs(t, ValidateURL(rawURL), ErrForbiddenAddress, rawURL)
+	}
+
+	assert.Error(t, ValidateURL("ftp://hooks.example.com"))
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := &Sender{Client: NewClient(time.Second)}
+	request := Request{URL: server.URL, Secret: "secret", Event: "service.created", Payload: []byte(`{}`)}
+
+	// Case fail: The server listens on the loopback address
+	result := sender.Send(context.Background(), request, time.Now())
+	assert.False(t, result.OK())
+	assert.True(t, errors.Is(result.Err, ErrForbiddenAddress), result.Err)
+
+	// Case: The private networks are allowed for local setups
+	AllowPrivateNetworks = true
+	defer func() { AllowPrivateNetworks = false }()
+
+	result = sender.Send(context.Background(), request, time.Now())
+	assert.True(t, result.OK())
+}
@@ -0,0 +1,19 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ZiyanK/service-catalog-api/app/model"
+	"github.com/ZiyanK/service-catalog-api/app/outbox"
+)
+
+// Publish creates a delivery of an outbox event for every webhook of the user or of the team of the service subscribed to it, the dispatcher then sends them
+func Publish(ctx context.Context, event outbox.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return model.EnqueueWebhookDeliveries(ctx, event.UserUUID, event.TeamID, event.ID, event.Event, string(payload))
+}
@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderEventID   = "X-Webhook-Event-Id"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// signaturePrefix is the prefix of the signature header value
+const signaturePrefix = "sha256="
+
+// maxResponseBody is the number of bytes of the response body kept in the delivery log
+const maxResponseBody = 1024
+
+// GenerateSecret returns a random secret used to sign the payloads of a webhook
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature of a payload sent at the given unix timestamp.
+// The HMAC-SHA256 is computed over `<timestamp>.<body>` so that a captured request cannot be replayed with another timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a payload, it can be used by receivers written in Go
+func Verify(secret, signature string, timestamp int64, body []byte) bool {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
+
+// Request is a delivery to send to a webhook
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	EventID    string
+	DeliveryID int
+	Payload    []byte
+}
+
+// Result is the outcome of sending a delivery
+type Result struct {
+	StatusCode int
+	Body       string
+	Err        error
+}
+
+// OK checks if the delivery was accepted, any 2xx response is a success
+func (r Result) OK() bool {
+	return r.Err == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Sender sends the signed deliveries
+type Sender struct {
+	Client *http.Client
+}
+
+// Send posts the payload to the webhook url, the returned result is never nil even when the request fails
+func (s *Sender) Send(ctx context.Context, request Request, now time.Time) Result {
+	timestamp := now.Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, request.URL, bytes.NewReader(request.Payload))
+	if err != nil {
+		return Result{Err: err}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "service-catalog-webhook/1.0")
+	req.Header.Set(HeaderEvent, request.Event)
+	req.Header.Set(HeaderEventID, request.EventID)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(request.DeliveryID))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(request.Secret, timestamp, request.Payload))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return Result{Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+
+	result := Result{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	}
+	if !result.OK() {
+		result.Err = fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return result
+}
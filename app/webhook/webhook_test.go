@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"event":"service.created"}`)
+
+	signature := Sign("secret", 1714500000, body)
+	assert.Len(t, signature, len("sha256=")+64)
+	assert.True(t, Verify("secret", signature, 1714500000, body))
+
+	// Case fail: Another secret, timestamp or body
+	assert.False(t, Verify("other", signature, 1714500000, body))
+	assert.False(t, Verify("secret", signature, 1714500001, body))
+	assert.False(t, Verify("secret", signature, 1714500000, []byte(`{}`)))
+	assert.False(t, Verify("secret", signature[len("sha256="):], 1714500000, body))
+}
+
+func TestSend(t *testing.T) {
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+
+		if !Verify("secret", r.Header.Get(HeaderSignature), timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		assert.Equal(t, "version.created", r.Header.Get(HeaderEvent))
+		assert.Equal(t, "7", r.Header.Get(HeaderDelivery))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sender := &Sender{Client: server.Client()}
+	request := Request{
+		URL:        server.URL,
+		Secret:     "secret",
+		Event:      "version.created",
+		EventID:    "0b8f6a52-4d1c-4a4e-9a39-7f0e6f2b1c11",
+		DeliveryID: 7,
+		Payload:    []byte(`{"event":"version.created"}`),
+	}
+
+	result := sender.Send(context.Background(), request, time.Now())
+	assert.True(t, result.OK())
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+
+	// Case fail: Invalid signature
+	request.Secret = "other"
+	result = sender.Send(context.Background(), request, time.Now())
+	assert.False(t, result.OK())
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+	assert.Error(t, result.Err)
+
+	// Case fail: Server error
+	request.Secret = "secret"
+	status = http.StatusBadGateway
+	result = sender.Send(context.Background(), request, time.Now())
+	assert.False(t, result.OK())
+
+	// Case fail: Unreachable url
+	server.Close()
+	result = sender.Send(context.Background(), request, time.Now())
+	assert.False(t, result.OK())
+	assert.Zero(t, result.StatusCode)
+}
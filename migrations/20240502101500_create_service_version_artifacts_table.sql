@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "service_version_artifacts" (
+  "artifact_id" SERIAL PRIMARY KEY,
+  "sv_id" INTEGER NOT NULL,
+  "type" VARCHAR(20) NOT NULL,
+  "uri" TEXT NOT NULL,
+  "digest" VARCHAR(71) UNIQUE NOT NULL,
+  "size_bytes" BIGINT NOT NULL,
+  "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE "service_version_artifacts";
+-- +goose StatementEnd
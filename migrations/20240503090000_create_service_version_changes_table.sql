@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "service_versions" ALTER COLUMN "version" TYPE VARCHAR(64);
+
+CREATE TABLE "service_version_changes" (
+  "change_id" SERIAL PRIMARY KEY,
+  "sv_id" INTEGER NOT NULL,
+  "category" VARCHAR(12) NOT NULL,
+  "description" TEXT NOT NULL,
+  "position" INTEGER NOT NULL DEFAULT 0,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE "service_version_changes";
+-- +goose StatementEnd
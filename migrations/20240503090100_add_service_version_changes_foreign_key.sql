@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "service_version_changes" ADD CONSTRAINT fk_service_version_changes_service_version FOREIGN KEY ("sv_id") REFERENCES "service_versions" ("sv_id");
+CREATE INDEX idx_svc_sv_id ON service_version_changes (sv_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_svc_sv_id;
+ALTER TABLE "service_version_changes" DROP CONSTRAINT fk_service_version_changes_service_version;
+-- +goose StatementEnd
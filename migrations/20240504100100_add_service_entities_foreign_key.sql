@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "service_entities" ADD CONSTRAINT fk_service_entities_service FOREIGN KEY ("service_id") REFERENCES "services" ("service_id");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "service_entities" DROP CONSTRAINT fk_service_entities_service;
+-- +goose StatementEnd
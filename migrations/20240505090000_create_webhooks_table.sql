@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "webhooks" (
+  "webhook_id" SERIAL PRIMARY KEY,
+  "user_uuid" UUID NOT NULL,
+  "url" TEXT NOT NULL,
+  "secret" VARCHAR(64) NOT NULL,
+  "events" TEXT NOT NULL,
+  "active" BOOLEAN NOT NULL DEFAULT TRUE,
+  "failure_count" INTEGER NOT NULL DEFAULT 0,
+  "disabled_at" TIMESTAMP,
+  "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE "webhooks";
+-- +goose StatementEnd
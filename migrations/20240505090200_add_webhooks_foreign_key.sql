@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "webhooks" ADD CONSTRAINT fk_webhooks_users FOREIGN KEY ("user_uuid") REFERENCES "users" ("user_uuid");
+ALTER TABLE "webhook_deliveries" ADD CONSTRAINT fk_webhook_deliveries_webhooks FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("webhook_id");
+CREATE INDEX idx_webhooks_user_uuid ON webhooks (user_uuid);
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, delivery_id);
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_webhook_deliveries_pending;
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook_id;
+DROP INDEX IF EXISTS idx_webhooks_user_uuid;
+ALTER TABLE "webhook_deliveries" DROP CONSTRAINT fk_webhook_deliveries_webhooks;
+ALTER TABLE "webhooks" DROP CONSTRAINT fk_webhooks_users;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "outbox_events" (
+  "outbox_id" BIGSERIAL PRIMARY KEY,
+  "event_id" UUID UNIQUE NOT NULL,
+  "event" VARCHAR(50) NOT NULL,
+  "user_uuid" UUID NOT NULL,
+  "service_id" INTEGER NOT NULL,
+  "payload" TEXT NOT NULL,
+  "attempts" INTEGER NOT NULL DEFAULT 0,
+  "last_error" TEXT,
+  "published_at" TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE "outbox_events";
+-- +goose StatementEnd
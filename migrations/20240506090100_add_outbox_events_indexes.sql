@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX idx_outbox_events_unpublished ON outbox_events (outbox_id) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_events_user_uuid ON outbox_events (user_uuid, outbox_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_outbox_events_user_uuid;
+DROP INDEX IF EXISTS idx_outbox_events_unpublished;
+-- +goose StatementEnd
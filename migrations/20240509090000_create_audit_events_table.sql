@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "audit_events" (
+  "audit_id" BIGSERIAL PRIMARY KEY,
+  "actor_uuid" UUID NOT NULL,
+  "action" VARCHAR(50) NOT NULL,
+  "target_type" VARCHAR(50) NOT NULL,
+  "target_id" VARCHAR(255) NOT NULL,
+  "before" TEXT,
+  "after" TEXT,
+  "request_id" VARCHAR(255) NOT NULL DEFAULT '',
+  "client_ip" VARCHAR(45) NOT NULL DEFAULT '',
+  "prev_hash" VARCHAR(64) NOT NULL,
+  "hash" VARCHAR(64) UNIQUE NOT NULL,
+  "created_at" TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_audit_events_actor_uuid ON audit_events (actor_uuid, audit_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- The audit log is append-only
+CREATE FUNCTION reject_audit_events_change() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW EXECUTE FUNCTION reject_audit_events_change();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE "audit_events";
+DROP FUNCTION IF EXISTS reject_audit_events_change();
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A revision of a service is it's state for a row version, it is current until valid_to
+CREATE TABLE "services_history" (
+  "service_id" INTEGER NOT NULL,
+  "revision" BIGINT NOT NULL,
+  "user_uuid" UUID NOT NULL,
+  "name" VARCHAR(255) NOT NULL,
+  "description" TEXT,
+  "valid_from" TIMESTAMP NOT NULL,
+  "valid_to" TIMESTAMP,
+  PRIMARY KEY ("service_id", "revision")
+);
+CREATE INDEX idx_services_history_valid_from ON services_history (service_id, valid_from);
+
+-- A version of a service from the revision it was added in to the revision it was removed in
+CREATE TABLE "service_versions_history" (
+  "sv_id" INTEGER PRIMARY KEY,
+  "service_id" INTEGER NOT NULL,
+  "version" VARCHAR(64) NOT NULL,
+  "changelog" TEXT,
+  "added_in" BIGINT NOT NULL,
+  "removed_in" BIGINT,
+  "valid_from" TIMESTAMP NOT NULL,
+  "valid_to" TIMESTAMP
+);
+CREATE INDEX idx_service_versions_history_service_id ON service_versions_history (service_id, valid_from);
+
+-- The current state is the first revision of the existing services
+INSERT INTO services_history(service_id, revision, user_uuid, name, description, valid_from)
+SELECT s.service_id, s.row_version, s.user_uuid, s.name, s.description, COALESCE(s.updated_at, s.created_at, CURRENT_TIMESTAMP)
+FROM services s;
+
+INSERT INTO service_versions_history(sv_id, service_id, version, changelog, added_in, valid_from)
+SELECT sv.sv_id, sv.service_id, COALESCE(sv.version, ''), sv.changelog, s.row_version, COALESCE(sv.created_at, CURRENT_TIMESTAMP)
+FROM service_versions sv
+JOIN services s ON s.service_id = sv.service_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE "service_versions_history";
+DROP TABLE "services_history";
+-- +goose StatementEnd
@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A service with the same version twice keeps the oldest one, the artifacts of the others and their changes which
+-- it does not have are moved to it
+CREATE TEMPORARY TABLE duplicate_service_versions AS
+SELECT sv.sv_id, (SELECT MIN(k.sv_id) FROM service_versions k WHERE k.service_id = sv.service_id AND k.version = sv.version) AS kept_sv_id
+FROM service_versions sv
+WHERE EXISTS (SELECT 1 FROM service_versions k WHERE k.service_id = sv.service_id AND k.version = sv.version AND k.sv_id < sv.sv_id);
+
+DELETE FROM service_version_changes
+WHERE EXISTS (
+  SELECT 1 FROM duplicate_service_versions d
+  JOIN service_version_changes k ON k.sv_id = d.kept_sv_id
+  WHERE d.sv_id = service_version_changes.sv_id AND k.category = service_version_changes.category AND k.description = service_version_changes.description
+);
+UPDATE service_version_changes
+SET sv_id = (SELECT d.kept_sv_id FROM duplicate_service_versions d WHERE d.sv_id = service_version_changes.sv_id)
+WHERE sv_id IN (SELECT sv_id FROM duplicate_service_versions);
+UPDATE service_version_artifacts
+SET sv_id = (SELECT d.kept_sv_id FROM duplicate_service_versions d WHERE d.sv_id = service_version_artifacts.sv_id)
+WHERE sv_id IN (SELECT sv_id FROM duplicate_service_versions);
+DELETE FROM service_versions WHERE sv_id IN (SELECT sv_id FROM duplicate_service_versions);
+DROP TABLE duplicate_service_versions;
+
+-- A user with services with the same name keeps the name for the oldest one, the others are suffixed with their id
+UPDATE services SET name = SUBSTRING(name, 1, 240) || ' (' || service_id || ')'
+WHERE EXISTS (SELECT 1 FROM services k WHERE k.user_uuid = services.user_uuid AND k.name = services.name AND k.service_id < services.service_id);
+
+ALTER TABLE "services" ADD CONSTRAINT uq_services_user_uuid_name UNIQUE ("user_uuid", "name");
+ALTER TABLE "service_versions" ADD CONSTRAINT uq_service_versions_service_id_version UNIQUE ("service_id", "version");
+
+-- Deleting a service deletes it's entity and versions, deleting a version deletes it's artifacts and changes
+ALTER TABLE "service_versions" DROP CONSTRAINT fk_service_versions_service;
+ALTER TABLE "service_versions" ADD CONSTRAINT fk_service_versions_service FOREIGN KEY ("service_id") REFERENCES "services" ("service_id") ON DELETE CASCADE;
+ALTER TABLE "service_entities" DROP CONSTRAINT fk_service_entities_service;
+ALTER TABLE "service_entities" ADD CONSTRAINT fk_service_entities_service FOREIGN KEY ("service_id") REFERENCES "services" ("service_id") ON DELETE CASCADE;
+ALTER TABLE "service_version_artifacts" DROP CONSTRAINT fk_service_version_artifacts_service_version;
+ALTER TABLE "service_version_artifacts" ADD CONSTRAINT fk_service_version_artifacts_service_version FOREIGN KEY ("sv_id") REFERENCES "service_versions" ("sv_id") ON DELETE CASCADE;
+ALTER TABLE "service_version_changes" DROP CONSTRAINT fk_service_version_changes_service_version;
+ALTER TABLE "service_version_changes" ADD CONSTRAINT fk_service_version_changes_service_version FOREIGN KEY ("sv_id") REFERENCES "service_versions" ("sv_id") ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "service_version_changes" DROP CONSTRAINT fk_service_version_changes_service_version;
+ALTER TABLE "service_version_changes" ADD CONSTRAINT fk_service_version_changes_service_version FOREIGN KEY ("sv_id") REFERENCES "service_versions" ("sv_id");
+ALTER TABLE "service_version_artifacts" DROP CONSTRAINT fk_service_version_artifacts_service_version;
+ALTER TABLE "service_version_artifacts" ADD CONSTRAINT fk_service_version_artifacts_service_version FOREIGN KEY ("sv_id") REFERENCES "service_versions" ("sv_id");
+ALTER TABLE "service_entities" DROP CONSTRAINT fk_service_entities_service;
+ALTER TABLE "service_entities" ADD CONSTRAINT fk_service_entities_service FOREIGN KEY ("service_id") REFERENCES "services" ("service_id");
+ALTER TABLE "service_versions" DROP CONSTRAINT fk_service_versions_service;
+ALTER TABLE "service_versions" ADD CONSTRAINT fk_service_versions_service FOREIGN KEY ("service_id") REFERENCES "services" ("service_id");
+
+ALTER TABLE "service_versions" DROP CONSTRAINT uq_service_versions_service_id_version;
+ALTER TABLE "services" DROP CONSTRAINT uq_services_user_uuid_name;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A digest is unique for each user instead of across the catalog, so that a user cannot find out or block
+-- the digests of another user. The owner of the service is copied to the artifact to enforce it.
+ALTER TABLE "service_version_artifacts" ADD COLUMN "user_uuid" UUID;
+
+UPDATE "service_version_artifacts" a SET "user_uuid" = s.user_uuid
+FROM "service_versions" sv
+JOIN "services" s ON s.service_id = sv.service_id
+WHERE sv.sv_id = a.sv_id;
+
+ALTER TABLE "service_version_artifacts" ALTER COLUMN "user_uuid" SET NOT NULL;
+ALTER TABLE "service_version_artifacts" DROP CONSTRAINT "service_version_artifacts_digest_key";
+ALTER TABLE "service_version_artifacts" ADD CONSTRAINT uq_service_version_artifacts_user_uuid_digest UNIQUE ("user_uuid", "digest");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- The migration fails when users have artifacts with the same digest, they have to be deleted first
+ALTER TABLE "service_version_artifacts" DROP CONSTRAINT uq_service_version_artifacts_user_uuid_digest;
+ALTER TABLE "service_version_artifacts" ADD CONSTRAINT "service_version_artifacts_digest_key" UNIQUE ("digest");
+ALTER TABLE "service_version_artifacts" DROP COLUMN "user_uuid";
+-- +goose StatementEnd
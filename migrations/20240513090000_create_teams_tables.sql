@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "teams" (
+  "team_id" SERIAL PRIMARY KEY,
+  "name" VARCHAR(100) NOT NULL,
+  "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE "team_members" (
+  "team_id" INTEGER NOT NULL REFERENCES "teams" ("team_id") ON DELETE CASCADE,
+  "user_uuid" UUID NOT NULL REFERENCES "users" ("user_uuid"),
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("team_id", "user_uuid")
+);
+CREATE INDEX idx_team_members_user_uuid ON team_members (user_uuid);
+
+-- A webhook of a team gets the events of the services of all its members
+ALTER TABLE "webhooks" ADD COLUMN "team_id" INTEGER REFERENCES "teams" ("team_id");
+CREATE INDEX idx_webhooks_team_id ON webhooks (team_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_webhooks_team_id;
+ALTER TABLE "webhooks" DROP COLUMN "team_id";
+DROP TABLE "team_members";
+DROP TABLE "teams";
+-- +goose StatementEnd
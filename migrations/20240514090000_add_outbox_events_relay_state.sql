@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The sinks an event was published to, so that an event is not published again to them when another sink fails
+ALTER TABLE "outbox_events" ADD COLUMN "published_sinks" TEXT NOT NULL DEFAULT '';
+-- A claimed event is hidden from the other relays until the lease ends
+ALTER TABLE "outbox_events" ADD COLUMN "claimed_until" TIMESTAMP;
+ALTER TABLE "outbox_events" ADD COLUMN "next_attempt_at" TIMESTAMP;
+-- An event which failed to be published too many times is dead, it is not published anymore
+ALTER TABLE "outbox_events" ADD COLUMN "dead_at" TIMESTAMP;
+
+DROP INDEX IF EXISTS idx_outbox_events_unpublished;
+CREATE INDEX idx_outbox_events_pending ON outbox_events (outbox_id) WHERE published_at IS NULL AND dead_at IS NULL;
+CREATE INDEX idx_outbox_events_pending_service_id ON outbox_events (service_id, outbox_id) WHERE published_at IS NULL AND dead_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_outbox_events_pending_service_id;
+DROP INDEX IF EXISTS idx_outbox_events_pending;
+CREATE INDEX idx_outbox_events_unpublished ON outbox_events (outbox_id) WHERE published_at IS NULL;
+
+ALTER TABLE "outbox_events" DROP COLUMN "dead_at";
+ALTER TABLE "outbox_events" DROP COLUMN "next_attempt_at";
+ALTER TABLE "outbox_events" DROP COLUMN "claimed_until";
+ALTER TABLE "outbox_events" DROP COLUMN "published_sinks";
+-- +goose StatementEnd
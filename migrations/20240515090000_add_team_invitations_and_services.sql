@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A user added to a team is invited until it accepts, the creators of the existing teams are the only members who accepted
+ALTER TABLE "team_members" ADD COLUMN "accepted_at" TIMESTAMP;
+UPDATE team_members SET accepted_at = created_at
+WHERE EXISTS (
+  SELECT 1 FROM audit_events a
+  WHERE a.action = 'team.created' AND a.target_type = 'team' AND a.target_id = CAST(team_members.team_id AS VARCHAR(255))
+    AND a.actor_uuid = team_members.user_uuid
+);
+
+-- A webhook of a team gets the events of the services attached to the team by their owners
+ALTER TABLE "services" ADD COLUMN "team_id" INTEGER REFERENCES "teams" ("team_id");
+CREATE INDEX idx_services_team_id ON services (team_id);
+
+-- The team of the service when the event was recorded
+ALTER TABLE "outbox_events" ADD COLUMN "team_id" INTEGER;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "outbox_events" DROP COLUMN "team_id";
+DROP INDEX IF EXISTS idx_services_team_id;
+ALTER TABLE "services" DROP COLUMN "team_id";
+ALTER TABLE "team_members" DROP COLUMN "accepted_at";
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The position of an event in the event stream, assigned in the order the events are published so that an event
+-- published after a retry is replayed to the clients which received later events. The events published before keep
+-- their outbox id as position so that the clients resume their streams where they left them.
+ALTER TABLE "outbox_events" ADD COLUMN "published_seq" BIGINT;
+UPDATE outbox_events SET published_seq = outbox_id WHERE published_at IS NOT NULL;
+
+CREATE UNIQUE INDEX idx_outbox_events_published_seq ON outbox_events (published_seq);
+CREATE INDEX idx_outbox_events_user_uuid_published_seq ON outbox_events (user_uuid, published_seq) WHERE published_seq IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_outbox_events_user_uuid_published_seq;
+DROP INDEX IF EXISTS idx_outbox_events_published_seq;
+ALTER TABLE "outbox_events" DROP COLUMN "published_seq";
+-- +goose StatementEnd
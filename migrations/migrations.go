@@ -0,0 +1,8 @@
+// Package migrations embeds the goose migrations of the schema into the binary.
+// The migrations of SQLite are in the sqlite directory, they have the same versions as the Postgres ones.
+package migrations
+
+import "embed"
+
+//go:embed *.sql sqlite/*.sql
+var FS embed.FS
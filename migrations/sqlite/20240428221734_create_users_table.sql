@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "users" (
+  "user_uuid" TEXT PRIMARY KEY,
+  "email" VARCHAR(50) UNIQUE NOT NULL,
+  "password" VARCHAR(255) NOT NULL,
+  "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE "users";
+-- +goose StatementEnd
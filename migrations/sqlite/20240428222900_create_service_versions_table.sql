@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "service_versions" (
+  "sv_id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "version" VARCHAR(64),
+  "changelog" TEXT,
+  "service_id" INTEGER NOT NULL REFERENCES "services" ("service_id"),
+  "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE "service_versions";
+-- +goose StatementEnd
@@ -0,0 +1,4 @@
+-- +goose Up
+-- SQLite cannot add a foreign key to a table, it is declared with the table
+
+-- +goose Down
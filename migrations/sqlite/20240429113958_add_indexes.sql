@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX idx_user_uuid ON services (user_uuid);
+CREATE INDEX idx_sv_name_and_service_id ON service_versions (version, service_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_user_uuid;
+DROP INDEX idx_sv_name_and_service_id;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SQLite cannot add a foreign key to a table, it is declared with the table
+CREATE INDEX idx_sva_sv_id ON service_version_artifacts (sv_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_sva_sv_id;
+-- +goose StatementEnd
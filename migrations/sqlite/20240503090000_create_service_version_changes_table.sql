@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The version of service_versions is declared as a VARCHAR(64) with the table, SQLite does not enforce the length
+CREATE TABLE "service_version_changes" (
+  "change_id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "sv_id" INTEGER NOT NULL REFERENCES "service_versions" ("sv_id"),
+  "category" VARCHAR(12) NOT NULL,
+  "description" TEXT NOT NULL,
+  "position" INTEGER NOT NULL DEFAULT 0,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE "service_version_changes";
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "service_entities" (
+  "service_id" INTEGER PRIMARY KEY REFERENCES "services" ("service_id"),
+  "api_version" VARCHAR(100) NOT NULL,
+  "kind" VARCHAR(20) NOT NULL,
+  "metadata" TEXT NOT NULL,
+  "spec" TEXT NOT NULL,
+  "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE "service_entities";
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "webhook_deliveries" (
+  "delivery_id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "webhook_id" INTEGER NOT NULL REFERENCES "webhooks" ("webhook_id"),
+  "event_id" TEXT NOT NULL,
+  "event" VARCHAR(50) NOT NULL,
+  "payload" TEXT NOT NULL,
+  "status" VARCHAR(20) NOT NULL DEFAULT 'pending',
+  "attempts" INTEGER NOT NULL DEFAULT 0,
+  "next_attempt_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "response_status" INTEGER,
+  "response_body" TEXT,
+  "error" TEXT,
+  "delivered_at" TIMESTAMP,
+  "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE "webhook_deliveries";
+-- +goose StatementEnd
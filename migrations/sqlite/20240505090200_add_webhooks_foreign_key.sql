@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SQLite cannot add a foreign key to a table, they are declared with the tables
+CREATE INDEX idx_webhooks_user_uuid ON webhooks (user_uuid);
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, delivery_id);
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_webhook_deliveries_pending;
+DROP INDEX idx_webhook_deliveries_webhook_id;
+DROP INDEX idx_webhooks_user_uuid;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "idempotency_keys" (
+  "user_uuid" TEXT NOT NULL,
+  "idempotency_key" VARCHAR(255) NOT NULL,
+  "method" VARCHAR(10) NOT NULL,
+  "path" TEXT NOT NULL,
+  "request_hash" VARCHAR(64) NOT NULL,
+  "status_code" INTEGER,
+  "content_type" TEXT,
+  "response_body" BLOB,
+  "created_at" TIMESTAMP NOT NULL,
+  PRIMARY KEY ("user_uuid", "idempotency_key")
+);
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE "idempotency_keys";
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "audit_events" (
+  "audit_id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "actor_uuid" TEXT NOT NULL,
+  "action" VARCHAR(50) NOT NULL,
+  "target_type" VARCHAR(50) NOT NULL,
+  "target_id" VARCHAR(255) NOT NULL,
+  "before" TEXT,
+  "after" TEXT,
+  "request_id" VARCHAR(255) NOT NULL DEFAULT '',
+  "client_ip" VARCHAR(45) NOT NULL DEFAULT '',
+  "prev_hash" VARCHAR(64) NOT NULL,
+  "hash" VARCHAR(64) UNIQUE NOT NULL,
+  "created_at" TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_audit_events_actor_uuid ON audit_events (actor_uuid, audit_id);
+
+-- The audit log is append-only
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+BEGIN
+  SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+
+CREATE TRIGGER audit_events_no_delete BEFORE DELETE ON audit_events
+BEGIN
+  SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE "audit_events";
+-- +goose StatementEnd
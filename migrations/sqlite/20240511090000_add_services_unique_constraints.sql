@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A service with the same version twice keeps the oldest one, the artifacts of the others and their changes which
+-- it does not have are moved to it
+CREATE TEMPORARY TABLE duplicate_service_versions AS
+SELECT sv.sv_id, (SELECT MIN(k.sv_id) FROM service_versions k WHERE k.service_id = sv.service_id AND k.version = sv.version) AS kept_sv_id
+FROM service_versions sv
+WHERE EXISTS (SELECT 1 FROM service_versions k WHERE k.service_id = sv.service_id AND k.version = sv.version AND k.sv_id < sv.sv_id);
+
+DELETE FROM service_version_changes
+WHERE EXISTS (
+  SELECT 1 FROM duplicate_service_versions d
+  JOIN service_version_changes k ON k.sv_id = d.kept_sv_id
+  WHERE d.sv_id = service_version_changes.sv_id AND k.category = service_version_changes.category AND k.description = service_version_changes.description
+);
+UPDATE service_version_changes
+SET sv_id = (SELECT d.kept_sv_id FROM duplicate_service_versions d WHERE d.sv_id = service_version_changes.sv_id)
+WHERE sv_id IN (SELECT sv_id FROM duplicate_service_versions);
+UPDATE service_version_artifacts
+SET sv_id = (SELECT d.kept_sv_id FROM duplicate_service_versions d WHERE d.sv_id = service_version_artifacts.sv_id)
+WHERE sv_id IN (SELECT sv_id FROM duplicate_service_versions);
+DELETE FROM service_versions WHERE sv_id IN (SELECT sv_id FROM duplicate_service_versions);
+DROP TABLE duplicate_service_versions;
+
+-- A user with services with the same name keeps the name for the oldest one, the others are suffixed with their id
+UPDATE services SET name = substr(name, 1, 240) || ' (' || service_id || ')'
+WHERE EXISTS (SELECT 1 FROM services k WHERE k.user_uuid = services.user_uuid AND k.name = services.name AND k.service_id < services.service_id);
+
+CREATE UNIQUE INDEX uq_services_user_uuid_name ON services (user_uuid, name);
+CREATE UNIQUE INDEX uq_service_versions_service_id_version ON service_versions (service_id, version);
+
+-- SQLite cannot change the foreign keys of a table and the tables cannot be rebuilt while they are referenced,
+-- so the deletes are cascaded by triggers. They run before the delete so that the foreign keys hold.
+CREATE TRIGGER cascade_services_delete BEFORE DELETE ON services
+BEGIN
+  DELETE FROM service_entities WHERE service_id = OLD.service_id;
+  DELETE FROM service_versions WHERE service_id = OLD.service_id;
+END;
+
+CREATE TRIGGER cascade_service_versions_delete BEFORE DELETE ON service_versions
+BEGIN
+  DELETE FROM service_version_artifacts WHERE sv_id = OLD.sv_id;
+  DELETE FROM service_version_changes WHERE sv_id = OLD.sv_id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER cascade_service_versions_delete;
+DROP TRIGGER cascade_services_delete;
+DROP INDEX uq_service_versions_service_id_version;
+DROP INDEX uq_services_user_uuid_name;
+-- +goose StatementEnd
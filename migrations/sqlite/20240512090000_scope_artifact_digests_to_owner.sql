@@ -0,0 +1,65 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SQLite cannot drop the unique constraint of a column, so the table is rebuilt. The trigger deleting the artifacts
+-- is created again once the table is renamed, SQLite does not rename a table while a trigger references a missing one.
+DROP TRIGGER cascade_service_versions_delete;
+
+CREATE TABLE "service_version_artifacts_scoped" (
+  "artifact_id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "sv_id" INTEGER NOT NULL REFERENCES "service_versions" ("sv_id"),
+  "user_uuid" TEXT NOT NULL,
+  "type" VARCHAR(20) NOT NULL,
+  "uri" TEXT NOT NULL,
+  "digest" VARCHAR(71) NOT NULL,
+  "size_bytes" BIGINT NOT NULL,
+  "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT uq_service_version_artifacts_user_uuid_digest UNIQUE ("user_uuid", "digest")
+);
+
+INSERT INTO "service_version_artifacts_scoped" (artifact_id, sv_id, user_uuid, type, uri, digest, size_bytes, updated_at, created_at)
+SELECT a.artifact_id, a.sv_id, s.user_uuid, a.type, a.uri, a.digest, a.size_bytes, a.updated_at, a.created_at
+FROM "service_version_artifacts" a
+JOIN "service_versions" sv ON sv.sv_id = a.sv_id
+JOIN "services" s ON s.service_id = sv.service_id;
+
+DROP TABLE "service_version_artifacts";
+ALTER TABLE "service_version_artifacts_scoped" RENAME TO "service_version_artifacts";
+CREATE INDEX idx_sva_sv_id ON service_version_artifacts (sv_id);
+
+CREATE TRIGGER cascade_service_versions_delete BEFORE DELETE ON service_versions
+BEGIN
+  DELETE FROM service_version_artifacts WHERE sv_id = OLD.sv_id;
+  DELETE FROM service_version_changes WHERE sv_id = OLD.sv_id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- The migration fails when users have artifacts with the same digest, they have to be deleted first
+DROP TRIGGER cascade_service_versions_delete;
+
+CREATE TABLE "service_version_artifacts_global" (
+  "artifact_id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "sv_id" INTEGER NOT NULL REFERENCES "service_versions" ("sv_id"),
+  "type" VARCHAR(20) NOT NULL,
+  "uri" TEXT NOT NULL,
+  "digest" VARCHAR(71) UNIQUE NOT NULL,
+  "size_bytes" BIGINT NOT NULL,
+  "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO "service_version_artifacts_global" (artifact_id, sv_id, type, uri, digest, size_bytes, updated_at, created_at)
+SELECT artifact_id, sv_id, type, uri, digest, size_bytes, updated_at, created_at FROM "service_version_artifacts";
+
+DROP TABLE "service_version_artifacts";
+ALTER TABLE "service_version_artifacts_global" RENAME TO "service_version_artifacts";
+CREATE INDEX idx_sva_sv_id ON service_version_artifacts (sv_id);
+
+CREATE TRIGGER cascade_service_versions_delete BEFORE DELETE ON service_versions
+BEGIN
+  DELETE FROM service_version_artifacts WHERE sv_id = OLD.sv_id;
+  DELETE FROM service_version_changes WHERE sv_id = OLD.sv_id;
+END;
+-- +goose StatementEnd